@@ -10,6 +10,9 @@ type Program interface {
 	Window() Window
 	Time() float64
 	Terminate()
+	// Saves compiled shader programs in dir, so later starts can load them instead of compiling
+	// Returns an error if the graphics driver does not support it, programs are still cached in memory either way
+	SetProgramCacheDir(dir string) error
 }
 
 type Window interface {
@@ -0,0 +1,362 @@
+// On-screen debug overlay showing fps, a frame time graph, render stats and custom values
+// Nothing is made or drawn before the overlay is enabled, so it can be left in release builds
+package overlay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/render/gl"
+	"github.com/eliiasg/deltawing/graphics/render/presets"
+	"github.com/eliiasg/deltawing/graphics/text"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+	"github.com/eliiasg/deltawing/input"
+	"github.com/eliiasg/deltawing/util/buffers"
+)
+
+// amount of frames in the graph
+const graphFrames = 120
+
+// frame times above these are drawn yellow and red
+const (
+	goodFrameTime = time.Second / 60
+	okFrameTime   = time.Second / 30
+)
+
+type watch struct {
+	name  string
+	value func() any
+}
+
+type Overlay struct {
+	// Nothing is drawn or measured while this is false
+	Enabled bool
+	// Key that toggles the overlay when using Listen or KeyPressed, defaults to F3
+	ToggleKey input.Key
+	// Top left corner of the overlay in pixels
+	X, Y float32
+	// Height of a line of text in pixels, defaults to 16
+	TextSize   float32
+	TextColor  color.Color
+	Background color.Color
+	// Layer of the background, everything else is drawn at Layer+1, defaults to the highest layer so the overlay is on top
+	Layer uint32
+	// Pixels per millisecond in the frame time graph, defaults to 2
+	GraphScale float32
+
+	renderer render.Renderer
+	glyphs   *text.GlyphBuffer
+	watches  []watch
+	// ring buffer of frame times
+	frameTimes [graphFrames]time.Duration
+	frames     int
+	lastFrame  time.Time
+	// made on the first enabled Draw
+	objects *objects
+}
+
+// everything made by the overlay
+type objects struct {
+	text    *text.TextRenderer
+	textOp  render.Operation
+	textPre *presets.TextPreset
+	textPro render.Procedure
+	// the background and bars use the same procedure and buffer, but are drawn at different layers
+	rectPre    *presets.Preset
+	rectPro    render.Procedure
+	background render.Operation
+	bars       render.Operation
+	rectData   render.DataBuffer
+	square     render.SpriteBuffer
+}
+
+// glyphs is used for the text, it is not freed with the overlay
+func New(renderer render.Renderer, glyphs *text.GlyphBuffer) *Overlay {
+	return &Overlay{
+		ToggleKey:  input.KeyF3,
+		X:          4,
+		Y:          4,
+		TextSize:   16,
+		TextColor:  color.White(),
+		Background: color.FromRGBA(24, 24, 24, 255),
+		// (Layer+1)*256 + 256 must fit in the 24 bit depth buffer
+		Layer:      65533,
+		GraphScale: 2,
+		renderer:   renderer,
+		glyphs:     glyphs,
+		watches:    make([]watch, 0),
+	}
+}
+
+// Sets the key pressed handler of the keyboard to toggle the overlay
+// This replaces any other handler, call KeyPressed from your own handler instead if you need one
+func (o *Overlay) Listen(keyboard input.Keyboard) {
+	keyboard.SetKeyPressedHandler(o.KeyPressed)
+}
+
+// Toggles the overlay if key is ToggleKey
+func (o *Overlay) KeyPressed(key input.Key) {
+	if key == o.ToggleKey {
+		o.Toggle()
+	}
+}
+
+func (o *Overlay) Toggle() {
+	o.Enabled = !o.Enabled
+	// old frame times would be from before the overlay was hidden
+	o.frames = 0
+	o.lastFrame = time.Time{}
+}
+
+// Shows a value on the overlay, value is only called when the overlay is drawn
+// Using the name of an existing watch replaces it
+func (o *Overlay) Watch(name string, value func() any) {
+	for i, w := range o.watches {
+		if w.name == name {
+			o.watches[i].value = value
+			return
+		}
+	}
+	o.watches = append(o.watches, watch{name, value})
+}
+
+func (o *Overlay) Unwatch(name string) {
+	for i, w := range o.watches {
+		if w.name == name {
+			o.watches = append(o.watches[:i], o.watches[i+1:]...)
+			return
+		}
+	}
+}
+
+// Should be called once every frame after everything else is drawn, usually with the primary RenderTarget
+// Does nothing if the overlay is disabled
+func (o *Overlay) Draw(target render.RenderTarget) error {
+	if !o.Enabled {
+		return nil
+	}
+	o.measure()
+	if o.objects == nil {
+		obj, err := o.makeObjects()
+		if err != nil {
+			return err
+		}
+		o.objects = obj
+	}
+	return o.draw(target)
+}
+
+// Frees everything made by the overlay, it is made again if the overlay is drawn afterwards
+func (o *Overlay) Free() {
+	if o.objects == nil {
+		return
+	}
+	o.objects.free()
+	o.objects = nil
+}
+
+// also used if makeObjects fails halfway
+func (obj *objects) free() {
+	if obj.text != nil {
+		obj.text.Free()
+	}
+	for _, object := range []render.RendererObject{obj.textOp, obj.textPro, obj.background, obj.bars, obj.rectPro, obj.rectData, obj.square} {
+		if object != nil {
+			object.Free()
+		}
+	}
+}
+
+func (o *Overlay) measure() {
+	now := time.Now()
+	if !o.lastFrame.IsZero() {
+		o.frameTimes[o.frames%graphFrames] = now.Sub(o.lastFrame)
+		o.frames++
+	}
+	o.lastFrame = now
+}
+
+// frame times in the order they happened
+func (o *Overlay) history() []time.Duration {
+	if o.frames < graphFrames {
+		return o.frameTimes[:o.frames]
+	}
+	start := o.frames % graphFrames
+	return append(o.frameTimes[start:], o.frameTimes[:start]...)
+}
+
+func (o *Overlay) makeObjects() (*objects, error) {
+	obj := new(objects)
+	var err error
+	if obj.textPre, err = presets.Text(o.renderer); err != nil {
+		return nil, err
+	}
+	if obj.textPro, err = obj.textPre.Finish(); err != nil {
+		return nil, err
+	}
+	if obj.textOp, err = o.renderer.MakeOperation(obj.textPro); err != nil {
+		obj.free()
+		return nil, err
+	}
+	obj.text = &text.TextRenderer{
+		Operation:       obj.textOp,
+		PositionChannel: obj.textPre.Attributes[0],
+		GlyphBuffer:     o.glyphs,
+		DefaultGlyph:    '?',
+		LineSpacing:     1.2,
+		SpaceSpacing:    0.3,
+	}
+	obj.text.Init(o.renderer)
+
+	if obj.rectPre, err = presets.Tinted(o.renderer); err == nil {
+		obj.rectPro, err = obj.rectPre.Finish()
+	}
+	if err != nil {
+		obj.free()
+		return nil, err
+	}
+	if obj.background, err = o.renderer.MakeOperation(obj.rectPro); err == nil {
+		obj.bars, err = o.renderer.MakeOperation(obj.rectPro)
+	}
+	if err != nil {
+		obj.free()
+		return nil, err
+	}
+	obj.rectData = obj.rectPre.MakeDataBuffer(o.renderer, false)
+	builder := o.renderer.MakeSpriteBufferBuilder()
+	builder.AddSprite(square())
+	obj.square = builder.MakeBuffer(true)
+	return obj, nil
+}
+
+// 1 by 1 white square with the origin in the bottom left, scaled to make rectangles
+func square() *vecsprite.VecSprite {
+	return &vecsprite.VecSprite{
+		Vertices: [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+		Colors:   []color.Color{color.White(), color.White(), color.White(), color.White()},
+		Layers:   []uint8{0, 0, 0, 0},
+		Indices:  []uint32{0, 1, 2, 0, 2, 3},
+	}
+}
+
+func (o *Overlay) content() string {
+	var sb strings.Builder
+	history := o.history()
+	if len(history) > 0 {
+		var sum time.Duration
+		for _, t := range history {
+			sum += t
+		}
+		avg := sum / time.Duration(len(history))
+		fmt.Fprintf(&sb, "FPS: %.1f (%.2f ms)", float64(time.Second)/float64(avg), float64(avg)/float64(time.Millisecond))
+	} else {
+		sb.WriteString("FPS: -")
+	}
+	// the overlay itself is included in the stats
+	if r, ok := gl.GLRenderer(o.renderer); ok {
+		last := r.Stats().Last
+		fmt.Fprintf(&sb, "\ndraws: %v, instances: %v, triangles: %v", last.DrawCalls, last.Instances, last.Triangles)
+		fmt.Fprintf(&sb, "\nuploaded: %v B, program switches: %v", last.BytesUploaded, last.ProgramSwitches)
+	}
+	for _, w := range o.watches {
+		fmt.Fprintf(&sb, "\n%v: %v", w.name, w.value())
+	}
+	return sb.String()
+}
+
+func frameColor(t time.Duration) color.Color {
+	switch {
+	case t <= goodFrameTime:
+		return color.FromRGBA(80, 220, 80, 255)
+	case t <= okFrameTime:
+		return color.FromRGBA(230, 200, 60, 255)
+	}
+	return color.FromRGBA(230, 70, 60, 255)
+}
+
+func (o *Overlay) draw(target render.RenderTarget) error {
+	obj := o.objects
+	const padding = 4
+	str := o.content()
+	lines := strings.Count(str, "\n") + 1
+	obj.text.Clear()
+	obj.text.AddText(0, 0, str)
+	obj.text.UpdateText()
+
+	// graph below the text, bars grow upwards from the bottom
+	graphHeight := float32(okFrameTime.Milliseconds()) * o.GraphScale
+	graphX := o.X + padding
+	graphY := o.Y + padding + (float32(lines-1)*obj.text.LineSpacing+1.3)*o.TextSize + padding + graphHeight
+	width := obj.text.StringWidth(str) * o.TextSize
+	if width < graphFrames {
+		width = graphFrames
+	}
+
+	data := make([]uint32, 0)
+	buffers.AddAll(&data, presets.TintedInstance{
+		Position: [2]float32{o.X, graphY + padding},
+		Scale:    [2]float32{width + padding*2, graphY + padding - o.Y},
+		Tint:     o.Background,
+	})
+	bars := uint32(1)
+	for i, t := range o.history() {
+		height := float32(t) / float32(time.Millisecond) * o.GraphScale
+		if height > graphHeight {
+			height = graphHeight
+		}
+		buffers.AddAll(&data, presets.TintedInstance{
+			Position: [2]float32{graphX + float32(i), graphY},
+			Scale:    [2]float32{1, height},
+			Tint:     frameColor(t),
+		})
+		bars++
+	}
+	// line at 60 fps
+	buffers.AddAll(&data, presets.TintedInstance{
+		Position: [2]float32{graphX, graphY - float32(goodFrameTime)/float32(time.Millisecond)*o.GraphScale},
+		Scale:    [2]float32{graphFrames, 1},
+		Tint:     color.FromRGBA(160, 160, 160, 255),
+	})
+	obj.rectData.SetData32(data)
+
+	if err := o.drawRects(obj.background, target, 0, 1, o.Layer); err != nil {
+		return err
+	}
+	if err := o.drawRects(obj.bars, target, 1, bars, o.Layer+1); err != nil {
+		return err
+	}
+
+	textOp := obj.textOp
+	if err := textOp.SetChannelValue(obj.textPre.Offset, [2]float32{o.X + padding, o.Y + padding}); err != nil {
+		return err
+	}
+	if err := textOp.SetChannelValue(obj.textPre.Size, o.TextSize); err != nil {
+		return err
+	}
+	c := o.TextColor
+	if err := textOp.SetChannelValue(obj.textPre.TextColor, [4]int32{int32(c.R), int32(c.G), int32(c.B), int32(c.A)}); err != nil {
+		return err
+	}
+	if err := textOp.SetChannelValue(obj.textPre.Layer, o.Layer+1); err != nil {
+		return err
+	}
+	return obj.text.DrawTo(target)
+}
+
+func (o *Overlay) drawRects(op render.Operation, target render.RenderTarget, offset, amount uint32, layer uint32) error {
+	obj := o.objects
+	if err := obj.rectPre.SetBuffer(op, obj.rectData, offset); err != nil {
+		return err
+	}
+	if err := op.SetSprite(obj.square, 0); err != nil {
+		return err
+	}
+	if err := op.SetChannelValue(obj.rectPre.Layer, layer); err != nil {
+		return err
+	}
+	op.SetAmount(amount)
+	return op.DrawTo(target)
+}
@@ -0,0 +1,99 @@
+package path
+
+import "math"
+
+// Elliptical arc from the current point to x, y like the A command in SVG, rotation is the angle of the x axis of the ellipse in radians
+// large picks the arc going more than half way around, sweep picks the arc going counterclockwise when y is up
+// Radii that are too small to reach x, y are scaled up, and a radius of 0 gives a line
+func (p *Path) ArcTo(rx, ry, rotation float32, large, sweep bool, x, y float32) {
+	p.arcTo(math.Abs(float64(rx)), math.Abs(float64(ry)), float64(rotation), large, sweep, pt(x, y))
+}
+
+// see https://www.w3.org/TR/SVG/implnote.html#ArcImplementationNotes
+func (p *Path) arcTo(rx, ry, rotation float64, large, sweep bool, end [2]float64) {
+	cur := p.current
+	if cur == end {
+		return
+	}
+	if rx == 0 || ry == 0 {
+		p.add(lineTo, end)
+		return
+	}
+	sinR, cosR := math.Sincos(rotation)
+	// the midpoint between the ends, in the coordinates of the ellipse
+	dx, dy := (cur[0]-end[0])/2, (cur[1]-end[1])/2
+	x1 := cosR*dx + sinR*dy
+	y1 := -sinR*dx + cosR*dy
+	// radii that are too small are scaled up until the ellipse reaches both ends
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx *= math.Sqrt(l)
+		ry *= math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(max(num/den, 0))
+	if large == sweep {
+		coef = -coef
+	}
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+	center := [2]float64{cosR*cx1 - sinR*cy1 + (cur[0]+end[0])/2, sinR*cx1 + cosR*cy1 + (cur[1]+end[1])/2}
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+	p.ellipseArc(center, rx, ry, rotation, theta, delta)
+	// avoids a tiny gap from rounding
+	p.add(lineTo, end)
+}
+
+// Circular arc around cx, cy, angles are in radians counterclockwise from the x axis when y is up
+// A line is added from the current point to the start of the arc like in HTML canvas, or a new subpath is started if the path is empty
+func (p *Path) Arc(cx, cy, r, start, sweep float32) {
+	p.EllipseArc(cx, cy, r, r, 0, start, sweep)
+}
+
+// Like Arc, but with different radii and the x axis of the ellipse rotated by rotation
+func (p *Path) EllipseArc(cx, cy, rx, ry, rotation, start, sweep float32) {
+	center := pt(cx, cy)
+	r := [2]float64{float64(rx), float64(ry)}
+	s, c := math.Sincos(float64(start))
+	sinR, cosR := math.Sincos(float64(rotation))
+	first := add(center, [2]float64{cosR*c*r[0] - sinR*s*r[1], sinR*c*r[0] + cosR*s*r[1]})
+	if p.Empty() {
+		p.start = first
+		p.add(moveTo, first)
+	} else if first != p.current {
+		p.add(lineTo, first)
+	}
+	p.ellipseArc(center, r[0], r[1], float64(rotation), float64(start), float64(sweep))
+}
+
+// Adds part of an ellipse as cubic curves, at most a quarter of the ellipse per curve
+// The path must already be at the start of the arc
+func (p *Path) ellipseArc(center [2]float64, rx, ry, rotation, start, sweep float64) {
+	if sweep == 0 {
+		return
+	}
+	segments := int(math.Ceil(math.Abs(sweep) / (math.Pi / 2)))
+	step := sweep / float64(segments)
+	// length of the control points of a cubic curve approximating an arc of the step
+	k := 4.0 / 3.0 * math.Tan(step/4)
+	sinR, cosR := math.Sincos(rotation)
+	point := func(x, y float64) [2]float64 {
+		return [2]float64{center[0] + cosR*x*rx - sinR*y*ry, center[1] + sinR*x*rx + cosR*y*ry}
+	}
+	for i := 0; i < segments; i++ {
+		a0 := start + step*float64(i)
+		a1 := a0 + step
+		s0, c0 := math.Sincos(a0)
+		s1, c1 := math.Sincos(a1)
+		p.add(cubicTo, point(c0-k*s0, s0+k*c0), point(c1+k*s1, s1-k*c1), point(c1, s1))
+	}
+}
@@ -0,0 +1,287 @@
+package path
+
+import (
+	"math"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+// Boolean operation used by Combine
+type Op uint8
+
+const (
+	// Everything in either sprite
+	Union Op = iota
+	// Only where the sprites overlap
+	Intersection
+	// The first sprite without the second
+	Difference
+	// Where exactly one of the sprites is
+	Xor
+)
+
+func (o Op) inside(a, b bool) bool {
+	switch o {
+	case Union:
+		return a || b
+	case Intersection:
+		return a && b
+	case Difference:
+		return a && !b
+	}
+	return a != b
+}
+
+// A triangle of one of the sprites given to Combine
+type sourceTriangle struct {
+	points [3][2]float64
+	colors [3]color.Color
+	// the highest layer of the vertices
+	layer uint8
+	// 0 for the first sprite, 1 for the second
+	operand int
+}
+
+// true if t is drawn on top of other, triangles later in the list are on top of earlier ones at the same layer
+func (t *sourceTriangle) above(other *sourceTriangle, tIdx, otherIdx int) bool {
+	if t.layer != other.layer {
+		return t.layer > other.layer
+	}
+	return tIdx > otherIdx
+}
+
+// interpolates the colors of the vertices, points outside the triangle get the color of the closest part of it
+func (t *sourceTriangle) colorAt(p [2]float64) color.Color {
+	a, b, c := t.points[0], t.points[1], t.points[2]
+	det := cross(sub(b, a), sub(c, a))
+	w1 := cross(sub(p, a), sub(c, a)) / det
+	w2 := cross(sub(b, a), sub(p, a)) / det
+	w := [3]float64{max(1-w1-w2, 0), max(w1, 0), max(w2, 0)}
+	sum := w[0] + w[1] + w[2]
+	channel := func(get func(c color.Color) uint8) uint8 {
+		v := 0.0
+		for i, c := range t.colors {
+			v += float64(get(c)) * w[i]
+		}
+		return uint8(min(math.Round(v/sum), 255))
+	}
+	return color.Color{
+		R: channel(func(c color.Color) uint8 { return c.R }),
+		G: channel(func(c color.Color) uint8 { return c.G }),
+		B: channel(func(c color.Color) uint8 { return c.B }),
+		A: channel(func(c color.Color) uint8 { return c.A }),
+	}
+}
+
+func sourceTriangles(sprite *vecsprite.VecSprite, operand int, res []sourceTriangle) []sourceTriangle {
+	for i := 0; i+2 < len(sprite.Indices); i += 3 {
+		t := sourceTriangle{operand: operand}
+		for j := 0; j < 3; j++ {
+			idx := sprite.Indices[i+j]
+			v := sprite.Vertices[idx]
+			t.points[j] = pt(v[0], v[1])
+			t.colors[j] = sprite.Colors[idx]
+			t.layer = max(t.layer, sprite.Layers[idx])
+		}
+		// triangles without area cover nothing
+		if cross(sub(t.points[1], t.points[0]), sub(t.points[2], t.points[0])) == 0 {
+			continue
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+// Returns a new sprite covering the area given by op, a and b are not changed
+// Every part of the result keeps the color and layer of the triangle that was visible there, so overlapping triangles are removed
+// For Intersection and Difference only the triangles of a are used for colors, so b can be a plain shape like a circle from Path.FillSprite
+// For Union and Xor triangles of b are on top of triangles of a on the same layer, like when drawing b after a
+// Paths can be combined by filling them into sprites first
+// Touching parts of the result with the same color and layer are merged, but the result still has more triangles than the sprites
+// where triangles with different colors overlap, or where their edges cut each other, so combining results again makes it grow
+func Combine(a, b *vecsprite.VecSprite, op Op) (*vecsprite.VecSprite, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	tris := sourceTriangles(a, 0, make([]sourceTriangle, 0, (len(a.Indices)+len(b.Indices))/3))
+	tris = sourceTriangles(b, 1, tris)
+	edges := make([]*edge, 0, len(tris)*3)
+	ys := make([]float64, 0, len(tris)*3)
+	for i, t := range tris {
+		for j, p := range t.points {
+			ys = append(ys, p[1])
+			if e := makeEdge(p, t.points[(j+1)%3]); e != nil {
+				e.owner = i
+				edges = append(edges, e)
+			}
+		}
+	}
+	res := newSpriteBuilder()
+	// triangles covering the current cell, and how many of them are from each sprite
+	inside := make([]bool, len(tris))
+	covering := make([]int, 0)
+	// trapezoids of the previous slab, which can be made longer if the same ones continue in the next slab
+	previous := make([]trapezoid, 0)
+	current := make([]trapezoid, 0)
+	sweep(edges, ys, func(y0, y1 float64, active []*edge) {
+		count := [2]int{}
+		covering = covering[:0]
+		current = current[:0]
+		for i, e := range active {
+			// triangles are convex, so every edge enters or leaves its triangle
+			t := e.owner
+			inside[t] = !inside[t]
+			if inside[t] {
+				covering = append(covering, t)
+				count[tris[t].operand]++
+			} else {
+				for j, c := range covering {
+					if c == t {
+						covering = append(covering[:j], covering[j+1:]...)
+						break
+					}
+				}
+				count[tris[t].operand]--
+			}
+			if i+1 == len(active) || !op.inside(count[0] > 0, count[1] > 0) {
+				continue
+			}
+			right := active[i+1]
+			// edges at the same place, like the shared edge of two triangles
+			if float32(e.xAt(y0)) == float32(right.xAt(y0)) && float32(e.xAt(y1)) == float32(right.xAt(y1)) {
+				continue
+			}
+			// the visible triangle decides the color and layer
+			top := -1
+			for _, c := range covering {
+				if (op == Intersection || op == Difference) && tris[c].operand != 0 {
+					continue
+				}
+				if top == -1 || tris[c].above(&tris[top], c, top) {
+					top = c
+				}
+			}
+			trap := trapezoid{&tris[top], tris[top].paint(top), e, right, y0, y1}
+			// next to the previous trapezoid with the same paint, so they can be one
+			if n := len(current); n > 0 && current[n-1].paint == trap.paint && current[n-1].right.sameAt(e, y0, y1) {
+				current[n-1].right = right
+				continue
+			}
+			current = append(current, trap)
+		}
+		// every triangle is left at the end of the slab, but rounding could leave some inside
+		for _, c := range covering {
+			inside[c] = false
+		}
+		// trapezoids that continue one from the previous slab start where that one started
+		for i := range current {
+			for j, prev := range previous {
+				if prev.y1 == y0 && prev.paint == current[i].paint && prev.left.sameLine(current[i].left) && prev.right.sameLine(current[i].right) {
+					current[i].y0, current[i].left, current[i].right = prev.y0, prev.left, prev.right
+					previous = append(previous[:j], previous[j+1:]...)
+					break
+				}
+			}
+		}
+		for _, prev := range previous {
+			res.trapezoid(prev.t, prev.left, prev.right, prev.y0, prev.y1)
+		}
+		previous, current = current, previous
+	})
+	for _, prev := range previous {
+		res.trapezoid(prev.t, prev.left, prev.right, prev.y0, prev.y1)
+	}
+	return res.sprite, nil
+}
+
+// part of the result that is not added to the sprite yet, since it might continue in the next slab
+type trapezoid struct {
+	t           *sourceTriangle
+	paint       paint
+	left, right *edge
+	y0, y1      float64
+}
+
+// parts of the result with the same paint look the same, so they can be merged
+type paint struct {
+	color color.Color
+	layer uint8
+	// index of the triangle if the color is not the same at every vertex, -1 otherwise
+	triangle int
+}
+
+func (t *sourceTriangle) paint(idx int) paint {
+	if t.colors[0] == t.colors[1] && t.colors[1] == t.colors[2] {
+		return paint{t.colors[0], t.layer, -1}
+	}
+	return paint{layer: t.layer, triangle: idx}
+}
+
+// true if the edges are at the same place from y0 to y1
+func (e *edge) sameAt(other *edge, y0, y1 float64) bool {
+	return float32(e.xAt(y0)) == float32(other.xAt(y0)) && float32(e.xAt(y1)) == float32(other.xAt(y1))
+}
+
+// true if the edges are parts of the same line
+func (e *edge) sameLine(other *edge) bool {
+	return e == other || (e.slope == other.slope && e.xAt(other.top[1]) == other.top[0])
+}
+
+// makes a sprite where vertices are shared if they have the same position, color and layer
+type spriteBuilder struct {
+	sprite *vecsprite.VecSprite
+	ids    map[spriteVertex]uint32
+}
+
+type spriteVertex struct {
+	pos   [2]float32
+	color color.Color
+	layer uint8
+}
+
+func newSpriteBuilder() *spriteBuilder {
+	return &spriteBuilder{
+		sprite: &vecsprite.VecSprite{
+			Vertices: make([][2]float32, 0),
+			Colors:   make([]color.Color, 0),
+			Layers:   make([]uint8, 0),
+			Indices:  make([]uint32, 0),
+		},
+		ids: make(map[spriteVertex]uint32),
+	}
+}
+
+func (b *spriteBuilder) vertex(t *sourceTriangle, x, y float64) uint32 {
+	v := spriteVertex{[2]float32{float32(x), float32(y)}, t.colorAt([2]float64{x, y}), t.layer}
+	if id, ok := b.ids[v]; ok {
+		return id
+	}
+	s := b.sprite
+	id := uint32(len(s.Vertices))
+	s.Vertices = append(s.Vertices, v.pos)
+	s.Colors = append(s.Colors, v.color)
+	s.Layers = append(s.Layers, v.layer)
+	b.ids[v] = id
+	return id
+}
+
+// the area between the edges from y0 to y1 colored like t, top or bottom may have no width
+func (b *spriteBuilder) trapezoid(t *sourceTriangle, left, right *edge, y0, y1 float64) {
+	x0l, x0r := left.xAt(y0), right.xAt(y0)
+	x1l, x1r := left.xAt(y1), right.xAt(y1)
+	if float32(x0l) >= float32(x0r) && float32(x1l) >= float32(x1r) {
+		return
+	}
+	tl, tr := b.vertex(t, x0l, y0), b.vertex(t, x0r, y0)
+	bl, br := b.vertex(t, x1l, y1), b.vertex(t, x1r, y1)
+	if float32(x0l) < float32(x0r) {
+		b.sprite.Indices = append(b.sprite.Indices, tl, tr, br)
+	}
+	if float32(x1l) < float32(x1r) {
+		b.sprite.Indices = append(b.sprite.Indices, tl, br, bl)
+	}
+}
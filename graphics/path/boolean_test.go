@@ -0,0 +1,164 @@
+package path
+
+import (
+	"math"
+	"testing"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+func rectSprite(x, y, w, h float32, c color.Color, layer uint8) *vecsprite.VecSprite {
+	p := New()
+	p.Rect(x, y, w, h)
+	sprite := p.FillSprite(NonZero, 0, c)
+	for i := range sprite.Layers {
+		sprite.Layers[i] = layer
+	}
+	return sprite
+}
+
+func circleSprite(x, y, r float32) *vecsprite.VecSprite {
+	p := New()
+	p.Circle(x, y, r)
+	return p.FillSprite(NonZero, 0.01, color.White())
+}
+
+// combines the sprites, checks that the result is valid and returns it
+func combine(t *testing.T, a, b *vecsprite.VecSprite, op Op) *vecsprite.VecSprite {
+	t.Helper()
+	res, err := Combine(a, b, op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestCombine(t *testing.T) {
+	a := rectSprite(0, 0, 10, 10, color.White(), 0)
+	b := rectSprite(5, 5, 10, 10, color.White(), 0)
+	far := rectSprite(20, 0, 10, 10, color.White(), 0)
+	// two circles with a radius of 10, 10 apart, the overlap is two segments with a chord of 2 sqrt(75)
+	circle := area(circleSprite(0, 0, 10).Vertices, circleSprite(0, 0, 10).Indices)
+	lens := 2 * (100*math.Acos(0.5) - 5*math.Sqrt(75))
+	ca, cb := circleSprite(0, 0, 10), circleSprite(10, 0, 10)
+	tests := []struct {
+		name string
+		a, b *vecsprite.VecSprite
+		op   Op
+		area float64
+		tol  float64
+	}{
+		{"union", a, b, Union, 175, 0.01},
+		{"intersection", a, b, Intersection, 25, 0.01},
+		{"difference", a, b, Difference, 75, 0.01},
+		{"xor", a, b, Xor, 150, 0.01},
+		{"separate union", a, far, Union, 200, 0.01},
+		{"separate intersection", a, far, Intersection, 0, 0.01},
+		{"separate difference", a, far, Difference, 100, 0.01},
+		{"same", a, a, Xor, 0, 0.01},
+		// the circles are flattened, so the areas are only close
+		{"circle union", ca, cb, Union, 2*circle - lens, 0.5},
+		{"circle intersection", ca, cb, Intersection, lens, 0.5},
+		{"circle difference", ca, cb, Difference, circle - lens, 0.5},
+		{"circle xor", ca, cb, Xor, 2 * (circle - lens), 0.5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := combine(t, test.a, test.b, test.op)
+			if a := area(res.Vertices, res.Indices); math.Abs(a-test.area) > test.tol {
+				t.Fatalf("Area is %v, expected %v", a, test.area)
+			}
+		})
+	}
+}
+
+func TestCombineColorAndLayer(t *testing.T) {
+	red := color.FromRGBA(255, 0, 0, 255)
+	blue := color.FromRGBA(0, 0, 255, 255)
+	// the color and layer of the vertices at x 0 and x 15, which are only in a and only in b
+	check := func(t *testing.T, res *vecsprite.VecSprite, aColor, bColor color.Color, aLayer, bLayer uint8, overlap color.Color) {
+		t.Helper()
+		for i, v := range res.Vertices {
+			c, layer := res.Colors[i], res.Layers[i]
+			switch {
+			case v[0] == 0 && v[1] < 5 && (c != aColor || layer != aLayer):
+				t.Fatalf("Vertex %v of a has color %v and layer %v", v, c.ToRGBA(), layer)
+			case v[0] == 15 && (c != bColor || layer != bLayer):
+				t.Fatalf("Vertex %v of b has color %v and layer %v", v, c.ToRGBA(), layer)
+			}
+		}
+		// the middle of the overlap is at 7.5, 7.5
+		for i := 0; i < len(res.Indices); i += 3 {
+			if !containsPoint(res, res.Indices[i:i+3], [2]float32{7.5, 7.5}) {
+				continue
+			}
+			if c := res.Colors[res.Indices[i]]; c != overlap {
+				t.Fatalf("Overlap has color %v, expected %v", c.ToRGBA(), overlap.ToRGBA())
+			}
+			return
+		}
+		t.Fatal("Overlap is not covered")
+	}
+	t.Run("b on top", func(t *testing.T) {
+		res := combine(t, rectSprite(0, 0, 10, 10, red, 1), rectSprite(5, 5, 10, 10, blue, 1), Union)
+		check(t, res, red, blue, 1, 1, blue)
+	})
+	t.Run("higher layer on top", func(t *testing.T) {
+		res := combine(t, rectSprite(0, 0, 10, 10, red, 2), rectSprite(5, 5, 10, 10, blue, 1), Union)
+		check(t, res, red, blue, 2, 1, red)
+	})
+	t.Run("intersection uses a", func(t *testing.T) {
+		res := combine(t, rectSprite(0, 0, 10, 10, red, 0), rectSprite(5, 5, 10, 10, blue, 3), Intersection)
+		for i := range res.Vertices {
+			if res.Colors[i] != red || res.Layers[i] != 0 {
+				t.Fatalf("Vertex %v has color %v and layer %v", res.Vertices[i], res.Colors[i].ToRGBA(), res.Layers[i])
+			}
+		}
+	})
+	t.Run("gradient", func(t *testing.T) {
+		// a keeps its colors where it is not covered
+		a := rectSprite(0, 0, 10, 10, red, 0)
+		for i, v := range a.Vertices {
+			if v[0] == 0 {
+				a.Colors[i] = blue
+			}
+		}
+		res := combine(t, a, rectSprite(5, 5, 10, 10, red, 0), Difference)
+		for i, v := range res.Vertices {
+			if (v[0] == 0 && res.Colors[i] != blue) || (v[0] == 10 && res.Colors[i] != red) {
+				t.Fatalf("Vertex %v has color %v", v, res.Colors[i].ToRGBA())
+			}
+		}
+	})
+}
+
+func containsPoint(sprite *vecsprite.VecSprite, tri []uint32, p [2]float32) bool {
+	side := func(a, b [2]float32) float32 {
+		return (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+	}
+	a, b, c := sprite.Vertices[tri[0]], sprite.Vertices[tri[1]], sprite.Vertices[tri[2]]
+	s0, s1, s2 := side(a, b), side(b, c), side(c, a)
+	return (s0 >= 0 && s1 >= 0 && s2 >= 0) || (s0 <= 0 && s1 <= 0 && s2 <= 0)
+}
+
+func TestCombineMerges(t *testing.T) {
+	// the same color everywhere, so the union of two rects only needs a few trapezoids
+	res := combine(t, rectSprite(0, 0, 10, 10, color.White(), 0), rectSprite(5, 5, 10, 10, color.White(), 0), Union)
+	if n := len(res.Indices) / 3; n > 6 {
+		t.Fatalf("Union of two rects has %v triangles", n)
+	}
+	// combining again should not make more triangles than the sprites have together
+	a, b := circleSprite(0, 0, 10), circleSprite(10, 0, 10)
+	union := combine(t, a, b, Union)
+	again := combine(t, union, b, Difference)
+	if n, limit := len(again.Indices), len(a.Indices)+len(b.Indices); n > limit {
+		t.Fatalf("Difference of the union has %v indices, the circles have %v", n, limit)
+	}
+	if _, err := Combine(a, &vecsprite.VecSprite{Indices: []uint32{0, 1, 2}}, Union); err == nil {
+		t.Fatal("Combined with an invalid sprite")
+	}
+}
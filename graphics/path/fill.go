@@ -0,0 +1,169 @@
+package path
+
+import (
+	"math"
+	"sort"
+)
+
+// Part of the outline going up or down, horizontal lines are not needed to find the inside
+type edge struct {
+	// top has the smaller y
+	top, bottom [2]float64
+	// 1 if the outline goes from top to bottom, -1 otherwise
+	dir int
+	// change in x per y
+	slope float64
+	// triangle the edge is from, only used by boolean operations
+	owner int
+}
+
+// nil if a and b are at the same height
+func makeEdge(a, b [2]float64) *edge {
+	if a[1] == b[1] {
+		return nil
+	}
+	e := &edge{top: a, bottom: b, dir: 1}
+	if a[1] > b[1] {
+		e.top, e.bottom, e.dir = b, a, -1
+	}
+	e.slope = (e.bottom[0] - e.top[0]) / (e.bottom[1] - e.top[1])
+	return e
+}
+
+func (e *edge) xAt(y float64) float64 {
+	return e.top[0] + (y-e.top[1])*e.slope
+}
+
+// Returns triangles covering the inside of the path, every subpath is closed
+// Outlines may overlap themselves and each other, the fill rule decides what is inside
+func (p *Path) Triangulate(rule FillRule, tolerance float32) (vertices [][2]float32, indices []uint32) {
+	return triangulate(p.flatten(float64(tolerance)), rule)
+}
+
+// The outlines are cut into horizontal slabs at every point and crossing, so no edges cross inside a slab
+// Then the inside of every slab is found by going from left to right and counting the edges, giving trapezoids
+func triangulate(lines []polyline, rule FillRule) ([][2]float32, []uint32) {
+	edges := make([]*edge, 0)
+	ys := make([]float64, 0)
+	for _, line := range lines {
+		points := line.points
+		for i, a := range points {
+			ys = append(ys, a[1])
+			if e := makeEdge(a, points[(i+1)%len(points)]); e != nil {
+				edges = append(edges, e)
+			}
+		}
+	}
+	t := newTriangles()
+	sweep(edges, ys, func(y0, y1 float64, active []*edge) {
+		winding := 0
+		var left *edge
+		for _, e := range active {
+			before := rule.inside(winding)
+			winding += e.dir
+			after := rule.inside(winding)
+			if !before && after {
+				left = e
+			} else if before && !after {
+				t.trapezoid(left, e, y0, y1)
+			}
+		}
+	})
+	return t.vertices, t.indices
+}
+
+// Cuts the edges into horizontal slabs at every y in ys and every crossing, so no edges cross inside a slab
+// f is called for every slab from the bottom up with the edges covering it sorted from left to right, active is reused between calls
+func sweep(edges []*edge, ys []float64, f func(y0, y1 float64, active []*edge)) {
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].top[1] < edges[j].top[1]
+	})
+	ys = append(ys, crossings(edges)...)
+	sort.Float64s(ys)
+
+	active := make([]*edge, 0)
+	next := 0
+	for i := 0; i+1 < len(ys); i++ {
+		y0, y1 := ys[i], ys[i+1]
+		if y0 == y1 {
+			continue
+		}
+		// edges covering the slab, every top and bottom is in ys so edges never end inside a slab
+		kept := active[:0]
+		for _, e := range active {
+			if e.bottom[1] > y0 {
+				kept = append(kept, e)
+			}
+		}
+		active = kept
+		for next < len(edges) && edges[next].top[1] <= y0 {
+			if edges[next].bottom[1] > y0 {
+				active = append(active, edges[next])
+			}
+			next++
+		}
+		mid := (y0 + y1) / 2
+		sort.Slice(active, func(i, j int) bool {
+			return active[i].xAt(mid) < active[j].xAt(mid)
+		})
+		f(y0, y1, active)
+	}
+}
+
+// y of every point where two edges cross
+func crossings(edges []*edge) []float64 {
+	res := make([]float64, 0)
+	for i, a := range edges {
+		// sorted by top, so no edge after this can reach a
+		for _, b := range edges[i+1:] {
+			if b.top[1] >= a.bottom[1] {
+				break
+			}
+			y0 := max(a.top[1], b.top[1])
+			y1 := min(a.bottom[1], b.bottom[1])
+			d0 := a.xAt(y0) - b.xAt(y0)
+			d1 := a.xAt(y1) - b.xAt(y1)
+			if (d0 < 0 && d1 > 0) || (d0 > 0 && d1 < 0) {
+				y := y0 + (y1-y0)*d0/(d0-d1)
+				if !math.IsNaN(y) {
+					res = append(res, y)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// triangles with shared vertices
+type triangles struct {
+	vertices [][2]float32
+	indices  []uint32
+	ids      map[[2]float32]uint32
+}
+
+func newTriangles() *triangles {
+	return &triangles{make([][2]float32, 0), make([]uint32, 0), make(map[[2]float32]uint32)}
+}
+
+func (t *triangles) vertex(x, y float64) uint32 {
+	v := [2]float32{float32(x), float32(y)}
+	if id, ok := t.ids[v]; ok {
+		return id
+	}
+	id := uint32(len(t.vertices))
+	t.vertices = append(t.vertices, v)
+	t.ids[v] = id
+	return id
+}
+
+// the area between the edges from y0 to y1, top or bottom may have no width
+func (t *triangles) trapezoid(left, right *edge, y0, y1 float64) {
+	tl, tr := t.vertex(left.xAt(y0), y0), t.vertex(right.xAt(y0), y0)
+	bl, br := t.vertex(left.xAt(y1), y1), t.vertex(right.xAt(y1), y1)
+	if tl != tr {
+		t.indices = append(t.indices, tl, tr, br)
+	}
+	if bl != br {
+		t.indices = append(t.indices, tl, br, bl)
+	}
+}
@@ -0,0 +1,158 @@
+package path
+
+import "math"
+
+// A subpath turned into lines
+type polyline struct {
+	points [][2]float64
+	// true if the subpath ended with Close
+	closed bool
+}
+
+func sub(a, b [2]float64) [2]float64 {
+	return [2]float64{a[0] - b[0], a[1] - b[1]}
+}
+
+func add(a, b [2]float64) [2]float64 {
+	return [2]float64{a[0] + b[0], a[1] + b[1]}
+}
+
+func mul(a [2]float64, s float64) [2]float64 {
+	return [2]float64{a[0] * s, a[1] * s}
+}
+
+func length(a [2]float64) float64 {
+	return math.Hypot(a[0], a[1])
+}
+
+func cross(a, b [2]float64) float64 {
+	return a[0]*b[1] - a[1]*b[0]
+}
+
+func dot(a, b [2]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1]
+}
+
+// upper limit of segments per curve, so huge curves with tiny tolerances do not use all memory
+const maxCurveSegments = 1024
+
+// amount of lines needed for a curve, where deviation is the largest second difference of the control points
+// the distance between a curve and its lines is at most deviation * c / n^2, c depends on the kind of curve
+func curveSegments(deviation, c, tolerance float64) int {
+	n := math.Ceil(math.Sqrt(deviation * c / tolerance))
+	if math.IsNaN(n) || n < 1 {
+		return 1
+	}
+	return int(min(n, maxCurveSegments))
+}
+
+// Turns every subpath into lines, curves are split so no point is further than tolerance from the curve
+func (p *Path) flatten(tolerance float64) []polyline {
+	if tolerance <= 0 {
+		tolerance = DefaultTolerance
+	}
+	res := make([]*polyline, 0)
+	var cur *polyline
+	// subpaths without a MoveTo start at the end of the previous one
+	var last [2]float64
+	ensure := func() {
+		if cur == nil {
+			cur = &polyline{points: [][2]float64{last}}
+			res = append(res, cur)
+		}
+	}
+	for _, c := range p.commands {
+		switch c.kind {
+		case moveTo:
+			cur = &polyline{points: [][2]float64{c.points[0]}}
+			res = append(res, cur)
+			last = c.points[0]
+		case lineTo:
+			ensure()
+			cur.points = append(cur.points, c.points[0])
+		case quadTo:
+			ensure()
+			p0 := cur.points[len(cur.points)-1]
+			p1, p2 := c.points[0], c.points[1]
+			// quadratics are off by at most |p0 - 2p1 + p2| / (4n^2)
+			n := curveSegments(length(add(sub(p0, mul(p1, 2)), p2)), 0.25, tolerance)
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				u := 1 - t
+				cur.points = append(cur.points, add(add(mul(p0, u*u), mul(p1, 2*u*t)), mul(p2, t*t)))
+			}
+		case cubicTo:
+			ensure()
+			p0 := cur.points[len(cur.points)-1]
+			p1, p2, p3 := c.points[0], c.points[1], c.points[2]
+			// cubics are off by at most 3/4 * max(|p0 - 2p1 + p2|, |p1 - 2p2 + p3|) / n^2
+			d := max(length(add(sub(p0, mul(p1, 2)), p2)), length(add(sub(p1, mul(p2, 2)), p3)))
+			n := curveSegments(d, 0.75, tolerance)
+			for i := 1; i <= n; i++ {
+				t := float64(i) / float64(n)
+				u := 1 - t
+				point := add(add(mul(p0, u*u*u), mul(p1, 3*u*u*t)), add(mul(p2, 3*u*t*t), mul(p3, t*t*t)))
+				cur.points = append(cur.points, point)
+			}
+		case closePath:
+			if cur != nil {
+				cur.closed = true
+				last = cur.points[0]
+			}
+			cur = nil
+		}
+		if cur != nil {
+			last = cur.points[len(cur.points)-1]
+		}
+	}
+	lines := make([]polyline, 0, len(res))
+	for _, line := range res {
+		line.points = removeDuplicates(line.points, line.closed)
+		lines = append(lines, *line)
+	}
+	return lines
+}
+
+// A subpath turned into lines by Path.Flatten
+type Polyline struct {
+	Points [][2]float32
+	// True if the subpath ended with Close, the start is not repeated at the end
+	Closed bool
+}
+
+// Turns every subpath into lines, curves are split so no point is further than tolerance from the curve
+// Repeated points are removed, so every line has a direction
+func (p *Path) Flatten(tolerance float32) []Polyline {
+	lines := p.flatten(float64(tolerance))
+	res := make([]Polyline, len(lines))
+	for i, line := range lines {
+		points := make([][2]float32, 0, len(line.points))
+		for _, point := range line.points {
+			// points close together can be the same after rounding
+			p := [2]float32{float32(point[0]), float32(point[1])}
+			if len(points) == 0 || p != points[len(points)-1] {
+				points = append(points, p)
+			}
+		}
+		if line.closed && len(points) > 1 && points[0] == points[len(points)-1] {
+			points = points[:len(points)-1]
+		}
+		res[i] = Polyline{points, line.closed}
+	}
+	return res
+}
+
+// repeated points would give lines without a direction
+func removeDuplicates(points [][2]float64, closed bool) [][2]float64 {
+	res := points[:0]
+	for i, point := range points {
+		if i == 0 || point != res[len(res)-1] {
+			res = append(res, point)
+		}
+	}
+	// the end of closed subpaths is usually the start again
+	if closed && len(res) > 1 && res[0] == res[len(res)-1] {
+		res = res[:len(res)-1]
+	}
+	return res
+}
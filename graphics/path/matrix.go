@@ -0,0 +1,68 @@
+package path
+
+import "math"
+
+// Affine transform, x' = a*x + c*y + e and y' = b*x + d*y + f like in SVG
+type Matrix [6]float32
+
+func Identity() Matrix {
+	return Matrix{1, 0, 0, 1, 0, 0}
+}
+
+func Translate(x, y float32) Matrix {
+	return Matrix{1, 0, 0, 1, x, y}
+}
+
+func Scale(x, y float32) Matrix {
+	return Matrix{x, 0, 0, y, 0, 0}
+}
+
+// Rotates counterclockwise when y is up, angle is in radians
+func Rotate(angle float32) Matrix {
+	s, c := math.Sincos(float64(angle))
+	return Matrix{float32(c), float32(s), float32(-s), float32(c), 0, 0}
+}
+
+// Skews along the x axis, angle is in radians
+func SkewX(angle float32) Matrix {
+	return Matrix{1, 0, float32(math.Tan(float64(angle))), 1, 0, 0}
+}
+
+// Skews along the y axis, angle is in radians
+func SkewY(angle float32) Matrix {
+	return Matrix{1, float32(math.Tan(float64(angle))), 0, 1, 0, 0}
+}
+
+// Returns the transform that applies other first and then m
+func (m Matrix) Multiply(other Matrix) Matrix {
+	a, o := m, other
+	return Matrix{
+		a[0]*o[0] + a[2]*o[1],
+		a[1]*o[0] + a[3]*o[1],
+		a[0]*o[2] + a[2]*o[3],
+		a[1]*o[2] + a[3]*o[3],
+		a[0]*o[4] + a[2]*o[5] + a[4],
+		a[1]*o[4] + a[3]*o[5] + a[5],
+	}
+}
+
+func (m Matrix) Apply(x, y float32) (float32, float32) {
+	res := m.apply(pt(x, y))
+	return float32(res[0]), float32(res[1])
+}
+
+func (m Matrix) apply(p [2]float64) [2]float64 {
+	return [2]float64{
+		float64(m[0])*p[0] + float64(m[2])*p[1] + float64(m[4]),
+		float64(m[1])*p[0] + float64(m[3])*p[1] + float64(m[5]),
+	}
+}
+
+// Largest amount the transform scales lengths by, used to keep tolerances in the same units after transforming
+func (m Matrix) MaxScale() float32 {
+	// largest singular value of the 2x2 part
+	a, b, c, d := float64(m[0]), float64(m[1]), float64(m[2]), float64(m[3])
+	sum := a*a + b*b + c*c + d*d
+	det := a*d - b*c
+	return float32(math.Sqrt((sum + math.Sqrt(max(sum*sum-4*det*det, 0))) / 2))
+}
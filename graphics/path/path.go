@@ -0,0 +1,152 @@
+// Paths made of lines and curves, tessellated into triangles for VecSprites
+// Coordinates are the same as in VecSprite, so y is up
+package path
+
+import (
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+// Maximum distance in sprite units between a curve and the lines it is turned into, used when no tolerance is given
+const DefaultTolerance = 0.1
+
+// Decides what is inside a path where it overlaps itself
+type FillRule uint8
+
+const (
+	// Inside if the path goes around the point more times in one direction than the other, so holes must go the opposite way of their outline
+	NonZero FillRule = iota
+	// Inside if the path goes around the point an odd amount of times, so every outline inside another one is a hole
+	EvenOdd
+)
+
+func (r FillRule) inside(winding int) bool {
+	if r == EvenOdd {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
+
+type commandKind uint8
+
+const (
+	moveTo commandKind = iota
+	lineTo
+	quadTo
+	cubicTo
+	closePath
+)
+
+type command struct {
+	kind commandKind
+	// control points followed by the end point, only the first few are used
+	points [3][2]float64
+}
+
+// A series of subpaths, every MoveTo starts a new one
+// Subpaths are always closed when filled, but only closed with Close when stroked
+type Path struct {
+	commands []command
+	// start of the current subpath and the end of the latest command
+	start, current [2]float64
+}
+
+func New() *Path {
+	return &Path{commands: make([]command, 0)}
+}
+
+func pt(x, y float32) [2]float64 {
+	return [2]float64{float64(x), float64(y)}
+}
+
+func (p *Path) add(kind commandKind, points ...[2]float64) {
+	c := command{kind: kind}
+	copy(c.points[:], points)
+	p.commands = append(p.commands, c)
+	if len(points) > 0 {
+		p.current = points[len(points)-1]
+	}
+}
+
+// Starts a new subpath at x, y
+func (p *Path) MoveTo(x, y float32) {
+	p.start = pt(x, y)
+	p.add(moveTo, p.start)
+}
+
+// Paths without a MoveTo start at (0, 0) like in SVG
+func (p *Path) LineTo(x, y float32) {
+	p.add(lineTo, pt(x, y))
+}
+
+// Quadratic bezier curve with the control point cx, cy
+func (p *Path) QuadTo(cx, cy, x, y float32) {
+	p.add(quadTo, pt(cx, cy), pt(x, y))
+}
+
+// Cubic bezier curve with the control points c1 and c2
+func (p *Path) CubicTo(c1x, c1y, c2x, c2y, x, y float32) {
+	p.add(cubicTo, pt(c1x, c1y), pt(c2x, c2y), pt(x, y))
+}
+
+// Closes the current subpath with a line to its start, the next command starts at the same point
+func (p *Path) Close() {
+	p.add(closePath)
+	p.current = p.start
+}
+
+// Returns the end of the latest command
+func (p *Path) Current() (x, y float32) {
+	return float32(p.current[0]), float32(p.current[1])
+}
+
+// Returns true if nothing has been added to the path
+func (p *Path) Empty() bool {
+	return len(p.commands) == 0
+}
+
+// Adds every subpath of other to p
+func (p *Path) Append(other *Path) {
+	p.commands = append(p.commands, other.commands...)
+	p.start, p.current = other.start, other.current
+}
+
+// Returns a copy of the path with every point transformed, curves stay exact since transforms are affine
+func (p *Path) Transformed(m Matrix) *Path {
+	res := &Path{commands: make([]command, len(p.commands))}
+	for i, c := range p.commands {
+		for j := range c.points {
+			c.points[j] = m.apply(c.points[j])
+		}
+		res.commands[i] = c
+	}
+	res.start, res.current = m.apply(p.start), m.apply(p.current)
+	return res
+}
+
+// Tessellates the inside of the path and adds the triangles to the sprite, every vertex gets the color and layer
+// sprite may be an empty VecSprite, tolerance is the maximum distance between curves and the lines used for them
+func (p *Path) Fill(sprite *vecsprite.VecSprite, rule FillRule, tolerance float32, c color.Color, layer uint8) {
+	vertices, indices := p.Triangulate(rule, tolerance)
+	AddTriangles(sprite, vertices, indices, c, layer)
+}
+
+// Returns a new sprite with only the inside of the path, on layer 0
+func (p *Path) FillSprite(rule FillRule, tolerance float32, c color.Color) *vecsprite.VecSprite {
+	sprite := &vecsprite.VecSprite{}
+	p.Fill(sprite, rule, tolerance, c, 0)
+	return sprite
+}
+
+// Adds triangles to the sprite, with the same color and layer for every vertex
+func AddTriangles(sprite *vecsprite.VecSprite, vertices [][2]float32, indices []uint32, c color.Color, layer uint8) {
+	start := uint32(len(sprite.Vertices))
+	for _, vert := range vertices {
+		sprite.Vertices = append(sprite.Vertices, vert)
+		sprite.Colors = append(sprite.Colors, c)
+		sprite.Layers = append(sprite.Layers, layer)
+	}
+	for _, idx := range indices {
+		sprite.Indices = append(sprite.Indices, idx+start)
+	}
+}
@@ -0,0 +1,259 @@
+package path
+
+import (
+	"math"
+	"testing"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+)
+
+// sum of the areas of the triangles, overlapping triangles would be counted twice
+func area(vertices [][2]float32, indices []uint32) float64 {
+	res := 0.0
+	for i := 0; i < len(indices); i += 3 {
+		a, b, c := vertices[indices[i]], vertices[indices[i+1]], vertices[indices[i+2]]
+		res += math.Abs(float64((b[0]-a[0])*(c[1]-a[1])-(c[0]-a[0])*(b[1]-a[1]))) / 2
+	}
+	return res
+}
+
+// fills the path into a sprite, checks that it is valid and returns the area
+func fillArea(t *testing.T, p *Path, rule FillRule, tolerance float32) float64 {
+	t.Helper()
+	sprite := p.FillSprite(rule, tolerance, color.White())
+	if err := sprite.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, layer := range sprite.Layers {
+		if layer != 0 {
+			t.Fatalf("Vertex has layer %v", layer)
+		}
+	}
+	return area(sprite.Vertices, sprite.Indices)
+}
+
+// five points with every second one connected, the pentagon in the middle is inside twice
+func pentagram(r float32) *Path {
+	p := New()
+	for i := 0; i < 5; i++ {
+		s, c := math.Sincos(math.Pi/2 + 4*math.Pi*float64(i)/5)
+		if i == 0 {
+			p.MoveTo(r*float32(c), r*float32(s))
+		} else {
+			p.LineTo(r*float32(c), r*float32(s))
+		}
+	}
+	p.Close()
+	return p
+}
+
+func TestFill(t *testing.T) {
+	// circumradius of the pentagon in the middle of a pentagram with a radius of 10
+	inner := 10 * math.Cos(2*math.Pi/5) / math.Cos(math.Pi/5)
+	star := 10 * 10 * inner * math.Sin(math.Pi/5) / 2
+	pentagon := 5 * inner * inner * math.Sin(2*math.Pi/5) / 2
+	tests := []struct {
+		name             string
+		path             func(p *Path)
+		nonZero, evenOdd float64
+	}{
+		{"empty", func(p *Path) {}, 0, 0},
+		{"square", func(p *Path) { p.Rect(0, 0, 10, 10) }, 100, 100},
+		{"clockwise square", func(p *Path) { p.Polygon([][2]float32{{0, 0}, {0, 10}, {10, 10}, {10, 0}}, true) }, 100, 100},
+		{"not closed", func(p *Path) { p.Polygon([][2]float32{{0, 0}, {10, 0}, {10, 10}}, false) }, 50, 50},
+		{"line", func(p *Path) { p.Polygon([][2]float32{{0, 0}, {10, 0}}, false) }, 0, 0},
+		{"hole in the same direction", func(p *Path) {
+			p.Rect(0, 0, 10, 10)
+			p.Rect(2, 2, 6, 6)
+		}, 100, 64},
+		{"hole in the opposite direction", func(p *Path) {
+			p.Rect(0, 0, 10, 10)
+			p.Polygon([][2]float32{{2, 2}, {2, 8}, {8, 8}, {8, 2}}, true)
+		}, 64, 64},
+		{"overlapping", func(p *Path) {
+			p.Rect(0, 0, 10, 10)
+			p.Rect(5, 5, 10, 10)
+		}, 175, 150},
+		{"separate", func(p *Path) {
+			p.Rect(0, 0, 10, 10)
+			p.Rect(20, 0, 10, 10)
+		}, 200, 200},
+		{"pentagram", func(p *Path) { p.Append(pentagram(10)) }, star, star - pentagon},
+		{"bow tie", func(p *Path) { p.Polygon([][2]float32{{0, 0}, {10, 10}, {10, 0}, {0, 10}}, true) }, 50, 50},
+		{"rounded rect", func(p *Path) { p.RoundedRect(0, 0, 20, 10, 2, 2) }, 200 - (4-math.Pi)*4, 200 - (4-math.Pi)*4},
+		{"regular polygon", func(p *Path) { p.RegularPolygon(0, 0, 10, 6, 0) }, 3 * math.Sqrt(3) / 2 * 100, 3 * math.Sqrt(3) / 2 * 100},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := New()
+			test.path(p)
+			if a := fillArea(t, p, NonZero, 0.001); math.Abs(a-test.nonZero) > 0.01 {
+				t.Errorf("NonZero area is %v, expected %v", a, test.nonZero)
+			}
+			if a := fillArea(t, p, EvenOdd, 0.001); math.Abs(a-test.evenOdd) > 0.01 {
+				t.Errorf("EvenOdd area is %v, expected %v", a, test.evenOdd)
+			}
+		})
+	}
+}
+
+func TestFillLayerAndColor(t *testing.T) {
+	p := New()
+	p.Rect(0, 0, 1, 1)
+	sprite := p.FillSprite(NonZero, 0, color.White())
+	red := color.FromRGBA(255, 0, 0, 255)
+	p.Transformed(Translate(5, 0)).Fill(sprite, NonZero, 0, red, 3)
+	if err := sprite.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range sprite.Vertices {
+		second := v[0] >= 5
+		if second != (sprite.Layers[i] == 3) || second != (sprite.Colors[i] == red) {
+			t.Fatalf("Vertex %v has layer %v and color %v", v, sprite.Layers[i], sprite.Colors[i].ToRGBA())
+		}
+	}
+}
+
+func TestCurveTolerance(t *testing.T) {
+	for _, tolerance := range []float32{1, 0.1, 0.01} {
+		p := New()
+		p.Circle(0, 0, 10)
+		lines := p.Flatten(tolerance)
+		if len(lines) != 1 || !lines[0].Closed {
+			t.Fatalf("Circle was flattened to %v lines", len(lines))
+		}
+		points := lines[0].Points
+		for i, a := range points {
+			b := points[(i+1)%len(points)]
+			// the points are on the circle, and the middle of the lines is at most tolerance inside it
+			if d := math.Hypot(float64(a[0]), float64(a[1])); math.Abs(d-10) > 0.01 {
+				t.Fatalf("Point %v is %v from the center", a, d)
+			}
+			mid := math.Hypot(float64(a[0]+b[0])/2, float64(a[1]+b[1])/2)
+			if 10-mid > float64(tolerance) {
+				t.Fatalf("Line from %v to %v is %v from the circle with a tolerance of %v", a, b, 10-mid, tolerance)
+			}
+		}
+		// the lines are inside the circle, so the area is a bit smaller, by at most the perimeter times the tolerance
+		a := fillArea(t, p, NonZero, tolerance)
+		if exact := math.Pi * 100; a > exact || exact-a > 2*math.Pi*10*float64(tolerance) {
+			t.Fatalf("Area with a tolerance of %v is %v, expected %v", tolerance, a, exact)
+		}
+	}
+}
+
+func TestCurveSegmentLimit(t *testing.T) {
+	p := New()
+	p.MoveTo(0, 0)
+	p.QuadTo(1e6, 1e6, 2e6, 0)
+	lines := p.Flatten(1e-6)
+	if n := len(lines[0].Points); n > maxCurveSegments+1 {
+		t.Fatalf("Curve was split into %v points", n)
+	}
+}
+
+func TestCurves(t *testing.T) {
+	quad := New()
+	quad.MoveTo(0, 0)
+	quad.QuadTo(5, 10, 10, 0)
+	if a := fillArea(t, quad, NonZero, 0.001); math.Abs(a-100.0/3) > 0.01 {
+		t.Fatalf("Area under the quadratic curve is %v", a)
+	}
+	cubic := New()
+	cubic.MoveTo(0, 0)
+	cubic.CubicTo(0, 10, 10, 10, 10, 0)
+	if a := fillArea(t, cubic, NonZero, 0.001); math.Abs(a-60) > 0.01 {
+		t.Fatalf("Area under the cubic curve is %v", a)
+	}
+	if x, y := cubic.Current(); x != 10 || y != 0 {
+		t.Fatalf("Current point is %v, %v", x, y)
+	}
+}
+
+func TestArcTo(t *testing.T) {
+	// a chord of 10 on a circle with a radius of 10 is a sixth of the circle
+	segment := 50 * (math.Pi/3 - math.Sin(math.Pi/3))
+	height := 10 - 10*math.Sin(math.Pi/3)
+	tests := []struct {
+		name         string
+		r            float32
+		large, sweep bool
+		area         float64
+		minY, maxY   float64
+	}{
+		{"small counterclockwise", 10, false, true, segment, -height, 0},
+		{"small clockwise", 10, false, false, segment, 0, height},
+		{"large counterclockwise", 10, true, true, math.Pi*100 - segment, -20 + height, 0},
+		{"large clockwise", 10, true, false, math.Pi*100 - segment, 0, 20 - height},
+		// too small, so scaled up to a half circle
+		{"small radius", 1, false, true, math.Pi * 25 / 2, -5, 0},
+		{"small radius large", 1, true, false, math.Pi * 25 / 2, 0, 5},
+		{"no radius", 0, false, true, 0, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := New()
+			p.MoveTo(0, 0)
+			p.ArcTo(test.r, test.r, 0, test.large, test.sweep, 10, 0)
+			if x, y := p.Current(); x != 10 || y != 0 {
+				t.Fatalf("Arc ends at %v, %v", x, y)
+			}
+			p.Close()
+			if a := fillArea(t, p, NonZero, 0.001); math.Abs(a-test.area) > 0.01 {
+				t.Fatalf("Area is %v, expected %v", a, test.area)
+			}
+			minY, maxY := 0.0, 0.0
+			for _, point := range p.Flatten(0.001)[0].Points {
+				minY, maxY = min(minY, float64(point[1])), max(maxY, float64(point[1]))
+			}
+			if math.Abs(minY-test.minY) > 0.01 || math.Abs(maxY-test.maxY) > 0.01 {
+				t.Fatalf("Arc goes from y %v to %v, expected %v to %v", minY, maxY, test.minY, test.maxY)
+			}
+		})
+	}
+}
+
+// arcs are made of cubic curves, which are only close to the ellipse
+func closeToArc(a, exact float64) bool {
+	return math.Abs(a-exact) < exact/1000
+}
+
+func TestArcToRotated(t *testing.T) {
+	// half of an ellipse with the x axis rotated to be vertical, so the arc is 5 wide
+	p := New()
+	p.MoveTo(0, 0)
+	p.ArcTo(10, 5, math.Pi/2, false, true, 0, 20)
+	p.Close()
+	if a := fillArea(t, p, NonZero, 0.001); !closeToArc(a, math.Pi*50/2) {
+		t.Fatalf("Area is %v, expected %v", a, math.Pi*50/2)
+	}
+}
+
+func TestArc(t *testing.T) {
+	// a quarter of a circle, with a line from the center since the path is not empty
+	p := New()
+	p.MoveTo(0, 0)
+	p.Arc(0, 0, 10, 0, math.Pi/2)
+	if a := fillArea(t, p, NonZero, 0.001); !closeToArc(a, math.Pi*25) {
+		t.Fatalf("Area is %v, expected %v", a, math.Pi*25)
+	}
+	// clockwise, and starts a subpath since the path is empty
+	p = New()
+	p.Arc(0, 0, 10, 0, -math.Pi)
+	if a := fillArea(t, p, NonZero, 0.001); !closeToArc(a, math.Pi*50) {
+		t.Fatalf("Area is %v, expected %v", a, math.Pi*50)
+	}
+}
+
+func TestTransformed(t *testing.T) {
+	p := New()
+	p.Circle(0, 0, 1)
+	scaled := p.Transformed(Scale(2, 3).Multiply(Rotate(1)))
+	// area is multiplied by the determinant
+	if a := fillArea(t, scaled, NonZero, 0.001); math.Abs(a-6*math.Pi) > 0.01 {
+		t.Fatalf("Area is %v, expected %v", a, 6*math.Pi)
+	}
+	if a := fillArea(t, p, NonZero, 0.001); math.Abs(a-math.Pi) > 0.01 {
+		t.Fatalf("Transforming changed the original path, area is %v", a)
+	}
+}
@@ -0,0 +1,88 @@
+package path
+
+import "math"
+
+// Adds a closed rectangle as a new subpath, x, y is the corner with the smallest coordinates
+// Like every shape it goes counterclockwise when y is up, use EvenOdd to cut holes with shapes
+func (p *Path) Rect(x, y, width, height float32) {
+	p.MoveTo(x, y)
+	p.LineTo(x+width, y)
+	p.LineTo(x+width, y+height)
+	p.LineTo(x, y+height)
+	p.Close()
+}
+
+// Rectangle with elliptical corners, radii are limited to half of the size
+func (p *Path) RoundedRect(x, y, width, height, rx, ry float32) {
+	rx, ry = min(abs(rx), abs(width)/2), min(abs(ry), abs(height)/2)
+	if rx == 0 || ry == 0 {
+		p.Rect(x, y, width, height)
+		return
+	}
+	x0, y0, x1, y1 := float64(x), float64(y), float64(x+width), float64(y+height)
+	r := [2]float64{float64(rx), float64(ry)}
+	p.MoveTo(x+rx, y)
+	p.add(lineTo, [2]float64{x1 - r[0], y0})
+	p.ellipseArc([2]float64{x1 - r[0], y0 + r[1]}, r[0], r[1], 0, -math.Pi/2, math.Pi/2)
+	p.add(lineTo, [2]float64{x1, y1 - r[1]})
+	p.ellipseArc([2]float64{x1 - r[0], y1 - r[1]}, r[0], r[1], 0, 0, math.Pi/2)
+	p.add(lineTo, [2]float64{x0 + r[0], y1})
+	p.ellipseArc([2]float64{x0 + r[0], y1 - r[1]}, r[0], r[1], 0, math.Pi/2, math.Pi/2)
+	p.add(lineTo, [2]float64{x0, y0 + r[1]})
+	p.ellipseArc([2]float64{x0 + r[0], y0 + r[1]}, r[0], r[1], 0, math.Pi, math.Pi/2)
+	p.Close()
+}
+
+// Adds a closed circle as a new subpath
+func (p *Path) Circle(cx, cy, r float32) {
+	p.Ellipse(cx, cy, r, r, 0)
+}
+
+// Adds a closed ellipse as a new subpath, rotation is the angle of the x axis of the ellipse in radians
+func (p *Path) Ellipse(cx, cy, rx, ry, rotation float32) {
+	if rx == 0 || ry == 0 {
+		return
+	}
+	sinR, cosR := math.Sincos(float64(rotation))
+	p.MoveTo(cx+float32(cosR)*rx, cy+float32(sinR)*rx)
+	p.ellipseArc(pt(cx, cy), float64(rx), float64(ry), float64(rotation), 0, 2*math.Pi)
+	p.Close()
+}
+
+// Adds a polygon through the points as a new subpath, closed if closed is true
+func (p *Path) Polygon(points [][2]float32, closed bool) {
+	for i, point := range points {
+		if i == 0 {
+			p.MoveTo(point[0], point[1])
+		} else {
+			p.LineTo(point[0], point[1])
+		}
+	}
+	if closed && len(points) > 0 {
+		p.Close()
+	}
+}
+
+// Adds a regular polygon with the given amount of sides as a new subpath, the first corner is at angle radians
+func (p *Path) RegularPolygon(cx, cy, r float32, sides int, angle float32) {
+	if sides < 3 {
+		return
+	}
+	for i := 0; i < sides; i++ {
+		s, c := math.Sincos(float64(angle) + 2*math.Pi*float64(i)/float64(sides))
+		x, y := cx+r*float32(c), cy+r*float32(s)
+		if i == 0 {
+			p.MoveTo(x, y)
+		} else {
+			p.LineTo(x, y)
+		}
+	}
+	p.Close()
+}
+
+func abs(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
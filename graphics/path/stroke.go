@@ -0,0 +1,407 @@
+package path
+
+import (
+	"math"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+// Shape of the corners between lines in a stroke
+type Join uint8
+
+const (
+	JoinMiter Join = iota
+	JoinRound
+	JoinBevel
+)
+
+// Shape of the ends of open subpaths in a stroke
+type Cap uint8
+
+const (
+	// Ends exactly at the end of the line
+	CapButt Cap = iota
+	CapRound
+	// Continues half the width past the end of the line
+	CapSquare
+)
+
+type StrokeStyle struct {
+	Width float32
+	Join  Join
+	Cap   Cap
+	// Miter joins longer than MiterLimit times the width are drawn as bevels instead, 4 is used if this is 0 like in SVG
+	MiterLimit float32
+	// Lengths of dashes and the gaps between them, starting with a dash, an odd amount is repeated twice like in SVG
+	// The line is solid if this is empty, or if the lengths are not positive
+	// The pattern is stretched on subpaths where it would repeat more than 4096 times
+	Dashes []float32
+	// How far into the dash pattern the start of every subpath is
+	DashOffset float32
+	// Multiplies the width along every subpath, t goes from 0 at the start to 1 at the end, the width is the same everywhere if nil
+	// Dashes use t of the whole subpath, so a tapered line stays tapered when dashed
+	WidthScale func(t float32) float32
+}
+
+// Returns the outline of the stroke, which must be filled with NonZero since the parts of the outline overlap
+// Closed subpaths get a join where they start, open subpaths get caps
+func (p *Path) Stroke(style StrokeStyle, tolerance float32) *Path {
+	tol := float64(tolerance)
+	if tol <= 0 {
+		tol = DefaultTolerance
+	}
+	res := New()
+	if style.Width <= 0 {
+		return res
+	}
+	for _, line := range p.flatten(tol) {
+		widths := make([]float64, len(line.points))
+		for i := range widths {
+			widths[i] = float64(style.Width)
+		}
+		if style.WidthScale != nil {
+			scaleWidths(line, widths, style.WidthScale)
+		}
+		strokeDashed(res, line, widths, style, tol)
+	}
+	return res
+}
+
+// Tessellates the stroke of the path and adds the triangles to the sprite, every vertex gets the color and layer
+func (p *Path) StrokeTo(sprite *vecsprite.VecSprite, style StrokeStyle, tolerance float32, c color.Color, layer uint8) {
+	p.Stroke(style, tolerance).Fill(sprite, NonZero, tolerance, c, layer)
+}
+
+// Returns the outline of a stroke through the points, with the width at every point given by widths
+// style.Width is used for points without a width, so for every point if widths is nil, WidthScale also applies
+// Useful for trails, where the width often changes towards the end
+func StrokePolyline(points [][2]float32, widths []float32, closed bool, style StrokeStyle, tolerance float32) *Path {
+	tol := float64(tolerance)
+	if tol <= 0 {
+		tol = DefaultTolerance
+	}
+	res := New()
+	line := polyline{points: make([][2]float64, 0, len(points)), closed: closed}
+	ws := make([]float64, 0, len(points))
+	for i, point := range points {
+		w := float64(style.Width)
+		if i < len(widths) {
+			w = float64(widths[i])
+		}
+		// repeated points have no direction, the first width is kept
+		if i > 0 && pt(point[0], point[1]) == line.points[len(line.points)-1] {
+			continue
+		}
+		line.points = append(line.points, pt(point[0], point[1]))
+		ws = append(ws, max(w, 0))
+	}
+	if closed && len(line.points) > 1 && line.points[0] == line.points[len(line.points)-1] {
+		line.points = line.points[:len(line.points)-1]
+		ws = ws[:len(ws)-1]
+	}
+	if len(line.points) == 0 {
+		return res
+	}
+	if style.WidthScale != nil {
+		scaleWidths(line, ws, style.WidthScale)
+	}
+	strokeDashed(res, line, ws, style, tol)
+	return res
+}
+
+// distance from the start of the line to every point, and the total length including the closing segment
+func distances(line polyline) ([]float64, float64) {
+	res := make([]float64, len(line.points))
+	total := 0.0
+	for i := 1; i < len(line.points); i++ {
+		total += length(sub(line.points[i], line.points[i-1]))
+		res[i] = total
+	}
+	if line.closed && len(line.points) > 1 {
+		total += length(sub(line.points[0], line.points[len(line.points)-1]))
+	}
+	return res, total
+}
+
+func scaleWidths(line polyline, widths []float64, scale func(t float32) float32) {
+	dist, total := distances(line)
+	for i := range widths {
+		t := 0.0
+		if total > 0 {
+			t = dist[i] / total
+		}
+		widths[i] *= max(float64(scale(float32(t))), 0)
+	}
+}
+
+// upper limit of repeats of the dash pattern per subpath, so tiny dashes on long lines do not use all memory
+// the pattern is made longer for lines that would repeat it more often
+const maxDashRepeats = 4096
+
+// the dash pattern with an even amount of lengths, nil if the line is solid
+func dashPattern(style StrokeStyle) []float64 {
+	res := make([]float64, 0, len(style.Dashes)*2)
+	sum := 0.0
+	for _, d := range style.Dashes {
+		if d < 0 {
+			return nil
+		}
+		res = append(res, float64(d))
+		sum += float64(d)
+	}
+	if sum <= 0 {
+		return nil
+	}
+	if len(res)%2 == 1 {
+		res = append(res, res...)
+	}
+	return res
+}
+
+// strokes the line, or every dash of it, widths has the full width of every point
+func strokeDashed(res *Path, line polyline, widths []float64, style StrokeStyle, tolerance float64) {
+	pattern := dashPattern(style)
+	if pattern == nil {
+		strokeLine(res, line.points, widths, line.closed, style, tolerance)
+		return
+	}
+	points, ws := line.points, widths
+	if line.closed && len(points) > 1 {
+		// dashes go all the way around and end at the start
+		points = append(points[:len(points):len(points)], points[0])
+		ws = append(ws[:len(ws):len(ws)], ws[0])
+	}
+	dist, _ := distances(polyline{points: points})
+	total := dist[len(dist)-1]
+	period := 0.0
+	for _, d := range pattern {
+		period += d
+	}
+	offset := float64(style.DashOffset)
+	if scale := total / period / maxDashRepeats; scale > 1 {
+		for i := range pattern {
+			pattern[i] *= scale
+		}
+		period *= scale
+		offset *= scale
+	}
+	// position in the pattern at the start of the line
+	idx := 0
+	pos := math.Mod(offset, period)
+	if pos < 0 {
+		pos += period
+	}
+	// dashes of length 0 are kept, since they are dots with round or square caps
+	for pos > pattern[idx] || (pos == pattern[idx] && pos > 0) {
+		pos -= pattern[idx]
+		idx = (idx + 1) % len(pattern)
+	}
+	// point and width at a distance along the line, seg is the segment to start searching from
+	seg := 0
+	at := func(d float64) ([2]float64, float64) {
+		for seg+1 < len(dist)-1 && dist[seg+1] < d {
+			seg++
+		}
+		l := dist[seg+1] - dist[seg]
+		t := 0.0
+		if l > 0 {
+			t = min(max((d-dist[seg])/l, 0), 1)
+		}
+		return add(points[seg], mul(sub(points[seg+1], points[seg]), t)), ws[seg] + (ws[seg+1]-ws[seg])*t
+	}
+	if len(points) == 1 {
+		// a dot is a single dash
+		if idx%2 == 0 {
+			strokeLine(res, points, ws, false, style, tolerance)
+		}
+		return
+	}
+	start := 0.0
+	// a dash of length 0 can be exactly at the end
+	for start < total || (start == total && pattern[idx] == 0 && idx%2 == 0) {
+		end := min(start+pattern[idx]-pos, total)
+		if idx%2 == 0 {
+			dashPoints := make([][2]float64, 0)
+			dashWidths := make([]float64, 0)
+			p, w := at(start)
+			dashPoints, dashWidths = append(dashPoints, p), append(dashWidths, w)
+			// every corner inside the dash
+			for i := seg + 1; i < len(dist) && dist[i] < end; i++ {
+				if dist[i] > start {
+					dashPoints, dashWidths = append(dashPoints, points[i]), append(dashWidths, ws[i])
+				}
+			}
+			p, w = at(end)
+			if p != dashPoints[len(dashPoints)-1] {
+				dashPoints, dashWidths = append(dashPoints, p), append(dashWidths, w)
+			}
+			strokeLine(res, dashPoints, dashWidths, false, style, tolerance)
+		}
+		if start == total {
+			break
+		}
+		start = end
+		pos = 0
+		idx = (idx + 1) % len(pattern)
+	}
+}
+
+// Adds a closed subpath going counterclockwise, so overlapping parts add up with NonZero
+func (p *Path) addPolygon(points [][2]float64) {
+	if len(points) < 3 {
+		return
+	}
+	area := 0.0
+	for i, a := range points {
+		area += cross(a, points[(i+1)%len(points)])
+	}
+	if area == 0 {
+		return
+	}
+	p.add(moveTo, points[0])
+	p.start = points[0]
+	for i := range points[1:] {
+		// the other way around if the polygon is clockwise
+		idx := i + 1
+		if area < 0 {
+			idx = len(points) - 1 - i
+		}
+		p.add(lineTo, points[idx])
+	}
+	p.Close()
+}
+
+func normalize(a [2]float64) [2]float64 {
+	return mul(a, 1/length(a))
+}
+
+// left side of the direction when y is up
+func normal(dir [2]float64) [2]float64 {
+	return [2]float64{-dir[1], dir[0]}
+}
+
+// angle between the points on a circle of radius r, so the lines between them are at most tolerance from the circle
+func arcStep(r, tolerance float64) float64 {
+	if r <= tolerance {
+		return math.Pi / 2
+	}
+	return max(2*math.Acos(1-tolerance/r), math.Pi/512)
+}
+
+// points on the arc around center from angle a0 going by sweep radians, including both ends
+func arcPoints(center [2]float64, r, a0, sweep, tolerance float64) [][2]float64 {
+	n := int(math.Ceil(math.Abs(sweep) / arcStep(r, tolerance)))
+	n = max(n, 1)
+	res := make([][2]float64, 0, n+1)
+	for i := 0; i <= n; i++ {
+		s, c := math.Sincos(a0 + sweep*float64(i)/float64(n))
+		res = append(res, add(center, [2]float64{c * r, s * r}))
+	}
+	return res
+}
+
+func circle(center [2]float64, r, tolerance float64) [][2]float64 {
+	// at least 8 sides, so small circles still look round
+	points := arcPoints(center, r, 0, 2*math.Pi, min(tolerance, r*(1-math.Cos(math.Pi/8))))
+	// the last point is the first one again
+	return points[:len(points)-1]
+}
+
+// widths has the full width at every point
+func strokeLine(res *Path, points [][2]float64, widths []float64, closed bool, style StrokeStyle, tolerance float64) {
+	if len(points) == 1 {
+		hw := widths[0] / 2
+		// a dot, only visible with caps that go past the end
+		switch style.Cap {
+		case CapRound:
+			res.addPolygon(circle(points[0], hw, tolerance))
+		case CapSquare:
+			c := points[0]
+			res.addPolygon([][2]float64{add(c, [2]float64{-hw, -hw}), add(c, [2]float64{hw, -hw}), add(c, [2]float64{hw, hw}), add(c, [2]float64{-hw, hw})})
+		}
+		return
+	}
+	segments := len(points) - 1
+	if closed {
+		segments = len(points)
+	}
+	dir := func(i int) [2]float64 {
+		return normalize(sub(points[(i+1)%len(points)], points[i]))
+	}
+	for i := 0; i < segments; i++ {
+		j := (i + 1) % len(points)
+		a, b := points[i], points[j]
+		n := normal(dir(i))
+		na, nb := mul(n, widths[i]/2), mul(n, widths[j]/2)
+		res.addPolygon([][2]float64{add(a, na), add(b, nb), sub(b, nb), sub(a, na)})
+	}
+	// joins between segment i-1 and i, at the start of segment i
+	first := 1
+	if closed {
+		first = 0
+	}
+	for i := first; i < segments; i++ {
+		prev := i - 1
+		if prev < 0 {
+			prev = segments - 1
+		}
+		strokeJoin(res, points[i], dir(prev), dir(i), style, widths[i]/2, tolerance)
+	}
+	if closed {
+		return
+	}
+	last := len(points) - 1
+	strokeCap(res, points[0], mul(dir(0), -1), style.Cap, widths[0]/2, tolerance)
+	strokeCap(res, points[last], dir(last-1), style.Cap, widths[last]/2, tolerance)
+}
+
+// d0 is the direction into the point and d1 is the direction out of it
+func strokeJoin(res *Path, point, d0, d1 [2]float64, style StrokeStyle, hw, tolerance float64) {
+	turn := cross(d0, d1)
+	if math.Abs(turn) < 1e-9 && dot(d0, d1) > 0 {
+		// straight, the segments already meet
+		return
+	}
+	// the outer side is the right side when turning left
+	side := 1.0
+	if turn > 0 {
+		side = -1
+	}
+	o0 := mul(normal(d0), hw*side)
+	o1 := mul(normal(d1), hw*side)
+	switch style.Join {
+	case JoinRound:
+		a0 := math.Atan2(o0[1], o0[0])
+		sweep := math.Atan2(cross(o0, o1), dot(o0, o1))
+		res.addPolygon(append([][2]float64{point}, arcPoints(point, hw, a0, sweep, tolerance)...))
+		return
+	case JoinMiter:
+		limit := float64(style.MiterLimit)
+		if limit <= 0 {
+			limit = 4
+		}
+		m := add(o0, o1)
+		// cosine of half the angle between the normals, the miter is hw / cos long
+		cos := dot(normalize(m), normalize(o0))
+		if cos > 0 && 1/cos <= limit {
+			tip := add(point, mul(normalize(m), hw/cos))
+			res.addPolygon([][2]float64{point, add(point, o0), tip, add(point, o1)})
+			return
+		}
+	}
+	res.addPolygon([][2]float64{point, add(point, o0), add(point, o1)})
+}
+
+// dir points away from the line
+func strokeCap(res *Path, point, dir [2]float64, cap Cap, hw, tolerance float64) {
+	n := mul(normal(dir), hw)
+	switch cap {
+	case CapRound:
+		a0 := math.Atan2(n[1], n[0])
+		res.addPolygon(arcPoints(point, hw, a0, -math.Pi, tolerance))
+	case CapSquare:
+		out := mul(dir, hw)
+		res.addPolygon([][2]float64{add(point, n), add(add(point, n), out), add(sub(point, n), out), sub(point, n)})
+	}
+}
@@ -0,0 +1,188 @@
+package path
+
+import (
+	"math"
+	"testing"
+)
+
+// area of the filled outline of the stroke
+func strokeArea(t *testing.T, p *Path, style StrokeStyle) float64 {
+	t.Helper()
+	return fillArea(t, p.Stroke(style, 0.001), NonZero, 0.001)
+}
+
+func line(points ...[2]float32) *Path {
+	p := New()
+	p.Polygon(points, false)
+	return p
+}
+
+func TestStroke(t *testing.T) {
+	straight := line([2]float32{0, 0}, [2]float32{10, 0})
+	// turns left by 90 degrees at (10, 0)
+	corner := line([2]float32{0, 0}, [2]float32{10, 0}, [2]float32{10, 10})
+	// turns by 135 degrees, so the miter is long
+	sharp := line([2]float32{0, 0}, [2]float32{10, 0}, [2]float32{0, 10})
+	square := New()
+	square.Rect(0, 0, 10, 10)
+	// the two segments of the corner overlap in a 1 by 1 square
+	cornerArea := 20 + 20 - 1.0
+	// the normals of the sharp corner are 135 degrees apart, the miter is a kite and the bevel is the triangle inside it
+	sharpBevel := strokeArea(t, sharp, StrokeStyle{Width: 2, Join: JoinBevel})
+	sharpMiter := sharpBevel + math.Tan(3*math.Pi/8) - math.Sin(3*math.Pi/4)/2
+	tests := []struct {
+		name  string
+		path  *Path
+		style StrokeStyle
+		area  float64
+	}{
+		{"no width", straight, StrokeStyle{}, 0},
+		{"butt", straight, StrokeStyle{Width: 2}, 20},
+		{"square cap", straight, StrokeStyle{Width: 2, Cap: CapSquare}, 24},
+		{"round cap", straight, StrokeStyle{Width: 2, Cap: CapRound}, 20 + math.Pi},
+		{"miter", corner, StrokeStyle{Width: 2, Join: JoinMiter}, cornerArea + 1},
+		{"bevel", corner, StrokeStyle{Width: 2, Join: JoinBevel}, cornerArea + 0.5},
+		{"round join", corner, StrokeStyle{Width: 2, Join: JoinRound}, cornerArea + math.Pi/4},
+		// the right angle has a miter of sqrt(2) times the width
+		{"miter over limit", corner, StrokeStyle{Width: 2, Join: JoinMiter, MiterLimit: 1.4}, cornerArea + 0.5},
+		{"miter under limit", corner, StrokeStyle{Width: 2, Join: JoinMiter, MiterLimit: 1.5}, cornerArea + 1},
+		// the miter of the sharp corner is 1/sin(22.5) times the width, which is about 2.6
+		{"default miter limit", sharp, StrokeStyle{Width: 2, Join: JoinMiter}, sharpMiter},
+		{"sharp miter under limit", sharp, StrokeStyle{Width: 2, Join: JoinMiter, MiterLimit: 3}, sharpMiter},
+		{"sharp miter over limit", sharp, StrokeStyle{Width: 2, Join: JoinMiter, MiterLimit: 2}, sharpBevel},
+		// a closed square with a miter at every corner, including the start
+		{"closed", square, StrokeStyle{Width: 2, Cap: CapRound}, 12*12 - 8*8},
+		{"closed bevel", square, StrokeStyle{Width: 2, Join: JoinBevel}, 12*12 - 8*8 - 4*0.5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if a := strokeArea(t, test.path, test.style); math.Abs(a-test.area) > 0.01 {
+				t.Fatalf("Area is %v, expected %v", a, test.area)
+			}
+		})
+	}
+}
+
+func TestDashes(t *testing.T) {
+	straight := line([2]float32{0, 0}, [2]float32{10, 0})
+	square := New()
+	square.Rect(0, 0, 10, 10)
+	tests := []struct {
+		name   string
+		path   *Path
+		style  StrokeStyle
+		area   float64
+		dashes int
+	}{
+		{"dash and gap", straight, StrokeStyle{Width: 2, Dashes: []float32{2, 3}}, 2 * (2 + 2), 2},
+		{"odd amount", straight, StrokeStyle{Width: 2, Dashes: []float32{2}}, 2 * (2 + 2 + 2), 3},
+		{"offset", straight, StrokeStyle{Width: 2, Dashes: []float32{2, 3}, DashOffset: 1}, 2 * (1 + 2 + 1), 3},
+		{"negative offset", straight, StrokeStyle{Width: 2, Dashes: []float32{2, 3}, DashOffset: -4}, 2 * (1 + 2 + 1), 3},
+		{"negative length is solid", straight, StrokeStyle{Width: 2, Dashes: []float32{2, -3}}, 20, 1},
+		{"zero lengths are solid", straight, StrokeStyle{Width: 2, Dashes: []float32{0, 0}}, 20, 1},
+		// dashes of length 0 are dots with round caps, one at 0, 5 and 10
+		{"dots", straight, StrokeStyle{Width: 2, Cap: CapRound, Dashes: []float32{0, 5}}, 3 * math.Pi, 3},
+		{"dots without caps", straight, StrokeStyle{Width: 2, Dashes: []float32{0, 5}}, 0, 0},
+		// dashes from 0 to 4, 12 to 24 and 32 to 40, the second one goes around a corner and has a miter
+		// the last one ends at the start, where it overlaps the first one since they are separate dashes
+		{"around a corner", square, StrokeStyle{Width: 2, Dashes: []float32{12, 8}, DashOffset: 8}, 4*2 + (12*2 - 1 + 1) + 8*2 - 1, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outline := test.path.Stroke(test.style, 0.001)
+			// the round dots are a bit smaller than circles
+			if a := fillArea(t, outline, NonZero, 0.001); math.Abs(a-test.area) > 0.02 {
+				t.Fatalf("Area is %v, expected %v", a, test.area)
+			}
+			// every dash is a separate part of the fill, so the parts are counted by the x ranges that do not touch
+			if parts := separateParts(outline); parts != test.dashes && test.path == straight {
+				t.Fatalf("Stroke has %v dashes, expected %v", parts, test.dashes)
+			}
+		})
+	}
+}
+
+// amount of ranges of x covered by the path, for dashes along the x axis
+func separateParts(p *Path) int {
+	type span struct{ min, max float32 }
+	spans := make([]span, 0)
+	for _, line := range p.Flatten(0.001) {
+		s := span{line.Points[0][0], line.Points[0][0]}
+		for _, point := range line.Points {
+			s.min, s.max = min(s.min, point[0]), max(s.max, point[0])
+		}
+		spans = append(spans, s)
+	}
+	// merges overlapping spans until nothing changes
+	for merged := true; merged; {
+		merged = false
+		for i := 0; i < len(spans) && !merged; i++ {
+			for j := i + 1; j < len(spans); j++ {
+				if spans[i].min <= spans[j].max && spans[j].min <= spans[i].max {
+					spans[i] = span{min(spans[i].min, spans[j].min), max(spans[i].max, spans[j].max)}
+					spans = append(spans[:j], spans[j+1:]...)
+					merged = true
+					break
+				}
+			}
+		}
+	}
+	return len(spans)
+}
+
+func TestDashLimit(t *testing.T) {
+	// would be a million dashes, but the pattern is stretched so it repeats at most maxDashRepeats times
+	straight := line([2]float32{0, 0}, [2]float32{1e6, 0})
+	outline := straight.Stroke(StrokeStyle{Width: 2, Dashes: []float32{0.5, 0.5}}, 0)
+	if parts := len(outline.Flatten(0)); parts > maxDashRepeats {
+		t.Fatalf("Stroke has %v parts", parts)
+	}
+	// half of the line is still dashes
+	if a := fillArea(t, outline, NonZero, 0); math.Abs(a-1e6) > 1 {
+		t.Fatalf("Area is %v, expected %v", a, 1e6)
+	}
+}
+
+func TestWidthScale(t *testing.T) {
+	straight := line([2]float32{0, 0}, [2]float32{10, 0})
+	taper := func(t float32) float32 { return 1 - t }
+	if a := strokeArea(t, straight, StrokeStyle{Width: 2, WidthScale: taper}); math.Abs(a-10) > 0.01 {
+		t.Fatalf("Tapered area is %v, expected 10", a)
+	}
+	// negative scales are 0
+	if a := strokeArea(t, straight, StrokeStyle{Width: 2, WidthScale: func(float32) float32 { return -1 }}); a != 0 {
+		t.Fatalf("Area with a negative scale is %v", a)
+	}
+	// the dashes use t of the whole line, the first one is from 0 to 5 with a width from 2 to 1
+	if a := strokeArea(t, straight, StrokeStyle{Width: 2, WidthScale: taper, Dashes: []float32{5, 5}}); math.Abs(a-7.5) > 0.01 {
+		t.Fatalf("Tapered dash area is %v, expected 7.5", a)
+	}
+}
+
+func TestStrokePolyline(t *testing.T) {
+	points := [][2]float32{{0, 0}, {10, 0}, {20, 0}}
+	tests := []struct {
+		name   string
+		points [][2]float32
+		widths []float32
+		closed bool
+		area   float64
+	}{
+		{"style width", points, nil, false, 40},
+		{"widths", points, []float32{4, 2, 0}, false, 30 + 10},
+		// points without a width use the width of the style
+		{"short widths", points, []float32{4}, false, 30 + 20},
+		{"negative width", points, []float32{-2, -2, -2}, false, 0},
+		{"repeated points", [][2]float32{{0, 0}, {0, 0}, {10, 0}, {10, 0}}, nil, false, 20},
+		{"closed", [][2]float32{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, nil, true, 12*12 - 8*8},
+		{"empty", nil, nil, false, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outline := StrokePolyline(test.points, test.widths, test.closed, StrokeStyle{Width: 2}, 0.001)
+			if a := fillArea(t, outline, NonZero, 0.001); math.Abs(a-test.area) > 0.01 {
+				t.Fatalf("Area is %v, expected %v", a, test.area)
+			}
+		})
+	}
+}
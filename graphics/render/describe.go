@@ -0,0 +1,160 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ChannelKind uint8
+
+const (
+	ChannelIntermediate ChannelKind = iota
+	ChannelFragmentIntermediate
+	ChannelAttribute
+	ChannelOperation
+	ChannelVarying
+	ChannelBuiltin
+	ChannelVertexData
+)
+
+func (k ChannelKind) String() string {
+	switch k {
+	case ChannelIntermediate:
+		return "intermediate"
+	case ChannelFragmentIntermediate:
+		return "fragment intermediate"
+	case ChannelAttribute:
+		return "attribute"
+	case ChannelOperation:
+		return "operation"
+	case ChannelVarying:
+		return "varying"
+	case ChannelVertexData:
+		return "vertex data"
+	}
+	return "builtin"
+}
+
+var shaderTypeNames = map[ChannelShaderType]string{
+	ShaderFloat:       "float",
+	ShaderInt:         "int",
+	ShaderUnsignedInt: "uint",
+}
+
+func (t ShaderType) String() string {
+	if t.Type == ShaderSampler {
+		return "texture"
+	}
+	return fmt.Sprintf("%v%v", shaderTypeNames[t.Type], t.Amount)
+}
+
+type ChannelDescription struct {
+	// The channel returned by the ProcedureBuilder
+	Channel Channel
+	Kind    ChannelKind
+	Type    ShaderType
+	// Name of the channel in the generated source
+	Name string
+	// Default value of intermediate and varying channels, empty if there is none
+	Expression string
+	// Location of attribute and vertex data channels, 0 for other kinds
+	Location uint32
+	// Name of the VecSprite.Data entry read by vertex data channels, empty for other kinds
+	DataName string
+}
+
+type CallDescription struct {
+	Function *Function
+	// true if added with CallFragmentFunction
+	Fragment bool
+	// Channels given to the function, in the same order as the parameters
+	Channels []Channel
+}
+
+type OutputDescription struct {
+	// Name of the output, like pos or xAxis
+	Name string
+	Type ShaderType
+	// Channel set for the output, nil if the default value is used
+	Channel Channel
+	// GLSL expression used for the output
+	Value string
+}
+
+// Describes a finished Procedure, for debugging and tools
+type ProcedureDescription struct {
+	// Channels in the order they were added, followed by builtin channels
+	Channels []ChannelDescription
+	// Vertex calls followed by fragment calls, each in the order they were called
+	Calls   []CallDescription
+	Outputs []OutputDescription
+	// Final source given to the driver
+	VertexSource   string
+	FragmentSource string
+}
+
+// Returns the description of a channel, false if it is not in the procedure
+func (d *ProcedureDescription) Channel(channel Channel) (ChannelDescription, bool) {
+	channel = UnwrapChannel(channel)
+	for _, desc := range d.Channels {
+		if desc.Channel == channel {
+			return desc, true
+		}
+	}
+	return ChannelDescription{}, false
+}
+
+// Returns every channel of the given kind, in the order they were added
+func (d *ProcedureDescription) ChannelsOf(kind ChannelKind) []ChannelDescription {
+	res := make([]ChannelDescription, 0)
+	for _, desc := range d.Channels {
+		if desc.Kind == kind {
+			res = append(res, desc)
+		}
+	}
+	return res
+}
+
+// Name of the channel in the generated source, or "?" if the channel is not in the procedure
+func (d *ProcedureDescription) ChannelName(channel Channel) string {
+	if desc, ok := d.Channel(channel); ok {
+		return desc.Name
+	}
+	return "?"
+}
+
+// Readable summary of the channels, calls and outputs, does not include the source
+func (d *ProcedureDescription) String() string {
+	var sb strings.Builder
+	sb.WriteString("channels:\n")
+	for _, channel := range d.Channels {
+		sb.WriteString(fmt.Sprintf("  %v %v %v", channel.Name, channel.Kind, channel.Type))
+		if channel.Kind == ChannelAttribute || channel.Kind == ChannelVertexData {
+			sb.WriteString(fmt.Sprintf(" location=%v", channel.Location))
+		}
+		if channel.DataName != "" {
+			sb.WriteString(fmt.Sprintf(" data=%q", channel.DataName))
+		}
+		if channel.Expression != "" {
+			sb.WriteString(" = " + channel.Expression)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("calls:\n")
+	for _, call := range d.Calls {
+		names := make([]string, 0, len(call.Channels))
+		for _, channel := range call.Channels {
+			names = append(names, d.ChannelName(channel))
+		}
+		stage := "vertex"
+		if call.Fragment {
+			stage = "fragment"
+		}
+		sb.WriteString(fmt.Sprintf("  %v %v(%v)\n", stage, call.Function.Name, strings.Join(names, ", ")))
+	}
+	sb.WriteString("outputs:\n")
+	for _, output := range d.Outputs {
+		sb.WriteString(fmt.Sprintf("  %v %v = %v\n", output.Name, output.Type, output.Value))
+	}
+	return sb.String()
+}
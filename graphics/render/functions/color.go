@@ -0,0 +1,33 @@
+package functions
+
+import "github.com/eliiasg/deltawing/graphics/render"
+
+// Converts hue, saturation and value from 0 to 1 to rgb from 0 to 1
+var hsvSnippet = render.NewSnippet("hsv", `vec3 dwHSV(vec3 c) {
+    vec3 p = abs(fract(c.xxx + vec3(1.0, 2.0 / 3.0, 1.0 / 3.0)) * 6.0 - 3.0);
+    return c.z * mix(vec3(1.0), clamp(p - 1.0, 0.0, 1.0), c.y);
+}`)
+
+// Converts hue, saturation and value from 0 to 1 to a color for ProcedureBuilder.SetColorChannel, alpha is 255
+// Parameters: in vec3 hsv, out ivec4 color
+var HSVToRGB = render.NewFunction(`void dwHSVToRGB(in vec3 hsv, out ivec4 color) {
+    color = ivec4(round(dwHSV(hsv) * 255.0), 255);
+}`, "dwHSVToRGB", float3, int4).Include(hsvSnippet)
+
+// Same as HSVToRGB, but the color is from 0 to 1, like in fragment functions
+// Parameters: in vec3 hsv, out vec4 color
+var HSVToRGBFloat = render.NewFunction(`void dwHSVToRGBFloat(in vec3 hsv, out vec4 color) {
+    color = vec4(dwHSV(hsv), 1.0);
+}`, "dwHSVToRGBFloat", float3, float4).Include(hsvSnippet)
+
+// Multiplies the color by the tint, both from 0 to 255, to tint the color given by ProcedureBuilder.SetColorChannel
+// Parameters: in ivec4 tint, inout ivec4 color
+var Tint = render.NewFunction(`void dwTint(in ivec4 tint, inout ivec4 color) {
+    color = color * tint / 255;
+}`, "dwTint", int4, int4)
+
+// Same as Tint, but the colors are from 0 to 1, like in fragment functions
+// Parameters: in vec4 tint, inout vec4 color
+var TintFloat = render.NewFunction(`void dwTintFloat(in vec4 tint, inout vec4 color) {
+    color *= tint;
+}`, "dwTintFloat", float4, float4)
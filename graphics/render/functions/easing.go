@@ -0,0 +1,28 @@
+package functions
+
+import "github.com/eliiasg/deltawing/graphics/render"
+
+// Easing functions change a value from 0 to 1 in place, to make animations less linear
+// Values outside 0 to 1 are clamped
+// Parameters: inout float t
+
+func easing(name, expr string) *render.Function {
+	return render.NewFunction("void "+name+"(inout float t) {\n    t = clamp(t, 0.0, 1.0);\n    t = "+expr+";\n}", name, float1)
+}
+
+var (
+	EaseInQuad       = easing("dwEaseInQuad", "t * t")
+	EaseOutQuad      = easing("dwEaseOutQuad", "1.0 - (1.0 - t) * (1.0 - t)")
+	EaseInOutQuad    = easing("dwEaseInOutQuad", "t < 0.5 ? 2.0 * t * t : 1.0 - pow(-2.0 * t + 2.0, 2.0) / 2.0")
+	EaseInCubic      = easing("dwEaseInCubic", "t * t * t")
+	EaseOutCubic     = easing("dwEaseOutCubic", "1.0 - pow(1.0 - t, 3.0)")
+	EaseInOutCubic   = easing("dwEaseInOutCubic", "t < 0.5 ? 4.0 * t * t * t : 1.0 - pow(-2.0 * t + 2.0, 3.0) / 2.0")
+	EaseInSine       = easing("dwEaseInSine", "1.0 - cos(t * 1.57079633)")
+	EaseOutSine      = easing("dwEaseOutSine", "sin(t * 1.57079633)")
+	EaseInOutSine    = easing("dwEaseInOutSine", "-(cos(3.14159265 * t) - 1.0) / 2.0")
+	EaseInExpo       = easing("dwEaseInExpo", "t == 0.0 ? 0.0 : pow(2.0, 10.0 * t - 10.0)")
+	EaseOutExpo      = easing("dwEaseOutExpo", "t == 1.0 ? 1.0 : 1.0 - pow(2.0, -10.0 * t)")
+	EaseOutBack      = easing("dwEaseOutBack", "1.0 + 2.70158 * (t - 1.0) * (t - 1.0) * (t - 1.0) + 1.70158 * (t - 1.0) * (t - 1.0)")
+	EaseOutBounce    = easing("dwEaseOutBounce", "t < 1.0 / 2.75 ? 7.5625 * t * t : t < 2.0 / 2.75 ? 7.5625 * (t - 1.5 / 2.75) * (t - 1.5 / 2.75) + 0.75 : t < 2.5 / 2.75 ? 7.5625 * (t - 2.25 / 2.75) * (t - 2.25 / 2.75) + 0.9375 : 7.5625 * (t - 2.625 / 2.75) * (t - 2.625 / 2.75) + 0.984375")
+	EaseInOutElastic = easing("dwEaseInOutElastic", "t == 0.0 || t == 1.0 ? t : t < 0.5 ? -(pow(2.0, 20.0 * t - 10.0) * sin((20.0 * t - 11.125) * 1.39626340)) / 2.0 : pow(2.0, -20.0 * t + 10.0) * sin((20.0 * t - 11.125) * 1.39626340) / 2.0 + 1.0")
+)
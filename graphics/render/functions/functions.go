@@ -0,0 +1,16 @@
+// Ready-made functions for ProcedureBuilder.CallFunction
+// Parameter types are declared, so channels given to them are checked
+package functions
+
+import "github.com/eliiasg/deltawing/graphics/render"
+
+// shorthands, since every function needs them
+var (
+	float1 = render.Type(render.ShaderFloat, 1)
+	float2 = render.Type(render.ShaderFloat, 2)
+	float3 = render.Type(render.ShaderFloat, 3)
+	float4 = render.Type(render.ShaderFloat, 4)
+	int4   = render.Type(render.ShaderInt, 4)
+	// channels from ProcedureBuilder.AddTextureChannel
+	sampler = render.Type(render.ShaderSampler, 1)
+)
@@ -0,0 +1,299 @@
+package functions
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/render/gl"
+	"github.com/eliiasg/deltawing/graphics/render/gl/glsl"
+	"github.com/eliiasg/deltawing/graphics/render/gl/shader"
+)
+
+var all = map[string]*render.Function{
+	"EaseInQuad": EaseInQuad, "EaseOutQuad": EaseOutQuad, "EaseInOutQuad": EaseInOutQuad,
+	"EaseInCubic": EaseInCubic, "EaseOutCubic": EaseOutCubic, "EaseInOutCubic": EaseInOutCubic,
+	"EaseInSine": EaseInSine, "EaseOutSine": EaseOutSine, "EaseInOutSine": EaseInOutSine,
+	"EaseInExpo": EaseInExpo, "EaseOutExpo": EaseOutExpo, "EaseOutBack": EaseOutBack,
+	"EaseOutBounce": EaseOutBounce, "EaseInOutElastic": EaseInOutElastic,
+	"HSVToRGB": HSVToRGB, "HSVToRGBFloat": HSVToRGBFloat, "Tint": Tint, "TintFloat": TintFloat,
+	"Rotate": Rotate, "RotateScale": RotateScale, "Scale": Scale, "ScaleXY": ScaleXY,
+	"CameraOffset": CameraOffset, "CameraZoom": CameraZoom,
+	"SampleTexture": SampleTexture, "ReadTexture": ReadTexture,
+}
+
+// functions that can only be used in fragment functions
+var fragmentOnly = map[*render.Function]bool{SampleTexture: true, ReadTexture: true}
+
+var glslTypes = map[string]render.ShaderType{
+	"float": float1, "vec2": float2, "vec3": float3, "vec4": float4,
+	"ivec4": int4, "sampler2D": sampler,
+}
+
+func parse(t *testing.T, function *render.Function) *glsl.Function {
+	file, err := glsl.Parse(function.Source)
+	if err != nil {
+		t.Fatalf("Source does not parse: %v", err)
+	}
+	f := file.Function(function.Name, len(function.Parameters))
+	if f == nil {
+		t.Fatalf("Source has no function %v with %v parameters", function.Name, len(function.Parameters))
+	}
+	return f
+}
+
+func TestSignatures(t *testing.T) {
+	for name, function := range all {
+		t.Run(name, func(t *testing.T) {
+			f := parse(t, function)
+			if f.ReturnType != "void" {
+				t.Errorf("Function returns %v, but functions must return void", f.ReturnType)
+			}
+			for i, param := range f.Params {
+				typ, ok := glslTypes[param.Type]
+				if !ok {
+					t.Fatalf("Unknown type %v of parameter %v", param.Type, param.Name)
+				}
+				if typ != function.Parameters[i] {
+					t.Errorf("Parameter %v is %v in GLSL, but declared as %v", param.Name, param.Type, function.Parameters[i])
+				}
+			}
+		})
+	}
+}
+
+func newBuilder() *shader.ShaderBuilder {
+	sb := gl.NewShaderBuilder("300 es")
+	// the only variables without defaults
+	sb.SetOutputChannel("pos", sb.AddOperationChannel(float2))
+	sb.SetOutputChannel("layer", sb.AddOperationChannel(render.Type(render.ShaderUnsignedInt, 1)))
+	return sb
+}
+
+func TestCalls(t *testing.T) {
+	for name, function := range all {
+		t.Run(name, func(t *testing.T) {
+			f := parse(t, function)
+			sb := newBuilder()
+			stage := shader.VertexStage
+			if fragmentOnly[function] {
+				stage = shader.FragmentStage
+			}
+			channels := make([]render.Channel, len(function.Parameters))
+			for i, typ := range function.Parameters {
+				if f.Params[i].Qualifier.Writes() {
+					// zero of the type, like vec2(0)
+					channels[i] = sb.AddIntermediateChannel(stage, typ, f.Params[i].Type+"(0)")
+				} else {
+					channels[i] = sb.AddOperationChannel(typ)
+				}
+			}
+			if err := sb.CallFunction(stage, function, channels...); err != nil {
+				t.Fatalf("Call failed: %v", err)
+			}
+			source, err := sb.Finish()
+			if err != nil {
+				t.Fatalf("Finish failed: %v", err)
+			}
+			if !strings.Contains(source.VertexSource+source.FragmentSource, function.Name+"(") {
+				t.Errorf("Shaders do not call %v", function.Name)
+			}
+		})
+	}
+}
+
+// the functions written in Go the same way as in GLSL, so the math can be checked without a context
+
+func clamp(x, lo, hi float64) float64 {
+	return min(max(x, lo), hi)
+}
+
+func rotate(angle float64) (xAxis, yAxis [2]float64) {
+	s, c := math.Sincos(angle)
+	return [2]float64{c, s}, [2]float64{-s, c}
+}
+
+func scale(s float64, xAxis, yAxis [2]float64) ([2]float64, [2]float64) {
+	return [2]float64{xAxis[0] * s, xAxis[1] * s}, [2]float64{yAxis[0] * s, yAxis[1] * s}
+}
+
+func cameraZoom(camera [2]float64, zoom float64, screen [2]float64, pos [2]float64) [2]float64 {
+	return [2]float64{(pos[0]-camera[0])*zoom + screen[0]*0.5, (pos[1]-camera[1])*zoom + screen[1]*0.5}
+}
+
+func hsvToRGB(h, s, v float64) [4]int {
+	res := [4]int{3: 255}
+	for i, offset := range []float64{1, 2.0 / 3, 1.0 / 3} {
+		x := h + offset
+		p := math.Abs((x-math.Floor(x))*6 - 3)
+		c := v * (1 + (clamp(p-1, 0, 1)-1)*s)
+		res[i] = int(math.Round(c * 255))
+	}
+	return res
+}
+
+func tint(tint, color [4]int) [4]int {
+	for i := range color {
+		color[i] = color[i] * tint[i] / 255
+	}
+	return color
+}
+
+func close2(a, b [2]float64) bool {
+	return math.Abs(a[0]-b[0]) < 1e-9 && math.Abs(a[1]-b[1]) < 1e-9
+}
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		name         string
+		xAxis, yAxis [2]float64
+		x, y         [2]float64
+	}{
+		{"no rotation", [2]float64{1, 0}, [2]float64{0, 1}, [2]float64{1, 0}, [2]float64{0, 1}},
+		// the y axis points down on screen, so this is clockwise
+		{"quarter", [2]float64{1, 0}, [2]float64{0, 1}, [2]float64{0, 1}, [2]float64{-1, 0}},
+		{"half", [2]float64{1, 0}, [2]float64{0, 1}, [2]float64{-1, 0}, [2]float64{0, -1}},
+	}
+	angles := []float64{0, math.Pi / 2, math.Pi}
+	for i, test := range tests {
+		x, y := rotate(angles[i])
+		if !close2(x, test.x) || !close2(y, test.y) {
+			t.Errorf("Rotate %v gives %v %v, expected %v %v", test.name, x, y, test.x, test.y)
+		}
+	}
+	// scaling happens before rotating, so RotateScale scales the axes of Rotate
+	x, y := rotate(math.Pi / 2)
+	if x, y := scale(2, x, y); !close2(x, [2]float64{0, 2}) || !close2(y, [2]float64{-2, 0}) {
+		t.Errorf("Scale gives %v %v", x, y)
+	}
+	if x, y := scale(-0.5, [2]float64{2, 0}, [2]float64{0, 4}); !close2(x, [2]float64{-1, 0}) || !close2(y, [2]float64{0, -2}) {
+		t.Errorf("Negative scale gives %v %v", x, y)
+	}
+	// the camera ends up in the middle of the screen
+	screen := [2]float64{100, 50}
+	if pos := cameraZoom([2]float64{10, 20}, 1, screen, [2]float64{10, 20}); !close2(pos, [2]float64{50, 25}) {
+		t.Errorf("Camera is at %v", pos)
+	}
+	if pos := cameraZoom([2]float64{10, 20}, 2, screen, [2]float64{15, 20}); !close2(pos, [2]float64{60, 25}) {
+		t.Errorf("Zoomed position is %v", pos)
+	}
+}
+
+func TestColors(t *testing.T) {
+	tests := []struct {
+		h, s, v float64
+		rgb     [4]int
+	}{
+		{0, 1, 1, [4]int{255, 0, 0, 255}},
+		{1.0 / 6, 1, 1, [4]int{255, 255, 0, 255}},
+		{1.0 / 3, 1, 1, [4]int{0, 255, 0, 255}},
+		{0.5, 1, 1, [4]int{0, 255, 255, 255}},
+		{2.0 / 3, 1, 1, [4]int{0, 0, 255, 255}},
+		// hue wraps around
+		{1, 1, 1, [4]int{255, 0, 0, 255}},
+		{0.5, 0.5, 1, [4]int{128, 255, 255, 255}},
+		{0.3, 0, 0.5, [4]int{128, 128, 128, 255}},
+		{0.3, 1, 0, [4]int{0, 0, 0, 255}},
+	}
+	for _, test := range tests {
+		if rgb := hsvToRGB(test.h, test.s, test.v); rgb != test.rgb {
+			t.Errorf("HSV %v %v %v is %v, expected %v", test.h, test.s, test.v, rgb, test.rgb)
+		}
+	}
+	if c := tint([4]int{128, 255, 255, 255}, [4]int{255, 128, 0, 255}); c != [4]int{128, 128, 0, 255} {
+		t.Errorf("Tinted color is %v", c)
+	}
+}
+
+// reference easings from https://easings.net, clamped like in GLSL
+var easings = map[*render.Function]func(t float64) float64{
+	EaseInQuad:    func(t float64) float64 { return t * t },
+	EaseOutQuad:   func(t float64) float64 { return 1 - (1-t)*(1-t) },
+	EaseInCubic:   func(t float64) float64 { return t * t * t },
+	EaseOutCubic:  func(t float64) float64 { return 1 - math.Pow(1-t, 3) },
+	EaseInSine:    func(t float64) float64 { return 1 - math.Cos(t*math.Pi/2) },
+	EaseOutSine:   func(t float64) float64 { return math.Sin(t * math.Pi / 2) },
+	EaseInOutSine: func(t float64) float64 { return -(math.Cos(math.Pi*t) - 1) / 2 },
+	EaseInOutQuad: func(t float64) float64 {
+		if t < 0.5 {
+			return 2 * t * t
+		}
+		return 1 - math.Pow(-2*t+2, 2)/2
+	},
+	EaseInOutCubic: func(t float64) float64 {
+		if t < 0.5 {
+			return 4 * t * t * t
+		}
+		return 1 - math.Pow(-2*t+2, 3)/2
+	},
+	EaseInExpo: func(t float64) float64 {
+		if t == 0 {
+			return 0
+		}
+		return math.Pow(2, 10*t-10)
+	},
+	EaseOutExpo: func(t float64) float64 {
+		if t == 1 {
+			return 1
+		}
+		return 1 - math.Pow(2, -10*t)
+	},
+	EaseOutBack: func(t float64) float64 {
+		const c1 = 1.70158
+		return 1 + (c1+1)*math.Pow(t-1, 3) + c1*math.Pow(t-1, 2)
+	},
+	EaseOutBounce: func(t float64) float64 {
+		const n1, d1 = 7.5625, 2.75
+		switch {
+		case t < 1/d1:
+			return n1 * t * t
+		case t < 2/d1:
+			t -= 1.5 / d1
+			return n1*t*t + 0.75
+		case t < 2.5/d1:
+			t -= 2.25 / d1
+			return n1*t*t + 0.9375
+		}
+		t -= 2.625 / d1
+		return n1*t*t + 0.984375
+	},
+	EaseInOutElastic: func(t float64) float64 {
+		const c5 = 2 * math.Pi / 4.5
+		switch {
+		case t == 0 || t == 1:
+			return t
+		case t < 0.5:
+			return -(math.Pow(2, 20*t-10) * math.Sin((20*t-11.125)*c5)) / 2
+		}
+		return math.Pow(2, -20*t+10)*math.Sin((20*t-11.125)*c5)/2 + 1
+	},
+}
+
+func TestEasings(t *testing.T) {
+	// values worked out by hand
+	known := map[*render.Function][2]float64{
+		EaseInQuad: {0.5, 0.25}, EaseOutQuad: {0.5, 0.75}, EaseInOutQuad: {0.25, 0.125},
+		EaseInCubic: {0.5, 0.125}, EaseOutCubic: {0.5, 0.875}, EaseInOutCubic: {0.25, 0.0625},
+		EaseInSine: {2.0 / 3, 0.5}, EaseOutSine: {1.0 / 3, 0.5}, EaseInOutSine: {0.5, 0.5},
+		EaseInExpo: {0.9, 0.5}, EaseOutExpo: {0.1, 0.5}, EaseOutBack: {0.5, 1.0876975},
+		EaseOutBounce: {0.5, 0.765625}, EaseInOutElastic: {0.5, 0.5},
+	}
+	for name, function := range all {
+		if !strings.HasPrefix(name, "Ease") {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			reference, ok := easings[function]
+			if !ok {
+				t.Fatal("No reference easing")
+			}
+			ease := func(x float64) float64 { return reference(clamp(x, 0, 1)) }
+			for _, x := range [][2]float64{{0, 0}, {1, 1}, {-1, 0}, {2, 1}, known[function]} {
+				if v := ease(x[0]); math.Abs(v-x[1]) > 1e-6 {
+					t.Errorf("Easing of %v is %v, expected %v", x[0], v, x[1])
+				}
+			}
+		})
+	}
+}
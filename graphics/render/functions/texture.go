@@ -0,0 +1,15 @@
+package functions
+
+import "github.com/eliiasg/deltawing/graphics/render"
+
+// Multiplies the color by the texture at uv, for fragment functions - uv usually comes from a varying set to ProcedureBuilder.VertexUVChannel
+// Parameters: in sampler2D texture, in vec2 uv, inout vec4 color
+var SampleTexture = render.NewFunction(`void dwSampleTexture(in sampler2D tex, in vec2 uv, inout vec4 color) {
+    color *= texture(tex, uv);
+}`, "dwSampleTexture", sampler, float2, float4)
+
+// Sets the color to the texture at uv, ignoring the color of the sprite
+// Parameters: in sampler2D texture, in vec2 uv, out vec4 color
+var ReadTexture = render.NewFunction(`void dwReadTexture(in sampler2D tex, in vec2 uv, out vec4 color) {
+    color = texture(tex, uv);
+}`, "dwReadTexture", sampler, float2, float4)
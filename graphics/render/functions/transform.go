@@ -0,0 +1,49 @@
+package functions
+
+import "github.com/eliiasg/deltawing/graphics/render"
+
+// Sets the axes to rotate a sprite by the angle in radians, positive angles are clockwise on screen
+// Parameters: in float angle, out vec2 xAxis, out vec2 yAxis
+var Rotate = render.NewFunction(`void dwRotate(in float angle, out vec2 xAxis, out vec2 yAxis) {
+    float c = cos(angle);
+    float s = sin(angle);
+    xAxis = vec2(c, s);
+    yAxis = vec2(-s, c);
+}`, "dwRotate", float1, float2, float2)
+
+// Sets the axes to rotate by the angle in radians and scale by the scale, scaling happens before rotation
+// Parameters: in float angle, in vec2 scale, out vec2 xAxis, out vec2 yAxis
+var RotateScale = render.NewFunction(`void dwRotateScale(in float angle, in vec2 scale, out vec2 xAxis, out vec2 yAxis) {
+    float c = cos(angle);
+    float s = sin(angle);
+    xAxis = vec2(c, s) * scale.x;
+    yAxis = vec2(-s, c) * scale.y;
+}`, "dwRotateScale", float1, float2, float2, float2)
+
+// Scales the axes by the same amount in both directions
+// Parameters: in float scale, inout vec2 xAxis, inout vec2 yAxis
+var Scale = render.NewFunction(`void dwScale(in float scale, inout vec2 xAxis, inout vec2 yAxis) {
+    xAxis *= scale;
+    yAxis *= scale;
+}`, "dwScale", float1, float2, float2)
+
+// Scales the axes by scale.x along the x axis and scale.y along the y axis
+// Parameters: in vec2 scale, inout vec2 xAxis, inout vec2 yAxis
+var ScaleXY = render.NewFunction(`void dwScaleXY(in vec2 scale, inout vec2 xAxis, inout vec2 yAxis) {
+    xAxis *= scale.x;
+    yAxis *= scale.y;
+}`, "dwScaleXY", float2, float2, float2)
+
+// Moves the position so the camera position is in the center of the screen
+// Parameters: in vec2 camera, inout vec2 pos
+var CameraOffset = render.NewFunction(`void dwCameraOffset(in vec2 camera, inout vec2 pos) {
+    pos = pos - camera + vec2(screenSize) * 0.5;
+}`, "dwCameraOffset", float2, float2)
+
+// Same as CameraOffset, but also zooms around the center of the screen, a zoom of 2 makes everything twice as big
+// Parameters: in vec2 camera, in float zoom, inout vec2 pos, inout vec2 xAxis, inout vec2 yAxis
+var CameraZoom = render.NewFunction(`void dwCameraZoom(in vec2 camera, in float zoom, inout vec2 pos, inout vec2 xAxis, inout vec2 yAxis) {
+    pos = (pos - camera) * zoom + vec2(screenSize) * 0.5;
+    xAxis *= zoom;
+    yAxis *= zoom;
+}`, "dwCameraZoom", float2, float1, float2, float2, float2)
@@ -1,6 +1,8 @@
 package gl
 
 import (
+	"fmt"
+
 	"github.com/eliiasg/deltawing/graphics/render"
 	"github.com/eliiasg/deltawing/graphics/render/gl/util"
 	"github.com/eliiasg/deltawing/graphics/vecsprite"
@@ -12,13 +14,16 @@ import (
 */
 
 func (r *Renderer) MakeSpriteBufferBuilder() render.SpriteBufferBuilder {
-	return &spriteBufferBuilder{r.cxt, make([]*vecsprite.VecSprite, 0)}
+	return &spriteBufferBuilder{r.cxt, make([]*vecsprite.VecSprite, 0), nil, nil}
 }
 
 // not making builders public, since they dont directly interact with gl
 type spriteBufferBuilder struct {
 	cxt     Context
 	sprites []*vecsprite.VecSprite
+	// from AddCompiled, only used while sprites is the same as compiledSprites
+	compiled        *vecsprite.Compiled
+	compiledSprites []*vecsprite.VecSprite
 }
 
 type SpriteBuffer struct {
@@ -28,10 +33,28 @@ type SpriteBuffer struct {
 	Verts any
 	// Buffer of inds
 	Inds any
+	// Buffer of uvs, only used if Textured is true
+	UVs any
+	// True if any of the sprites has uvs
+	Textured bool
+	// Buffer of the interleaved VecSprite.Data of every vertex
+	Data any
+	// Where every name from VecSprite.Data is in Data, nil if no sprite has data
+	DataLayout map[string]util.DataLayout
+	// Size in bytes of the data of a single vertex
+	DataStride uint32
 	// Start indices for every sprite, first is 0, last is len(Inds)
 	IdxPositions []uint32
 	// The usage passed to BufferData
 	Usage uint32
+	// Size in bytes of the vertex and index data
+	Size uint32
+	// sprites from the latest Reallocate, used to make debug data
+	sprites []*vecsprite.VecSprite
+	// nil until drawn with a DebugMode
+	debug *spriteDebugData
+	// increased by Reallocate, so operations know when the vertex layout might have changed
+	generation uint64
 }
 
 func GLSpriteBuffer(s render.SpriteBuffer) (*SpriteBuffer, bool) {
@@ -39,9 +62,20 @@ func GLSpriteBuffer(s render.SpriteBuffer) (*SpriteBuffer, bool) {
 	return res, ok
 }
 
+// Estimated amount of GPU memory used in bytes
+func (s *SpriteBuffer) MemorySize() uint64 {
+	return uint64(s.Size)
+}
+
 func (s *SpriteBuffer) Free() {
 	s.cxt.DeleteBuffer(s.Verts)
 	s.cxt.DeleteBuffer(s.Inds)
+	s.cxt.DeleteBuffer(s.UVs)
+	s.cxt.DeleteBuffer(s.Data)
+	if s.debug != nil {
+		s.debug.free(s.cxt)
+		s.debug = nil
+	}
 }
 
 func (s *spriteBufferBuilder) AddSprite(sprite *vecsprite.VecSprite) uint32 {
@@ -50,9 +84,39 @@ func (s *spriteBufferBuilder) AddSprite(sprite *vecsprite.VecSprite) uint32 {
 	return uint32(len(s.sprites) - 1)
 }
 
+func (s *spriteBufferBuilder) AddCompiled(sprites []*vecsprite.VecSprite, compiled *vecsprite.Compiled) uint32 {
+	if len(s.sprites) == 0 {
+		s.compiled = compiled
+		s.compiledSprites = append([]*vecsprite.VecSprite(nil), sprites...)
+	}
+	first := uint32(len(s.sprites))
+	s.sprites = append(s.sprites, sprites...)
+	return first
+}
+
+// returns the compiled data from AddCompiled if it can be used, otherwise the sprites are compiled
+func (s *spriteBufferBuilder) compile() (verts, inds, idxPositions []uint32) {
+	if s.compiled != nil && len(s.sprites) == len(s.compiledSprites) && len(s.compiled.IdxPositions) == len(s.sprites)+1 {
+		same := true
+		for i, sprite := range s.sprites {
+			same = same && sprite == s.compiledSprites[i]
+		}
+		if same {
+			return s.compiled.Vertices, s.compiled.Indices, s.compiled.IdxPositions
+		}
+	}
+	return util.CompileVecSpriteBuffer(s.sprites)
+}
+
+func (s *spriteBufferBuilder) ReplaceSprite(id uint32, sprite *vecsprite.VecSprite) error {
+	if int(id) >= len(s.sprites) {
+		return fmt.Errorf("Cannot replace sprite %v, the builder only has %v sprites", id, len(s.sprites))
+	}
+	s.sprites[id] = sprite
+	return nil
+}
+
 func (s *spriteBufferBuilder) MakeBuffer(static bool) render.SpriteBuffer {
-	// make sure to not modify some VAO
-	s.cxt.BindVertexArray(nil)
 	sb := new(SpriteBuffer)
 	sb.cxt = s.cxt
 	if static {
@@ -63,15 +127,36 @@ func (s *spriteBufferBuilder) MakeBuffer(static bool) render.SpriteBuffer {
 	// data
 	sb.Verts = s.cxt.CreateBuffer()
 	sb.Inds = s.cxt.CreateBuffer()
+	sb.UVs = s.cxt.CreateBuffer()
+	sb.Data = s.cxt.CreateBuffer()
 	s.Reallocate(sb)
 	return sb
 }
 
 func (s *spriteBufferBuilder) Reallocate(buffer render.SpriteBuffer) {
-	sb, _ := GLSpriteBuffer(buffer)
+	sb, ok := GLSpriteBuffer(buffer)
+	// there is no error to return, so buffers from other renderers are left as they are
+	if !ok {
+		return
+	}
+	// make sure to not modify some VAO, since the element buffer is stored on it
+	s.cxt.BindVertexArray(nil)
 	var verts, inds []uint32
 	// turn sprites into arrays for verts and inds
-	verts, inds, sb.IdxPositions = util.CompileVecSpriteBuffer(s.sprites)
+	verts, inds, sb.IdxPositions = s.compile()
+	// uvs are in their own buffer, so sprites without them do not use more memory
+	uvs := util.CompileVecSpriteUVs(s.sprites)
+	sb.Textured = uvs != nil
+	var data []uint32
+	data, sb.DataLayout, sb.DataStride = util.CompileVecSpriteData(s.sprites)
+	sb.Size = uint32(len(verts)+len(inds)+len(uvs)+len(data)) * 4
+	sb.generation++
+	// copied, since sprites can be replaced in the builder before the next Reallocate
+	sb.sprites = append([]*vecsprite.VecSprite(nil), s.sprites...)
+	if sb.debug != nil {
+		sb.debug.free(s.cxt)
+		sb.debug = nil
+	}
 	// vertex buffer
 	s.cxt.BindBuffer(enum.ARRAY_BUFFER, sb.Verts)
 	// only * 4 because type is slice of uint32
@@ -83,10 +168,20 @@ func (s *spriteBufferBuilder) Reallocate(buffer render.SpriteBuffer) {
 	if len(inds) > 0 {
 		s.cxt.BufferData(enum.ELEMENT_ARRAY_BUFFER, inds, sb.Usage)
 	}
+	if sb.Textured {
+		s.cxt.BindBuffer(enum.ARRAY_BUFFER, sb.UVs)
+		s.cxt.BufferData(enum.ARRAY_BUFFER, uvs, sb.Usage)
+	}
+	if len(data) > 0 {
+		s.cxt.BindBuffer(enum.ARRAY_BUFFER, sb.Data)
+		s.cxt.BufferData(enum.ARRAY_BUFFER, data, sb.Usage)
+	}
 }
 
 func (s *spriteBufferBuilder) Clear() {
 	s.sprites = make([]*vecsprite.VecSprite, 0)
+	s.compiled = nil
+	s.compiledSprites = nil
 }
 
 /*
@@ -104,6 +199,13 @@ type DataBuffer struct {
 	Layout []render.InputType
 	// Size in bytes of a section of the buffer
 	LayoutSize uint16
+	// Size in bytes of the data
+	Size uint32
+}
+
+func GLDataBuffer(d render.DataBuffer) (*DataBuffer, bool) {
+	res, ok := d.(*DataBuffer)
+	return res, ok
 }
 
 func (r *Renderer) MakeDataBuffer(static bool) render.DataBuffer {
@@ -124,6 +226,11 @@ func (d *DataBuffer) bind() {
 	d.cxt.BindBuffer(enum.ARRAY_BUFFER, d.Buffer)
 }
 
+// Estimated amount of GPU memory used in bytes
+func (d *DataBuffer) MemorySize() uint64 {
+	return uint64(d.Size)
+}
+
 func (d *DataBuffer) Free() {
 	d.cxt.DeleteBuffer(d.Buffer)
 }
@@ -132,6 +239,7 @@ func (d *DataBuffer) Free() {
 // code repitition will work for now
 func (d *DataBuffer) SetData8(data []uint8) {
 	d.bind()
+	d.Size = uint32(len(data)) * 1
 	if len(data) > 0 {
 		d.cxt.BufferData(enum.ARRAY_BUFFER, data, d.Usage)
 	}
@@ -139,6 +247,7 @@ func (d *DataBuffer) SetData8(data []uint8) {
 
 func (d *DataBuffer) SetData16(data []uint16) {
 	d.bind()
+	d.Size = uint32(len(data)) * 2
 	if len(data) > 0 {
 		d.cxt.BufferData(enum.ARRAY_BUFFER, data, d.Usage)
 	}
@@ -146,6 +255,7 @@ func (d *DataBuffer) SetData16(data []uint16) {
 
 func (d *DataBuffer) SetData32(data []uint32) {
 	d.bind()
+	d.Size = uint32(len(data)) * 4
 	if len(data) > 0 {
 		d.cxt.BufferData(enum.ARRAY_BUFFER, data, d.Usage)
 	}
@@ -153,6 +263,7 @@ func (d *DataBuffer) SetData32(data []uint32) {
 
 func (d *DataBuffer) SetData64(data []uint64) {
 	d.bind()
+	d.Size = uint32(len(data)) * 8
 	if len(data) > 0 {
 		d.cxt.BufferData(enum.ARRAY_BUFFER, data, d.Usage)
 	}
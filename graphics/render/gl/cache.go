@@ -0,0 +1,230 @@
+package gl
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/eliiasg/deltawing/graphics/render/gl/glsl"
+	"github.com/eliiasg/deltawing/graphics/render/gl/shader"
+	"github.com/eliiasg/glow/enum"
+)
+
+// A linked program shared by every Procedure with the same source
+type program struct {
+	prog               any
+	screenSizeLocation any
+	timeLocation       any
+	debugModeLocation  any
+	uniformLocations   map[string]any
+	// sources of the shaders used by the program, empty if it was loaded from a binary
+	shaders []shaderKey
+	// amount of Procedures using the program
+	refs int
+}
+
+type shaderKey struct {
+	typ    uint32
+	source string
+}
+
+type compiledShader struct {
+	shader any
+	refs   int
+}
+
+// Reuses programs and shaders with the same source, most procedures use the same fragment shader
+type programCache struct {
+	cxt Context
+	// nil if the context does not support program binaries
+	binaryCxt ProgramBinaryContext
+	programs  map[string]*program
+	shaders   map[shaderKey]*compiledShader
+	// directory for program binaries, empty if they are not saved
+	dir string
+}
+
+// binaryCxt is used for program binaries, since cxt might wrap it
+func newProgramCache(cxt, binaryCxt Context) *programCache {
+	binary, _ := binaryCxt.(ProgramBinaryContext)
+	return &programCache{
+		cxt:       cxt,
+		binaryCxt: binary,
+		programs:  make(map[string]*program),
+		shaders:   make(map[shaderKey]*compiledShader),
+	}
+}
+
+func programKey(source *shader.ProgramSource) string {
+	// null byte since it cannot be in either source
+	return source.VertexSource + "\x00" + source.FragmentSource
+}
+
+// Saves linked programs in dir, and loads them from there instead of compiling when possible
+// Only works if the Context implements ProgramBinaryContext and the driver supports it
+func (c *programCache) setDir(dir string) error {
+	if c.binaryCxt == nil || !c.binaryCxt.SupportsProgramBinary() {
+		return errors.New("Program binaries are not supported by this context")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	c.dir = dir
+	return nil
+}
+
+// Returns the program for the source, and compiles it if it is not cached
+// Every call should have a matching release
+func (c *programCache) acquire(source *shader.ProgramSource) (*program, error) {
+	key := programKey(source)
+	if prog, ok := c.programs[key]; ok {
+		prog.refs++
+		return prog, nil
+	}
+	prog := c.load(key)
+	if prog == nil {
+		var err error
+		prog, err = c.compile(source)
+		if err != nil {
+			return nil, err
+		}
+		c.save(key, prog)
+	}
+	prog.screenSizeLocation = c.cxt.GetUniformLocation(prog.prog, "screenSize")
+	prog.timeLocation = c.cxt.GetUniformLocation(prog.prog, "time")
+	prog.debugModeLocation = c.cxt.GetUniformLocation(prog.prog, "debugMode")
+	prog.uniformLocations = make(map[string]any)
+	for _, name := range source.UniformNames {
+		prog.uniformLocations[name] = c.cxt.GetUniformLocation(prog.prog, name)
+	}
+	prog.refs = 1
+	c.programs[key] = prog
+	return prog, nil
+}
+
+// Deletes the program when no Procedures use it anymore
+func (c *programCache) release(source *shader.ProgramSource) {
+	key := programKey(source)
+	prog, ok := c.programs[key]
+	if !ok {
+		return
+	}
+	prog.refs--
+	if prog.refs > 0 {
+		return
+	}
+	delete(c.programs, key)
+	c.cxt.DeleteProgram(prog.prog)
+	for _, key := range prog.shaders {
+		c.releaseShader(key)
+	}
+}
+
+func (c *programCache) compile(source *shader.ProgramSource) (*program, error) {
+	vertKey := shaderKey{enum.VERTEX_SHADER, source.VertexSource}
+	vert, err := c.acquireShader(vertKey, source.VertexLocations)
+	if err != nil {
+		return nil, err
+	}
+	fragKey := shaderKey{enum.FRAGMENT_SHADER, source.FragmentSource}
+	frag, err := c.acquireShader(fragKey, source.FragmentLocations)
+	if err != nil {
+		c.releaseShader(vertKey)
+		return nil, err
+	}
+	var binaryCxt ProgramBinaryContext
+	if c.dir != "" {
+		binaryCxt = c.binaryCxt
+	}
+	prog, err := createProgram(c.cxt, vert, frag, binaryCxt)
+	if err != nil {
+		c.releaseShader(vertKey)
+		c.releaseShader(fragKey)
+		return nil, err
+	}
+	return &program{prog: prog, shaders: []shaderKey{vertKey, fragKey}}, nil
+}
+
+func (c *programCache) acquireShader(key shaderKey, locations []glsl.Location) (any, error) {
+	if compiled, ok := c.shaders[key]; ok {
+		compiled.refs++
+		return compiled.shader, nil
+	}
+	shader, err := compileShader(c.cxt, key.typ, key.source, locations)
+	if err != nil {
+		return nil, err
+	}
+	c.shaders[key] = &compiledShader{shader, 1}
+	return shader, nil
+}
+
+func (c *programCache) releaseShader(key shaderKey) {
+	compiled, ok := c.shaders[key]
+	if !ok {
+		return
+	}
+	compiled.refs--
+	if compiled.refs > 0 {
+		return
+	}
+	delete(c.shaders, key)
+	c.cxt.DeleteShader(compiled.shader)
+}
+
+func (c *programCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".bin")
+}
+
+// Returns nil if there is no saved binary, or if the driver rejects it
+func (c *programCache) load(key string) *program {
+	if c.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.path(key))
+	// a missing file just means it has not been saved yet
+	if err != nil || len(data) < 4 {
+		return nil
+	}
+	cxt := c.binaryCxt
+	prog := cxt.CreateProgram()
+	cxt.ProgramBinary(prog, binary.LittleEndian.Uint32(data), data[4:])
+	if cxt.GetProgramParameter(prog, enum.LINK_STATUS) == enum.FALSE {
+		// likely made by another driver, it will be overwritten when compiled
+		cxt.DeleteProgram(prog)
+		return nil
+	}
+	return &program{prog: prog, shaders: make([]shaderKey, 0)}
+}
+
+// Failing to save is ignored, since the program will just be compiled next time
+func (c *programCache) save(key string, prog *program) {
+	if c.dir == "" {
+		return
+	}
+	format, bin := c.binaryCxt.GetProgramBinary(prog.prog)
+	if len(bin) == 0 {
+		return
+	}
+	data := binary.LittleEndian.AppendUint32(make([]byte, 0, len(bin)+4), format)
+	// written to another file first, so other programs using the same dir or a crash never leave half a binary
+	file, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return
+	}
+	// temp files are only readable by the owner
+	file.Chmod(0o644)
+	_, err = file.Write(append(data, bin...))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.path(key))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+}
@@ -29,25 +29,33 @@ type Context interface {
 	BindTexture(target uint32, texture any)
 	BindVertexArray(array any)
 
+	ActiveTexture(texture uint32)
 	AttachShader(program any, shader any)
 	// WARNING: might override bound TEXTURE_2D in webgl
 	BlitFramebuffer(srcX0 int32, srcY0 int32, srcX1 int32, srcY1 int32, dstX0 int32, dstY0 int32, dstX1 int32, dstY1 int32, mask uint32, filter uint32)
 	BufferData(target uint32, data any, usage uint32)
 	Clear(mask uint32)
+	// Used for integer buffers, since ClearColor only works for normalized and float buffers
+	ClearBufferuiv(buffer uint32, drawbuffer int32, value []uint32)
 	ClearColor(r, g, b, a float32)
 	CompileShader(shader any)
+	DisableVertexAttribArray(index uint32)
 	DrawElementsInstanced(mode uint32, count int32, xtype uint32, indexOffset uintptr, instancecount int32)
 	EnableVertexAttribArray(index uint32)
 	FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer any)
 	FramebufferTexture2D(target uint32, attachment uint32, textarget uint32, texture any, level int32)
+	GenerateMipmap(target uint32)
 	GetProgramInfoLog(program any) string
 	GetProgramParameter(program any, pname uint32) int32
 	GetShaderInfoLog(shader any) string
 	GetShaderParameter(shader any, pname uint32) int32
 	GetUniformLocation(program any, name string) any
 	LinkProgram(program any)
+	// pixels must be a slice big enough for the region, it is filled with the data
+	ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels any)
 	RenderbufferStorageMultisample(target uint32, samples int32, internalformat uint32, width int32, height int32)
 	ShaderSource(shader any, source string)
+	TexParameteri(target uint32, pname uint32, param int32)
 	TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels any)
 	UseProgram(program any)
 	VertexAttribDivisor(index uint32, divisor uint32)
@@ -70,3 +78,15 @@ type Context interface {
 
 	// gl.Enable left to platform specific init functions
 }
+
+// Optionally implemented by Contexts that can save and load linked programs, used to persist the program cache
+type ProgramBinaryContext interface {
+	Context
+	// Returns false if the driver does not support program binaries
+	SupportsProgramBinary() bool
+	// Must be called before LinkProgram, otherwise GetProgramBinary might not work
+	SetProgramBinaryRetrievable(program any)
+	GetProgramBinary(program any) (format uint32, binary []byte)
+	// Check LINK_STATUS afterwards, the driver may reject binaries made by other drivers or versions
+	ProgramBinary(program any, format uint32, binary []byte)
+}
@@ -0,0 +1,158 @@
+package gl
+
+import (
+	"math"
+
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+	"github.com/eliiasg/deltawing/util/buffers"
+	"github.com/eliiasg/glow/enum"
+)
+
+// Ways of drawing operations to find problems with sprites and layers, set with Renderer.SetDebugMode
+// Lines are used instead of polygon modes, since WebGL does not have them
+type DebugMode struct {
+	// Draws the edges of every triangle instead of filling them
+	Wireframe bool
+	// Draws the bounding box of the sprite of every instance on top of everything, transformed like the sprite
+	Bounds bool
+	// Colors triangles by their layer instead of with the procedure, so the order of layers can be seen
+	LayerColors bool
+}
+
+// values of the debugMode uniform in the fragment shader
+const (
+	debugUniformOff uint32 = iota
+	debugUniformLayers
+	debugUniformBounds
+)
+
+type debugState struct {
+	mode DebugMode
+	// the uniform is only set after a mode has been enabled, so debugging costs nothing before that
+	used bool
+}
+
+func (r *Renderer) SetDebugMode(mode DebugMode) {
+	r.debug.mode = mode
+	if mode != (DebugMode{}) {
+		r.debug.used = true
+	}
+}
+
+func (r *Renderer) DebugMode() DebugMode {
+	return r.debug.mode
+}
+
+// Lines for the debug modes, made the first time a sprite buffer is drawn in a debug mode
+type spriteDebugData struct {
+	// Indices of lines, first the edges of triangles, then bounding boxes
+	Inds any
+	// Corners of the bounding box of every sprite, 4 per sprite
+	BoundsVerts any
+	// Start indices of the edges of every sprite in Inds, like SpriteBuffer.IdxPositions
+	WireIdxPositions []uint32
+	// Position of the first bounding box in Inds, every sprite has 8 indices
+	BoundsIdxStart uint32
+}
+
+func makeSpriteDebugData(cxt Context, sprites []*vecsprite.VecSprite) *spriteDebugData {
+	d := &spriteDebugData{WireIdxPositions: make([]uint32, 0, len(sprites)+1)}
+	inds := make([]uint32, 0)
+	verts := make([]uint32, 0, len(sprites)*12)
+	// edges, the vertex offset is the same as in util.CompileVecSpriteBuffer
+	var vertPos uint32
+	for _, sprite := range sprites {
+		d.WireIdxPositions = append(d.WireIdxPositions, uint32(len(inds)))
+		for i := 0; i+2 < len(sprite.Indices); i += 3 {
+			a, b, c := sprite.Indices[i]+vertPos, sprite.Indices[i+1]+vertPos, sprite.Indices[i+2]+vertPos
+			inds = append(inds, a, b, b, c, c, a)
+		}
+		vertPos += uint32(len(sprite.Vertices))
+	}
+	d.WireIdxPositions = append(d.WireIdxPositions, uint32(len(inds)))
+	// bounding boxes
+	d.BoundsIdxStart = uint32(len(inds))
+	for i, sprite := range sprites {
+		minX, minY, maxX, maxY := spriteBounds(sprite)
+		for _, corner := range [4][2]float32{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}} {
+			buffers.AddTo(&verts, corner[0])
+			buffers.AddTo(&verts, corner[1])
+			// the color is set by the shader, highest layer in the operation
+			buffers.AddTo(&verts, [4]uint8{255, 0, 255, 255})
+		}
+		start := uint32(i) * 4
+		inds = append(inds, start, start+1, start+1, start+2, start+2, start+3, start+3, start)
+	}
+	cxt.BindVertexArray(nil)
+	d.Inds = cxt.CreateBuffer()
+	cxt.BindBuffer(enum.ELEMENT_ARRAY_BUFFER, d.Inds)
+	if len(inds) > 0 {
+		cxt.BufferData(enum.ELEMENT_ARRAY_BUFFER, inds, enum.STATIC_DRAW)
+	}
+	d.BoundsVerts = cxt.CreateBuffer()
+	cxt.BindBuffer(enum.ARRAY_BUFFER, d.BoundsVerts)
+	if len(verts) > 0 {
+		cxt.BufferData(enum.ARRAY_BUFFER, verts, enum.STATIC_DRAW)
+	}
+	return d
+}
+
+func spriteBounds(sprite *vecsprite.VecSprite) (minX, minY, maxX, maxY float32) {
+	if len(sprite.Vertices) == 0 {
+		return 0, 0, 0, 0
+	}
+	minX, minY = math.MaxFloat32, math.MaxFloat32
+	maxX, maxY = -math.MaxFloat32, -math.MaxFloat32
+	for _, vert := range sprite.Vertices {
+		minX = min(minX, vert[0])
+		minY = min(minY, vert[1])
+		maxX = max(maxX, vert[0])
+		maxY = max(maxY, vert[1])
+	}
+	return
+}
+
+func (d *spriteDebugData) free(cxt Context) {
+	cxt.DeleteBuffer(d.Inds)
+	cxt.DeleteBuffer(d.BoundsVerts)
+}
+
+// Draws the operation with the debug mode, the operation must already be bound
+// data must be made before binding, since making it unbinds the VAO
+func (o *Operation) drawDebug(mode DebugMode, data *spriteDebugData) {
+	if mode.LayerColors {
+		o.setDebugUniform(debugUniformLayers)
+	} else {
+		o.setDebugUniform(debugUniformOff)
+	}
+	if mode.Wireframe {
+		start := data.WireIdxPositions[o.SpriteID]
+		amount := data.WireIdxPositions[o.SpriteID+1] - start
+		o.cxt.BindBuffer(enum.ELEMENT_ARRAY_BUFFER, data.Inds)
+		o.cxt.DrawElementsInstanced(enum.LINES, int32(amount), enum.UNSIGNED_INT, uintptr(start*4), int32(o.InstanceAmt))
+	} else {
+		o.cxt.DrawElementsInstanced(enum.TRIANGLES, o.SpriteIdxAmt, enum.UNSIGNED_INT, uintptr(o.SpriteIdxStart*4), int32(o.InstanceAmt))
+	}
+	if mode.Bounds {
+		o.setDebugUniform(debugUniformBounds)
+		o.cxt.BindBuffer(enum.ELEMENT_ARRAY_BUFFER, data.Inds)
+		o.setVertexBuffer(data.BoundsVerts, nil)
+		start := data.BoundsIdxStart + o.SpriteID*8
+		o.cxt.DrawElementsInstanced(enum.LINES, 8, enum.UNSIGNED_INT, uintptr(start*4), int32(o.InstanceAmt))
+		o.setVertexBuffer(o.Sprite.Verts, o.Sprite)
+	}
+	// the element buffer is stored on the VAO
+	o.cxt.BindBuffer(enum.ELEMENT_ARRAY_BUFFER, o.Sprite.Inds)
+}
+
+func (o *Operation) setDebugUniform(value uint32) {
+	o.cxt.Uniform1ui(o.Proc.DebugModeLocation, value)
+}
+
+// returns the debug data of the buffer, and makes it if needed
+func (s *SpriteBuffer) debugData() *spriteDebugData {
+	if s.debug == nil {
+		s.debug = makeSpriteDebugData(s.cxt, s.sprites)
+	}
+	return s.debug
+}
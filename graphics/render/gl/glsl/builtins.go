@@ -0,0 +1,53 @@
+package glsl
+
+import "strings"
+
+func set(words string) map[string]bool {
+	res := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		res[word] = true
+	}
+	return res
+}
+
+// Based on the GLSL ES 3.00 specification: https://registry.khronos.org/OpenGL/specs/es/3.0/GLSL_ES_Specification_3.00.pdf
+
+var builtinTypes = set(`
+	void bool int uint float
+	vec2 vec3 vec4 bvec2 bvec3 bvec4 ivec2 ivec3 ivec4 uvec2 uvec3 uvec4
+	mat2 mat3 mat4 mat2x2 mat2x3 mat2x4 mat3x2 mat3x3 mat3x4 mat4x2 mat4x3 mat4x4
+	sampler2D sampler3D samplerCube sampler2DShadow samplerCubeShadow sampler2DArray sampler2DArrayShadow
+	isampler2D isampler3D isamplerCube isampler2DArray usampler2D usampler3D usamplerCube usampler2DArray
+`)
+
+// keywords that may appear before a type in a declaration
+var qualifiers = set(`
+	const in out inout uniform flat smooth centroid invariant highp mediump lowp
+`)
+
+var keywords = set(`
+	if else for while do switch case default break continue return discard true false struct precision layout
+`)
+
+var builtinFunctions = set(`
+	radians degrees sin cos tan asin acos atan sinh cosh tanh asinh acosh atanh
+	pow exp log exp2 log2 sqrt inversesqrt
+	abs sign floor trunc round roundEven ceil fract mod modf min max clamp mix step smoothstep isnan isinf
+	floatBitsToInt floatBitsToUint intBitsToFloat uintBitsToFloat
+	packSnorm2x16 unpackSnorm2x16 packUnorm2x16 unpackUnorm2x16 packHalf2x16 unpackHalf2x16
+	length distance dot cross normalize faceforward reflect refract
+	matrixCompMult outerProduct transpose determinant inverse
+	lessThan lessThanEqual greaterThan greaterThanEqual equal notEqual any all not
+	textureSize texture textureProj textureLod textureOffset texelFetch texelFetchOffset textureProjOffset
+	textureLodOffset textureProjLod textureProjLodOffset textureGrad textureGradOffset textureProjGrad textureProjGradOffset
+	dFdx dFdy fwidth
+`)
+
+func isType(name string, file *File) bool {
+	return builtinTypes[name] || file.Types[name]
+}
+
+// every name starting with gl_ is reserved for builtin variables and constants
+func isBuiltinVariable(name string) bool {
+	return strings.HasPrefix(name, "gl_")
+}
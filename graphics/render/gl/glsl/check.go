@@ -0,0 +1,213 @@
+package glsl
+
+import "fmt"
+
+// Checks that every identifier used in the function bodies is declared
+// known is used for names declared outside the file, like the variables of the shader the file is put in
+func (f *File) CheckIdentifiers(known func(name string) bool) error {
+	for _, function := range f.Functions {
+		if err := f.checkFunction(function, known); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type scope struct {
+	// variables and local structs, true for structs
+	names map[string]bool
+	// scope of the variables declared in a for header, it ends with the body of the loop
+	loop bool
+	// paren depth of the for, so the end of the header is known
+	depth int
+	// true after the header, the next statement is the body
+	inBody bool
+	// index of the first token of the body
+	bodyStart int
+	// true if the body is a block, otherwise it ends at the next ';'
+	block bool
+}
+
+type scopes []*scope
+
+func newScope() *scope {
+	return &scope{names: make(map[string]bool)}
+}
+
+func (s scopes) has(name string) bool {
+	for _, sc := range s {
+		if _, ok := sc.names[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s scopes) isType(name string) bool {
+	for _, sc := range s {
+		if sc.names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func (s scopes) top() *scope {
+	return s[len(s)-1]
+}
+
+// true if the top scope is a loop with a body that ends at the next ';'
+func (s scopes) inStatementLoop(depth int) bool {
+	top := s.top()
+	return top.loop && top.inBody && !top.block && depth == top.depth
+}
+
+func (f *File) checkFunction(function *Function, known func(string) bool) error {
+	params := newScope()
+	for _, param := range function.Params {
+		params.names[param.Name] = false
+	}
+	sc := scopes{params}
+	body := function.body
+	// used to find the end of declarations like 'float a = 1.0, b;'
+	depth := 0
+	inDecl := false
+	declDepth := 0
+	// true if the next '{' is the body of a struct, so the names after its '}' are declared
+	structBody := false
+	// the scopes of struct bodies
+	structs := make(map[*scope]bool)
+	declare := func(name string, isType bool) {
+		sc.top().names[name] = isType
+	}
+	for i := 0; i < len(body); i++ {
+		t := body[i]
+		var next token
+		if i+1 < len(body) {
+			next = body[i+1]
+		}
+		if t.kind == tokenOperator {
+			switch t.text {
+			case "{":
+				sc = append(sc, newScope())
+				if structBody {
+					structs[sc.top()] = true
+					structBody = false
+				}
+				// body of a for loop
+				if top := sc[len(sc)-2]; top.loop && top.inBody && i == top.bodyStart {
+					top.block = true
+				}
+			case "}":
+				// loops with a single statement that did not end with ';', like 'for (...) if (a) {...}'
+				for len(sc) > 1 && sc.top().loop {
+					sc = sc[:len(sc)-1]
+				}
+				if len(sc) == 1 {
+					return fmt.Errorf("line %v: unexpected '}' in %v", t.line, function.Name)
+				}
+				closed := sc.top()
+				sc = sc[:len(sc)-1]
+				if structs[closed] && next.kind == tokenIdent {
+					// 'struct S {...} s;'
+					declare(next.text, false)
+					inDecl = true
+					declDepth = depth
+					i++
+				}
+				// the loop the block was the body of, and loops that had that loop as their body
+				if top := sc.top(); top.loop && top.inBody && top.block {
+					sc = sc[:len(sc)-1]
+					for sc.inStatementLoop(depth) {
+						sc = sc[:len(sc)-1]
+					}
+				}
+			case "(", "[":
+				depth++
+			case ")", "]":
+				depth--
+				if depth < declDepth {
+					inDecl = false
+				}
+				if top := sc.top(); t.text == ")" && top.loop && !top.inBody && depth == top.depth {
+					top.inBody = true
+					top.bodyStart = i + 1
+				}
+			case ";":
+				if depth == declDepth {
+					inDecl = false
+				}
+				for sc.inStatementLoop(depth) {
+					sc = sc[:len(sc)-1]
+				}
+			case ",":
+				if inDecl && depth == declDepth && next.kind == tokenIdent {
+					declare(next.text, false)
+					i++
+				}
+			}
+			continue
+		}
+		if t.kind != tokenIdent {
+			continue
+		}
+		// fields and swizzles
+		if i > 0 && body[i-1].text == "." {
+			continue
+		}
+		switch t.text {
+		case "struct":
+			if next.kind == tokenIdent {
+				declare(next.text, true)
+				i++
+			}
+			structBody = true
+			continue
+		case "for":
+			if next.text == "(" {
+				sc = append(sc, &scope{names: make(map[string]bool), loop: true, depth: depth})
+			}
+			continue
+		}
+		if keywords[t.text] || qualifiers[t.text] {
+			continue
+		}
+		if isType(t.text, f) || sc.isType(t.text) {
+			// a type followed by a name is a declaration, otherwise it is a constructor
+			// the name can be after array sizes, like 'float[3] a', the sizes are still checked
+			j := i + 1
+			for j < len(body) && body[j].text == "[" {
+				for open := 0; j < len(body); j++ {
+					if body[j].text == "[" {
+						open++
+					} else if body[j].text == "]" {
+						open--
+						if open == 0 {
+							j++
+							break
+						}
+					}
+				}
+			}
+			if j < len(body) && body[j].kind == tokenIdent {
+				declare(body[j].text, false)
+				inDecl = true
+				declDepth = depth
+				if j == i+1 {
+					i++
+				}
+			}
+			continue
+		}
+		if next.text == "(" {
+			if !builtinFunctions[t.text] && !f.HasFunction(t.text) && !f.Globals[t.text] && !known(t.text) {
+				return fmt.Errorf("line %v: unknown function '%v' in %v", t.line, t.text, function.Name)
+			}
+			continue
+		}
+		if !sc.has(t.text) && !f.Globals[t.text] && !isBuiltinVariable(t.text) && !known(t.text) {
+			return fmt.Errorf("line %v: unknown identifier '%v' in %v", t.line, t.text, function.Name)
+		}
+	}
+	return nil
+}
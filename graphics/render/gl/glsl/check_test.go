@@ -0,0 +1,73 @@
+package glsl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckIdentifiers(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		// empty if the body is valid
+		err string
+	}{
+		{"params and locals", "float b = a * 2.0; return b;", ""},
+		{"several declarators", "float b = 1.0, c = b; return b + c;", ""},
+		{"unknown identifier", "return a + missing;", "unknown identifier 'missing'"},
+		{"unknown function", "return missing(a);", "unknown function 'missing'"},
+		{"builtins", "return sin(a) + gl_FragCoord.x;", ""},
+		{"known", "return a * outside;", ""},
+		{"swizzle", "vec2 v = vec2(a); return v.x + v.yx.y;", ""},
+		{"array after type", "float[3] b = float[3](a, a, a); return b[0];", ""},
+		{"array after name", "float b[2]; b[0] = a; return b[0];", ""},
+		{"array size is checked", "float[missing] b; return a;", "unknown identifier 'missing'"},
+		{"local struct", "struct S { float x; }; S s = S(a); return s.x;", ""},
+		{"local struct variable", "struct S { float x; } s; s.x = a; return s.x;", ""},
+		{"struct array", "struct S { float x; }; S[2] s; return s[0].x;", ""},
+		{"struct is scoped", "{ struct S { float x; }; } S s; return a;", "unknown identifier 'S'"},
+		{"block scope", "{ float b = a; } return b;", "unknown identifier 'b'"},
+		{"for", "float b = 0.0; for (int i = 0; i < 3; i++) { b += float(i); } return b;", ""},
+		{"for variable leaks", "for (int i = 0; i < 3; i++) { a += 1.0; } return float(i);", "unknown identifier 'i'"},
+		{"for without block", "float b = 0.0; for (int i = 0; i < 3; i++) b += float(i); return float(i);", "unknown identifier 'i'"},
+		{"nested for", "float b = 0.0; for (int i = 0; i < 3; i++) for (int j = 0; j < i; j++) { b += float(i * j); } return float(j);", "unknown identifier 'j'"},
+		{"for with if", "for (int i = 0; i < 3; i++) if (a > 0.0) { a -= float(i); } else { a += float(i); } return a;", ""},
+		{"for with two variables", "for (int i = 0, j = 1; i < j; i++) { a += float(j); } return a;", ""},
+		{"loop after loop", "for (int i = 0; i < 2; i++) { a += 1.0; } for (int i = 0; i < 2; i++) { a += float(i); } return a;", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := Parse("float f(float a) {\n" + test.body + "\n}")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = file.CheckIdentifiers(func(name string) bool { return name == "outside" })
+			if test.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestCheckFileDeclarations(t *testing.T) {
+	src := `
+#define SCALE 2.0
+struct Light { vec2 pos; };
+uniform float strength;
+float helper(Light l) { return l.pos.x * strength; }
+float f(float a) { Light l = Light(vec2(a)); return helper(l) * SCALE; }
+`
+	file, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.CheckIdentifiers(func(string) bool { return false }); err != nil {
+		t.Fatal(err)
+	}
+}
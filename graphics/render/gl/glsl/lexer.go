@@ -0,0 +1,114 @@
+package glsl
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind uint8
+
+const (
+	tokenIdent tokenKind = iota
+	tokenNumber
+	tokenOperator
+	// only '#define NAME', other preprocessor lines are skipped
+	tokenDefine
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// 1 based, like in compiler errors
+	line int
+}
+
+// longest first, so '<<=' is not read as '<<' and '='
+var operators = []string{
+	"<<=", ">>=",
+	"++", "--", "<<", ">>", "<=", ">=", "==", "!=", "&&", "||", "^^", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=",
+	"(", ")", "[", "]", "{", "}", ".", ",", ";", "+", "-", "*", "/", "%", "<", ">", "!", "~", "=", "&", "|", "^", "?", ":",
+}
+
+func isLetter(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func tokenize(src string) ([]token, error) {
+	tokens := make([]token, 0)
+	line := 1
+	// true if only whitespace has been read on the current line, used for preprocessor lines
+	lineStart := true
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			lineStart = true
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\x00':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("line %v: comment is never closed", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '#' && lineStart:
+			// preprocessor lines can continue with '\'
+			start := i
+			for i < len(src) && (src[i] != '\n' || src[i-1] == '\\') {
+				i++
+			}
+			directive := strings.Fields(strings.ReplaceAll(src[start+1:i], "\\\n", " "))
+			if len(directive) >= 2 && directive[0] == "define" {
+				name := directive[1]
+				// function like macros
+				if idx := strings.IndexByte(name, '('); idx != -1 {
+					name = name[:idx]
+				}
+				tokens = append(tokens, token{tokenDefine, name, line})
+			}
+			line += strings.Count(src[start:i], "\n")
+		case isLetter(c):
+			start := i
+			for i < len(src) && (isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, src[start:i], line})
+			lineStart = false
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || isLetter(src[i]) || src[i] == '.' ||
+				// exponent sign
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, src[start:i], line})
+			lineStart = false
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("line %v: unexpected character '%c'", line, c)
+			}
+			tokens = append(tokens, token{tokenOperator, op, line})
+			lineStart = false
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,53 @@
+package glsl
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Where a piece of code is placed in a generated shader
+type Location struct {
+	// Used in messages, like the name of a function
+	Name string
+	// First line in the generated shader, 1 based
+	Line int
+	// Amount of lines
+	Lines int
+}
+
+// Finds part in source, returns false if it is not there
+func Locate(source, part, name string) (Location, bool) {
+	idx := strings.Index(source, part)
+	if idx == -1 {
+		return Location{}, false
+	}
+	return Location{name, strings.Count(source[:idx], "\n") + 1, strings.Count(part, "\n") + 1}, true
+}
+
+// Matches the line number in the formats used by drivers, like:
+// 'ERROR: 0:12: ...' (WebGL/ANGLE), '0:12(5): error: ...' (Mesa) and '0(12) : error ...' (Nvidia)
+var logLine = regexp.MustCompile(`^\s*(?:ERROR: |WARNING: )?\d+[:(](\d+)`)
+
+// Adds the location to every line in a driver info log that points to a line inside one of the locations
+func MapLog(log string, locations []Location) string {
+	lines := strings.Split(strings.TrimRight(log, "\x00\n"), "\n")
+	for i, line := range lines {
+		match := logLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		num, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		for _, loc := range locations {
+			if num >= loc.Line && num < loc.Line+loc.Lines {
+				lines[i] = fmt.Sprintf("%v (in %v, line %v)", line, loc.Name, num-loc.Line+1)
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
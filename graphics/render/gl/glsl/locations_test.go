@@ -0,0 +1,38 @@
+package glsl
+
+import "testing"
+
+func TestLocate(t *testing.T) {
+	source := "line 1\nline 2\nfunc a\nfunc b\nline 5"
+	loc, ok := Locate(source, "func a\nfunc b", "f")
+	if !ok || loc != (Location{"f", 3, 2}) {
+		t.Fatalf("Got %+v, %v", loc, ok)
+	}
+	if _, ok := Locate(source, "missing", "f"); ok {
+		t.Fatal("Found missing part")
+	}
+}
+
+func TestMapLog(t *testing.T) {
+	locations := []Location{{"rotate", 10, 3}, {"scale", 20, 1}}
+	tests := []struct {
+		name     string
+		log      string
+		expected string
+	}{
+		{"angle", "ERROR: 0:11: 'x' : undeclared identifier", "ERROR: 0:11: 'x' : undeclared identifier (in rotate, line 2)"},
+		{"angle warning", "WARNING: 0:20: unused", "WARNING: 0:20: unused (in scale, line 1)"},
+		{"mesa", "0:12(5): error: syntax error", "0:12(5): error: syntax error (in rotate, line 3)"},
+		{"nvidia", "0(20) : error C1008: undefined variable", "0(20) : error C1008: undefined variable (in scale, line 1)"},
+		{"outside locations", "ERROR: 0:13: 'x' : undeclared identifier", "ERROR: 0:13: 'x' : undeclared identifier"},
+		{"other lines", "ERROR: 2 compilation errors.\x00\n", "ERROR: 2 compilation errors."},
+		{"several lines", "ERROR: 0:10: a\nERROR: 0:5: b\n", "ERROR: 0:10: a (in rotate, line 1)\nERROR: 0:5: b"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if res := MapLog(test.log, locations); res != test.expected {
+				t.Fatalf("Got %q, expected %q", res, test.expected)
+			}
+		})
+	}
+}
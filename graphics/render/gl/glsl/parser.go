@@ -0,0 +1,341 @@
+// A small GLSL ES 3.00 parser, used to catch mistakes in render.Functions before the driver compiles them
+// It only parses declarations, function bodies are checked token by token
+package glsl
+
+import (
+	"fmt"
+)
+
+type Qualifier uint8
+
+const (
+	QualifierIn Qualifier = iota
+	QualifierOut
+	QualifierInOut
+)
+
+func (q Qualifier) String() string {
+	switch q {
+	case QualifierOut:
+		return "out"
+	case QualifierInOut:
+		return "inout"
+	}
+	return "in"
+}
+
+// Returns true if the function can write to the parameter
+func (q Qualifier) Writes() bool {
+	return q == QualifierOut || q == QualifierInOut
+}
+
+type Param struct {
+	Qualifier Qualifier
+	// GLSL type name, like vec2
+	Type string
+	// empty if the parameter is unnamed
+	Name string
+}
+
+type Function struct {
+	Name       string
+	ReturnType string
+	Params     []Param
+	// line of the function name, 1 based
+	Line int
+	// tokens between the braces, nil if the function is only declared
+	body []token
+}
+
+// Returns false if the function is only declared
+func (f *Function) HasBody() bool {
+	return f.body != nil
+}
+
+// A parsed GLSL source
+type File struct {
+	Functions []*Function
+	// global variables and macros
+	Globals map[string]bool
+	// names of structs
+	Types map[string]bool
+}
+
+// Returns the function with the given name and amount of parameters, or nil if there is none
+func (f *File) Function(name string, params int) *Function {
+	for _, function := range f.Functions {
+		if function.Name == name && len(function.Params) == params {
+			return function
+		}
+	}
+	return nil
+}
+
+// Returns true if a function with the given name exists
+func (f *File) HasFunction(name string) bool {
+	for _, function := range f.Functions {
+		if function.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	file   *File
+	// if false, unknown tokens at the top level are skipped
+	strict bool
+}
+
+// Parses the declarations in a GLSL source, like the source of a render.Function
+func Parse(src string) (*File, error) {
+	return parse(src, true)
+}
+
+// Same as Parse, but unknown code outside functions is skipped
+// Used for shaders with keywords like '<attributes>' that are replaced later
+func ParseTemplate(src string) (*File, error) {
+	return parse(src, false)
+}
+
+func parse(src string, strict bool) (*File, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{
+		tokens: tokens,
+		file: &File{
+			Functions: make([]*Function, 0),
+			Globals:   make(map[string]bool),
+			Types:     make(map[string]bool),
+		},
+		strict: strict,
+	}
+	for !p.done() {
+		if err := p.parseTopLevel(); err != nil {
+			return nil, err
+		}
+	}
+	return p.file, nil
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+// returns an empty token at the end, to avoid checking done everywhere
+func (p *parser) peek() token {
+	if p.done() {
+		line := 0
+		if len(p.tokens) > 0 {
+			line = p.tokens[len(p.tokens)-1].line
+		}
+		return token{tokenOperator, "", line}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("line %v: %v", t.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(text string) error {
+	t := p.next()
+	if t.text != text {
+		if t.text == "" {
+			return p.errorf(t, "expected '%v' but the source ended", text)
+		}
+		return p.errorf(t, "expected '%v' but got '%v'", text, t.text)
+	}
+	return nil
+}
+
+// skips tokens until the closing bracket of the one just read, returns the skipped tokens
+func (p *parser) skipBlock(open, close string) ([]token, error) {
+	start := p.pos
+	depth := 1
+	for !p.done() {
+		t := p.next()
+		switch t.text {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return p.tokens[start : p.pos-1], nil
+			}
+		}
+	}
+	return nil, p.errorf(p.tokens[start-1], "'%v' is never closed", open)
+}
+
+func (p *parser) parseTopLevel() error {
+	t := p.peek()
+	switch {
+	case t.kind == tokenDefine:
+		p.file.Globals[t.text] = true
+		p.next()
+	case t.text == "precision":
+		for !p.done() && p.next().text != ";" {
+		}
+	case t.text == "layout":
+		p.next()
+		if err := p.expect("("); err != nil {
+			return err
+		}
+		_, err := p.skipBlock("(", ")")
+		return err
+	case qualifiers[t.text]:
+		p.next()
+	case t.text == "struct":
+		return p.parseStruct()
+	case t.kind == tokenIdent && isType(t.text, p.file):
+		return p.parseDeclaration()
+	case p.strict:
+		return p.errorf(t, "unexpected '%v' outside of function", t.text)
+	default:
+		p.next()
+	}
+	return nil
+}
+
+func (p *parser) parseStruct() error {
+	p.next()
+	name := p.next()
+	if name.kind != tokenIdent {
+		return p.errorf(name, "expected struct name")
+	}
+	p.file.Types[name.text] = true
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	if _, err := p.skipBlock("{", "}"); err != nil {
+		return err
+	}
+	// 'struct A {...} a;' also declares a variable
+	if p.peek().kind == tokenIdent {
+		p.file.Globals[p.next().text] = true
+	}
+	return p.parseDeclarators()
+}
+
+// skips array sizes like [4]
+func (p *parser) skipArray() error {
+	for p.peek().text == "[" {
+		p.next()
+		if _, err := p.skipBlock("[", "]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseDeclaration() error {
+	typ := p.next()
+	if err := p.skipArray(); err != nil {
+		return err
+	}
+	name := p.peek()
+	if name.kind != tokenIdent {
+		if p.strict {
+			return p.errorf(name, "expected name after '%v'", typ.text)
+		}
+		return nil
+	}
+	p.next()
+	if p.peek().text == "(" {
+		return p.parseFunction(typ.text, name)
+	}
+	p.file.Globals[name.text] = true
+	return p.parseDeclarators()
+}
+
+// reads the rest of a variable declaration, including other variables like in 'float a, b;'
+func (p *parser) parseDeclarators() error {
+	depth := 0
+	for !p.done() {
+		t := p.next()
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		case ",":
+			if depth == 0 && p.peek().kind == tokenIdent {
+				p.file.Globals[p.next().text] = true
+			}
+		case ";":
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return p.errorf(p.peek(), "expected ';' but the source ended")
+}
+
+func (p *parser) parseFunction(returnType string, name token) error {
+	function := &Function{Name: name.text, ReturnType: returnType, Params: make([]Param, 0), Line: name.line}
+	p.next()
+	// 'f()' and 'f(void)'
+	if p.peek().text == "void" && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == ")" {
+		p.next()
+	}
+	for p.peek().text != ")" {
+		param, err := p.parseParam()
+		if err != nil {
+			return err
+		}
+		function.Params = append(function.Params, param)
+		if p.peek().text == "," {
+			p.next()
+		} else if p.peek().text != ")" {
+			return p.errorf(p.peek(), "expected ',' or ')' in parameters of %v", function.Name)
+		}
+	}
+	p.next()
+	p.file.Functions = append(p.file.Functions, function)
+	// prototype
+	if p.peek().text == ";" {
+		p.next()
+		return nil
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	body, err := p.skipBlock("{", "}")
+	function.body = body
+	return err
+}
+
+func (p *parser) parseParam() (Param, error) {
+	param := Param{}
+	for qualifiers[p.peek().text] {
+		switch p.next().text {
+		case "out":
+			param.Qualifier = QualifierOut
+		case "inout":
+			param.Qualifier = QualifierInOut
+		}
+	}
+	typ := p.next()
+	if typ.kind != tokenIdent || !isType(typ.text, p.file) {
+		return param, p.errorf(typ, "expected parameter type but got '%v'", typ.text)
+	}
+	param.Type = typ.text
+	if err := p.skipArray(); err != nil {
+		return param, err
+	}
+	if p.peek().kind == tokenIdent {
+		param.Name = p.next().text
+	}
+	return param, p.skipArray()
+}
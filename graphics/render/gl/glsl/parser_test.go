@@ -0,0 +1,129 @@
+package glsl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	src := "#version 300 es\n#define SCALE(x) (x * 2.0)\nfloat a = 1.5e-3; // comment\n/* multi\nline */ a <<= 2;"
+	tokens, err := tokenize(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []token{
+		{tokenDefine, "SCALE", 2},
+		{tokenIdent, "float", 3}, {tokenIdent, "a", 3}, {tokenOperator, "=", 3}, {tokenNumber, "1.5e-3", 3}, {tokenOperator, ";", 3},
+		{tokenIdent, "a", 5}, {tokenOperator, "<<=", 5}, {tokenNumber, "2", 5}, {tokenOperator, ";", 5},
+	}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Fatalf("Got %v, expected %v", tokens, expected)
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{"unclosed comment", "float a;\n/* never closed", "line 2: comment is never closed"},
+		{"unexpected character", "float a = 1.0 @ 2.0;", "unexpected character '@'"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := tokenize(test.src)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	src := `
+precision highp float;
+#define PI 3.14159
+struct Light { vec2 pos; float strength; } sun;
+uniform float time, speed;
+layout(location = 0) out vec4 color;
+const float values[2] = float[2](1.0, 2.0);
+float declared(float a);
+void move(inout vec2 pos, in float[2] offsets, out float length, Light) {
+	pos += vec2(offsets[0], offsets[1]);
+	length = 0.0;
+}
+float declared(void) { return PI; }
+`
+	file, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"PI", "sun", "time", "speed", "values"} {
+		if !file.Globals[name] {
+			t.Errorf("%v is not a global", name)
+		}
+	}
+	if !file.Types["Light"] {
+		t.Error("Light is not a type")
+	}
+	move := file.Function("move", 4)
+	if move == nil || !move.HasBody() || move.ReturnType != "void" || move.Line != 9 {
+		t.Fatalf("move is %+v", move)
+	}
+	params := []Param{
+		{QualifierInOut, "vec2", "pos"},
+		{QualifierIn, "float", "offsets"},
+		{QualifierOut, "float", "length"},
+		{QualifierIn, "Light", ""},
+	}
+	if !reflect.DeepEqual(move.Params, params) {
+		t.Fatalf("Parameters of move are %+v", move.Params)
+	}
+	if prototype := file.Function("declared", 1); prototype == nil || prototype.HasBody() {
+		t.Fatalf("Prototype is %+v", prototype)
+	}
+	if declared := file.Function("declared", 0); declared == nil || !declared.HasBody() {
+		t.Fatalf("declared(void) is %+v", declared)
+	}
+	if !file.HasFunction("declared") || file.HasFunction("missing") {
+		t.Fatal("HasFunction is wrong")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{"unknown top level", "float a;\nfoo bar;", "line 2: unexpected 'foo' outside of function"},
+		{"unclosed body", "float f() {\nreturn 1.0;", "'{' is never closed"},
+		{"missing semicolon", "float a", "expected ';' but the source ended"},
+		{"bad parameter", "float f(foo a) { return 1.0; }", "expected parameter type but got 'foo'"},
+		{"bad separator", "float f(float a float b) { return a; }", "expected ',' or ')' in parameters of f"},
+		{"struct without name", "struct { float a; };", "expected struct name"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.src)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	file, err := ParseTemplate("<attributes>\nuniform float time;\n<functions>\nvoid main() { <calls> }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !file.Globals["time"] || file.Function("main", 0) == nil {
+		t.Fatalf("Template parsed as %+v", file)
+	}
+	if _, err := Parse("<attributes>\nvoid main() {}"); err == nil {
+		t.Fatal("Parse should not skip unknown code")
+	}
+}
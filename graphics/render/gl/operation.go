@@ -1,6 +1,9 @@
 package gl
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/eliiasg/deltawing/graphics/render"
 	"github.com/eliiasg/deltawing/graphics/render/gl/shader"
 	"github.com/eliiasg/deltawing/graphics/render/gl/util"
@@ -9,6 +12,8 @@ import (
 
 type Operation struct {
 	cxt Context
+	// returns the time in seconds
+	time func() float64
 	// Vao Object
 	Vao any
 	// Amount of instances to draw
@@ -21,6 +26,28 @@ type Operation struct {
 	SpriteIdxStart int32
 	// Amount of indices in sprite
 	SpriteIdxAmt int32
+	// Buffer and id of the sprite, used to update the indices if the buffer is reallocated
+	Sprite   *SpriteBuffer
+	SpriteID uint32
+	// Attributes set with SetInstanceAttribute, by their location
+	Attributes map[uint32]AttributeBinding
+	// Textures set with SetTexture, by the name of the uniform
+	Textures map[string]*Texture
+	// SpriteBuffer.generation when the VAO was set up, the uvs and data might change when the buffer is reallocated
+	spriteGeneration uint64
+	// shared with the renderer
+	debug *debugState
+	// amount of renames of Proc already applied to UniformParams and Textures
+	renamed int
+}
+
+// The buffer used for an attribute, used to check the amount of instances when drawing
+type AttributeBinding struct {
+	Buffer *DataBuffer
+	// offset in instances
+	Offset uint32
+	// index in the layout of Buffer
+	Index uint16
 }
 
 func GLOperation(o render.Operation) (*Operation, bool) {
@@ -28,19 +55,36 @@ func GLOperation(o render.Operation) (*Operation, bool) {
 	return res, ok
 }
 
-func (r *Renderer) MakeOperation(proc render.Procedure) render.Operation {
-	return &Operation{r.cxt, r.cxt.CreateVertexArray(), 0, proc.(*Procedure), make(map[string]any), 0, 0}
+func (r *Renderer) MakeOperation(proc render.Procedure) (render.Operation, error) {
+	p, ok := GLProcedure(proc)
+	if !ok {
+		return nil, errors.New("Cannot make operation with a procedure that is not from the gl renderer")
+	}
+	return &Operation{r.cxt, r.time, r.cxt.CreateVertexArray(), 0, p, make(map[string]any), 0, 0, nil, 0, make(map[uint32]AttributeBinding), make(map[string]*Texture), 0, r.debug, len(p.renames)}, nil
 }
 
 func (o *Operation) Free() {
 	o.cxt.DeleteVertexArray(o.Vao)
 }
 
-func (o *Operation) SetInstanceAttribute(channel render.Channel, buffer render.DataBuffer, offset uint32, index uint16) {
-	channelInfo := o.Proc.AttribChannels[channel]
-	buf := buffer.(*DataBuffer)
+func (o *Operation) SetInstanceAttribute(channel render.Channel, buffer render.DataBuffer, offset uint32, index uint16) error {
+	channelInfo, ok := o.Proc.AttribChannels[render.UnwrapChannel(channel)]
+	if !ok {
+		return errors.New("Channel is not an attribute channel of the procedure")
+	}
+	buf, ok := GLDataBuffer(buffer)
+	if !ok {
+		return errors.New("Cannot use a data buffer that is not from the gl renderer for an attribute")
+	}
 	if len(buf.Layout) == 0 {
-		panic("missing buffer layout")
+		return errors.New("Buffer layout must be set before using the buffer for an attribute")
+	}
+	if int(index) >= len(buf.Layout) {
+		return fmt.Errorf("Buffer index %v is out of range for layout with %v elements", index, len(buf.Layout))
+	}
+	// ints can be read as floats, but not the other way around
+	if inputType := buf.Layout[index].Type; render.IsInt(channelInfo.Type.Type) && (inputType == render.InputFloat || inputType == render.InputDouble) {
+		return fmt.Errorf("Cannot use float input for %v channel", channelInfo.Type)
 	}
 	// calculate offset
 	off := uintptr(offset) * uintptr(buf.LayoutSize)
@@ -63,7 +107,8 @@ func (o *Operation) SetInstanceAttribute(channel render.Channel, buffer render.D
 		o.cxt.VertexAttribPointer(channelInfo.Index, int32(typ.Amount), glType(typ.Type), false, int32(buf.LayoutSize), off)
 	}
 	o.cxt.VertexAttribDivisor(channelInfo.Index, 1)
-
+	o.Attributes[channelInfo.Index] = AttributeBinding{buf, offset, index}
+	return nil
 }
 
 // very exiting function
@@ -91,38 +136,132 @@ func glType(typ render.ChannelInputType) uint32 {
 	}
 }
 
-func (o *Operation) SetChannelValue(channel render.Channel, data any) {
+func (o *Operation) SetChannelValue(channel render.Channel, data any) error {
 	glChan := shader.GLChannel(channel)
-	//uniform := o.cxt.GetUniformLocation(o.Proc.Prog, glChan.Name())
+	if glChan == nil {
+		return errors.New("Unable to set channel value: Channel is not from the gl renderer")
+	}
+	desc, ok := o.operationChannel(channel)
+	if !ok || desc.Type.Type == render.ShaderSampler {
+		return errors.New("Unable to set channel value: Channel is not an operation channel of the procedure")
+	}
 	if !util.AssertType(glChan.ShaderType(), data) {
-		// Maybe bad?
-		panic("Unable to set channel value: Invalid type")
+		return fmt.Errorf("Unable to set channel value: Expected %v but got %T", glChan.ShaderType(), data)
 	}
+	o.rename()
 	// set param
-	o.UniformParams[glChan.Name()] = data
+	o.UniformParams[desc.Name] = data
+	return nil
 }
 
-func (o *Operation) DrawTo(target render.RenderTarget) {
-	// tell OpenGl how big target is, i don't really understand why this would be required
-	o.cxt.Viewport(0, 0, int32(target.Width()), int32(target.Height()))
-	o.bind(target)
+// compared by the channel, not the name, since channels of other procedures can have the same name
+func (o *Operation) operationChannel(channel render.Channel) (render.ChannelDescription, bool) {
+	desc, ok := o.Proc.Description.Channel(channel)
+	return desc, ok && desc.Kind == render.ChannelOperation
+}
+
+func (o *Operation) DrawTo(target render.RenderTarget) error {
+	tar, ok := GLRenderTarget(target)
+	if !ok {
+		return errors.New("Cannot draw to a RenderTarget that is not from the gl renderer")
+	}
+	if err := o.prepare(target.Width(), target.Height()); err != nil {
+		return err
+	}
+	if mode := o.debug.mode; mode != (DebugMode{}) {
+		data := o.Sprite.debugData()
+		o.bind(tar)
+		o.initShader(target.Width(), target.Height())
+		o.drawDebug(mode, data)
+		return nil
+	}
+	o.bind(tar)
 	o.initShader(target.Width(), target.Height())
+	// programs keep the value from the last debug draw
+	if o.debug.used {
+		o.setDebugUniform(debugUniformOff)
+	}
 	// o.spriteIdxStart is *4, because the argument is in bytes, but type is 32bit
 	o.cxt.DrawElementsInstanced(enum.TRIANGLES, o.SpriteIdxAmt, enum.UNSIGNED_INT, uintptr(o.SpriteIdxStart*4), int32(o.InstanceAmt))
+	return nil
 }
 
-func (o *Operation) bind(target render.RenderTarget) {
+// checks the operation and sets the viewport and sprite indices, used before every draw
+func (o *Operation) prepare(width, height uint16) error {
+	if err := o.check(); err != nil {
+		return err
+	}
+	// tell OpenGl how big target is, i don't really understand why this would be required
+	o.cxt.Viewport(0, 0, int32(width), int32(height))
+	// positions might have changed if the sprite buffer was reallocated
+	o.SpriteIdxStart = int32(o.Sprite.IdxPositions[o.SpriteID])
+	o.SpriteIdxAmt = int32(o.Sprite.IdxPositions[o.SpriteID+1]) - o.SpriteIdxStart
+	if o.spriteGeneration != o.Sprite.generation {
+		o.cxt.BindVertexArray(o.Vao)
+		o.setVertexBuffer(o.Sprite.Verts, o.Sprite)
+	}
+	return nil
+}
+
+// checks that drawing will not read outside of any buffer
+func (o *Operation) check() error {
+	o.rename()
+	if o.Sprite == nil {
+		return errors.New("Sprite must be set before drawing")
+	}
+	// buffer might have been reallocated with fewer sprites
+	if int(o.SpriteID)+1 >= len(o.Sprite.IdxPositions) {
+		return fmt.Errorf("Sprite %v is not in the sprite buffer", o.SpriteID)
+	}
+	for _, name := range o.Proc.TextureNames {
+		if _, ok := o.Textures[name]; !ok {
+			return fmt.Errorf("Texture %v must be set before drawing", name)
+		}
+	}
+	if o.InstanceAmt == 0 {
+		return nil
+	}
+	for channel, info := range o.Proc.AttribChannels {
+		binding, ok := o.Attributes[info.Index]
+		if !ok {
+			return fmt.Errorf("Attribute %v must be set before drawing", shader.GLChannel(channel).Name())
+		}
+		buf := binding.Buffer
+		// end of the last element read
+		end := (uint64(binding.Offset) + uint64(o.InstanceAmt)) * uint64(buf.LayoutSize)
+		if end > uint64(buf.Size) {
+			return fmt.Errorf("Cannot draw %v instances from offset %v, buffer for attribute %v only has %v", o.InstanceAmt, binding.Offset, shader.GLChannel(channel).Name(), buf.Size/uint32(buf.LayoutSize))
+		}
+	}
+	return nil
+}
+
+func (o *Operation) bind(target *RenderTarget) {
 	o.cxt.UseProgram(o.Proc.Prog)
 	o.cxt.BindVertexArray(o.Vao)
-	tar, _ := GLRenderTarget(target)
-	o.cxt.BindFramebuffer(enum.FRAMEBUFFER, tar.Framebuffer)
+	o.cxt.BindFramebuffer(enum.FRAMEBUFFER, target.Framebuffer)
 }
 
 func (o *Operation) initShader(width, height uint16) {
+	o.setUniforms(o.Proc.UniformLocations, o.Proc.ScreenSizeLocation, o.Proc.TimeLocation, width, height)
+}
+
+// locations are given since the picking pass uses another program
+func (o *Operation) setUniforms(locations map[string]any, screenSizeLocation, timeLocation any, width, height uint16) {
 	for name, param := range o.UniformParams {
-		setUniform(o.cxt, o.Proc.UniformLocations[name], param)
+		setUniform(o.cxt, locations[name], param)
+	}
+	if len(o.Proc.TextureNames) > 0 {
+		for unit, name := range o.Proc.TextureNames {
+			o.cxt.ActiveTexture(enum.TEXTURE0 + uint32(unit))
+			o.cxt.BindTexture(enum.TEXTURE_2D, o.Textures[name].Texture)
+			setUniform(o.cxt, locations[name], int32(unit))
+		}
+		// other code binds textures without setting the unit
+		o.cxt.ActiveTexture(enum.TEXTURE0)
 	}
-	setUniform(o.cxt, o.Proc.ScreenSizeLocation, [2]int32{int32(width), int32(height)})
+	setUniform(o.cxt, screenSizeLocation, [2]int32{int32(width), int32(height)})
+	setUniform(o.cxt, timeLocation, float32(o.time()))
 }
 
 // very smart to have a function for every type, i just love OpenGL
@@ -158,22 +297,100 @@ func setUniform(cxt Context, location any, data any) {
 	}
 }
 
-func (o *Operation) SetSprite(buffer render.SpriteBuffer, id uint32) {
-	buf := buffer.(*SpriteBuffer)
-	// tell operation what sprite to draw
-	o.SpriteIdxStart = int32(buf.IdxPositions[id])
-	o.SpriteIdxAmt = int32(buf.IdxPositions[id+1]) - o.SpriteIdxStart
+func (o *Operation) SetSprite(buffer render.SpriteBuffer, id uint32) error {
+	buf, ok := GLSpriteBuffer(buffer)
+	if !ok {
+		return errors.New("Cannot use a sprite buffer that is not from the gl renderer")
+	}
+	if int(id)+1 >= len(buf.IdxPositions) {
+		return fmt.Errorf("Sprite %v is not in the sprite buffer", id)
+	}
+	// tell operation what sprite to draw, indices are found when drawing
+	o.Sprite = buf
+	o.SpriteID = id
 	// setup vao
 	o.cxt.BindVertexArray(o.Vao)
-	o.cxt.BindBuffer(enum.ARRAY_BUFFER, buf.Verts)
 	// to store on VAO
 	o.cxt.BindBuffer(enum.ELEMENT_ARRAY_BUFFER, buf.Inds)
+	o.setVertexBuffer(buf.Verts, buf)
+	return nil
+}
+
+// the VAO must be bound, also used for the bounding boxes of the debug mode
+// the uvs and data are read from sprite, if it is nil the shader gets zeros for them
+func (o *Operation) setVertexBuffer(verts any, sprite *SpriteBuffer) {
+	o.cxt.BindBuffer(enum.ARRAY_BUFFER, verts)
 	// position
 	o.cxt.EnableVertexAttribArray(0)
 	o.cxt.VertexAttribPointer(0, 2, enum.FLOAT, false, 12, 0)
 	// color / layer
 	o.cxt.EnableVertexAttribArray(1)
 	o.cxt.VertexAttribIPointer(1, 4, enum.UNSIGNED_BYTE, 12, 8)
+	// uv
+	if sprite != nil && sprite.Textured {
+		o.cxt.BindBuffer(enum.ARRAY_BUFFER, sprite.UVs)
+		o.cxt.EnableVertexAttribArray(2)
+		o.cxt.VertexAttribPointer(2, 2, enum.FLOAT, false, 8, 0)
+	} else {
+		o.cxt.DisableVertexAttribArray(2)
+	}
+	// data, layouts might be missing if no sprite in the buffer has the name
+	if len(o.Proc.DataChannels) > 0 && sprite != nil {
+		o.cxt.BindBuffer(enum.ARRAY_BUFFER, sprite.Data)
+	}
+	for _, info := range o.Proc.DataChannels {
+		var layout util.DataLayout
+		ok := false
+		if sprite != nil {
+			layout, ok = sprite.DataLayout[info.Name]
+		}
+		if !ok {
+			o.cxt.DisableVertexAttribArray(info.Index)
+			continue
+		}
+		o.cxt.EnableVertexAttribArray(info.Index)
+		// the shader gets zeros if the channel has more floats than the layout
+		o.cxt.VertexAttribPointer(info.Index, int32(min(layout.Amount, info.Amount)), enum.FLOAT, false, int32(sprite.DataStride), uintptr(layout.Offset))
+		o.cxt.VertexAttribDivisor(info.Index, 0)
+	}
+	if sprite != nil {
+		o.spriteGeneration = sprite.generation
+	}
+}
+
+func (o *Operation) SetTexture(channel render.Channel, texture render.Texture) error {
+	glChan := shader.GLChannel(channel)
+	if glChan == nil {
+		return errors.New("Unable to set texture: Channel is not from the gl renderer")
+	}
+	desc, ok := o.operationChannel(channel)
+	if !ok || desc.Type.Type != render.ShaderSampler {
+		return errors.New("Unable to set texture: Channel is not a texture channel of the procedure")
+	}
+	tex, ok := GLTexture(texture)
+	if !ok {
+		return errors.New("Unable to set texture: Texture is not from the gl renderer")
+	}
+	o.rename()
+	o.Textures[desc.Name] = tex
+	return nil
+}
+
+// the uniform names can change when the procedure is replaced, so the values set before have to be moved to the new names
+func (o *Operation) rename() {
+	for ; o.renamed < len(o.Proc.renames); o.renamed++ {
+		names := o.Proc.renames[o.renamed]
+		params := make(map[string]any, len(o.UniformParams))
+		for name, param := range o.UniformParams {
+			params[names[name]] = param
+		}
+		o.UniformParams = params
+		textures := make(map[string]*Texture, len(o.Textures))
+		for name, tex := range o.Textures {
+			textures[names[name]] = tex
+		}
+		o.Textures = textures
+	}
 }
 
 func (o *Operation) SetAmount(amount uint32) {
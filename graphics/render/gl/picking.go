@@ -0,0 +1,168 @@
+package gl
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/render/gl/shader"
+	"github.com/eliiasg/deltawing/internal/rendering/shader_sources"
+	"github.com/eliiasg/glow/enum"
+)
+
+// Finds what instance of what operation is at a pixel, by drawing operations again with ids instead of colors
+// Usage: Begin every frame, Draw the operations that can be picked, then Pick with the mouse position
+type PickingPass struct {
+	cxt      Context
+	version  string
+	programs *programCache
+	// Framebuffer object
+	Framebuffer any
+	// RGBA32UI texture, red is the id of the operation and green is the instance
+	IDBuffer any
+	// Depth texture, so layers work like when drawing normally
+	DepthBuffer   any
+	width, height uint16
+	// by procedure, remade if the source of the procedure changed since it was made
+	pickingPrograms map[*Procedure]*pickingProgram
+}
+
+// Result of PickingPass.Pick
+type Pick struct {
+	// Id given to PickingPass.Draw
+	ID uint32
+	// gl_InstanceID of the instance
+	Instance uint32
+}
+
+// the vertex shader of a procedure with the picking fragment shader
+type pickingProgram struct {
+	// source of the procedure when the program was made
+	procSource *shader.ProgramSource
+	source     *shader.ProgramSource
+	prog       *program
+	idLocation any
+}
+
+func (r *Renderer) MakePickingPass() *PickingPass {
+	return &PickingPass{
+		cxt:             r.cxt,
+		version:         r.version,
+		programs:        r.programs,
+		Framebuffer:     r.cxt.CreateFramebuffer(),
+		IDBuffer:        r.cxt.CreateTexture(),
+		DepthBuffer:     r.cxt.CreateTexture(),
+		pickingPrograms: make(map[*Procedure]*pickingProgram),
+	}
+}
+
+func (p *PickingPass) Free() {
+	p.cxt.DeleteFramebuffer(p.Framebuffer)
+	p.cxt.DeleteTexture(p.IDBuffer)
+	p.cxt.DeleteTexture(p.DepthBuffer)
+	for _, prog := range p.pickingPrograms {
+		p.programs.release(prog.source)
+	}
+	p.pickingPrograms = make(map[*Procedure]*pickingProgram)
+}
+
+// Estimated amount of GPU memory used in bytes, 16 bytes for ids and 4 for depth per pixel
+func (p *PickingPass) MemorySize() uint64 {
+	return uint64(p.width) * uint64(p.height) * 20
+}
+
+// Clears the ids, and resizes the pass if needed, should be called before Draw with the size of the RenderTarget the operations are drawn to
+func (p *PickingPass) Begin(width, height uint16) {
+	p.cxt.BindFramebuffer(enum.FRAMEBUFFER, p.Framebuffer)
+	if width != p.width || height != p.height {
+		p.resize(width, height)
+	}
+	// ClearColor does not work for integer buffers
+	p.cxt.ClearBufferuiv(enum.COLOR, 0, []uint32{0, 0, 0, 0})
+	p.cxt.Clear(enum.DEPTH_BUFFER_BIT)
+}
+
+// framebuffer must be bound
+func (p *PickingPass) resize(width, height uint16) {
+	p.width = width
+	p.height = height
+	p.cxt.BindTexture(enum.TEXTURE_2D, p.IDBuffer)
+	p.cxt.TexImage2D(enum.TEXTURE_2D, 0, enum.RGBA32UI, int32(width), int32(height), 0, enum.RGBA_INTEGER, enum.UNSIGNED_INT, nil)
+	p.cxt.BindTexture(enum.TEXTURE_2D, p.DepthBuffer)
+	p.cxt.TexImage2D(enum.TEXTURE_2D, 0, enum.DEPTH_COMPONENT32F, int32(width), int32(height), 0, enum.DEPTH_COMPONENT, enum.FLOAT, nil)
+	p.cxt.FramebufferTexture2D(enum.FRAMEBUFFER, enum.COLOR_ATTACHMENT0, enum.TEXTURE_2D, p.IDBuffer, 0)
+	p.cxt.FramebufferTexture2D(enum.FRAMEBUFFER, enum.DEPTH_ATTACHMENT, enum.TEXTURE_2D, p.DepthBuffer, 0)
+}
+
+// Draws the operation like DrawTo, but with id instead of colors, id cannot be 0 since that means nothing was drawn
+// Positions and layers are the same as when drawing normally, but fragment functions of the procedure are not used
+func (p *PickingPass) Draw(operation render.Operation, id uint32) error {
+	if id == 0 {
+		return errors.New("Picking id cannot be 0, it is used for pixels without operations")
+	}
+	if p.width == 0 || p.height == 0 {
+		return errors.New("Begin must be called before drawing to a PickingPass")
+	}
+	o, ok := GLOperation(operation)
+	if !ok {
+		return errors.New("Operation is not from the gl renderer")
+	}
+	prog, err := p.program(o.Proc)
+	if err != nil {
+		return err
+	}
+	if err := o.prepare(p.width, p.height); err != nil {
+		return err
+	}
+	p.cxt.UseProgram(prog.prog.prog)
+	p.cxt.BindVertexArray(o.Vao)
+	p.cxt.BindFramebuffer(enum.FRAMEBUFFER, p.Framebuffer)
+	o.setUniforms(prog.prog.uniformLocations, prog.prog.screenSizeLocation, prog.prog.timeLocation, p.width, p.height)
+	p.cxt.Uniform1ui(prog.idLocation, id)
+	p.cxt.DrawElementsInstanced(enum.TRIANGLES, o.SpriteIdxAmt, enum.UNSIGNED_INT, uintptr(o.SpriteIdxStart*4), int32(o.InstanceAmt))
+	return nil
+}
+
+// returns the picking program for the procedure, and makes it if needed
+func (p *PickingPass) program(proc *Procedure) (*pickingProgram, error) {
+	prog, ok := p.pickingPrograms[proc]
+	if ok && prog.procSource == proc.source {
+		return prog, nil
+	}
+	// the procedure was replaced or freed
+	if ok {
+		p.programs.release(prog.source)
+		delete(p.pickingPrograms, proc)
+	}
+	if proc.source == nil {
+		return nil, errors.New("Cannot draw operation with freed procedure")
+	}
+	// copied so the cache sees it as a different program
+	source := *proc.source
+	source.FragmentSource = strings.Replace(shader_sources.PickingSource, "<version>", p.version, 1)
+	source.FragmentLocations = nil
+	compiled, err := p.programs.acquire(&source)
+	if err != nil {
+		return nil, err
+	}
+	res := &pickingProgram{proc.source, &source, compiled, p.cxt.GetUniformLocation(compiled.prog, "pickID")}
+	p.pickingPrograms[proc] = res
+	return res, nil
+}
+
+// Returns what was drawn at x, y with (0, 0) in the top left, like the coordinates from input.Mouse
+// ok is false if nothing was drawn there, or if the position is outside the pass
+// Only the single pixel is read, but reading waits for the GPU to finish drawing the pass
+func (p *PickingPass) Pick(x, y float64) (pick Pick, ok bool) {
+	if x < 0 || y < 0 || x >= float64(p.width) || y >= float64(p.height) {
+		return Pick{}, false
+	}
+	data := make([]uint32, 4)
+	p.cxt.BindFramebuffer(enum.READ_FRAMEBUFFER, p.Framebuffer)
+	// y is flipped, since OpenGL has (0, 0) in the bottom left
+	p.cxt.ReadPixels(int32(x), int32(p.height)-1-int32(y), 1, 1, enum.RGBA_INTEGER, enum.UNSIGNED_INT, data)
+	if data[0] == 0 {
+		return Pick{}, false
+	}
+	return Pick{data[0], data[1]}, true
+}
@@ -10,10 +10,26 @@ type Renderer struct {
 	// retured by PrimaryRendertarget, used by webgl since cannot blit multisampled to real primary
 	primaryOverride render.RenderTarget
 	version         string
+	// returns seconds since start, used for the time channel
+	time func() float64
+	// programs shared by procedures
+	programs *programCache
+	// same as cxt, used for stats
+	counter *countingContext
+	// shared with every operation
+	debug *debugState
 }
 
 // doing it like this since some types might be extended (like primaryRenderTarget)
+// Renderers wrapping another one, like resources.Scope, are unwrapped first
 func GLRenderer(r render.Renderer) (*Renderer, bool) {
+	for {
+		wrapper, ok := r.(interface{ Unwrap() render.Renderer })
+		if !ok {
+			break
+		}
+		r = wrapper.Unwrap()
+	}
 	res, ok := r.(*Renderer)
 	return res, ok
 }
@@ -27,6 +43,22 @@ func (r *Renderer) GLSLVersion() string {
 	return r.version
 }
 
+// Saves compiled programs in dir, so they can be loaded instead of compiled next time the program starts
+// Returns an error if the context does not support program binaries, like WebGL
+func (r *Renderer) SetProgramCacheDir(dir string) error {
+	return r.programs.setDir(dir)
+}
+
+// Should be called once every frame, after everything is drawn, this is done by the program or app
+func (r *Renderer) EndFrame() {
+	r.counter.endFrame()
+}
+
+// Counters of draw calls, uploads and state changes for the latest frames
+func (r *Renderer) Stats() Stats {
+	return r.counter.stats()
+}
+
 func (r *Renderer) PrimaryRenderTarget() render.RenderTarget {
 	return r.primaryOverride
 }
@@ -37,11 +69,18 @@ func (r *Renderer) RealPrimaryRenderTarget() render.RenderTarget {
 
 // should be called after gl and GLFW is initialized
 // assumes primary rendertarget is set up properly
-func NewRenderer(winWdith, winHeight func() uint16, cxt Context, version string, overrideTarget bool) *Renderer {
+// time should return the time in seconds since the program started
+func NewRenderer(winWdith, winHeight func() uint16, time func() float64, glCxt Context, version string, overrideTarget bool) *Renderer {
+	// everything uses the counting context, so all calls are counted
+	counter := &countingContext{Context: glCxt}
 	rend := &Renderer{
-		primary: &primaryRenderTarget{&RenderTarget{cxt, nil, nil, nil, false, 0, 0}, winWdith, winHeight},
-		cxt:     cxt,
-		version: version,
+		time:     time,
+		primary:  &primaryRenderTarget{&RenderTarget{counter, nil, nil, nil, false, 0, 0}, winWdith, winHeight},
+		cxt:      counter,
+		version:  version,
+		programs: newProgramCache(counter, glCxt),
+		counter:  counter,
+		debug:    &debugState{},
 	}
 	if overrideTarget {
 		rend.primaryOverride = rend.MakeRenderTarget(1, 1, false)
@@ -1,6 +1,8 @@
 package gl
 
 import (
+	"errors"
+
 	"github.com/eliiasg/deltawing/graphics/render"
 	"github.com/eliiasg/glow/enum"
 )
@@ -47,7 +49,7 @@ func (r *Renderer) MakeRenderTarget(width, height uint16, multisample bool) rend
 		DepthBuffer: depth,
 		Multisample: multisample,
 	}
-	// to init texture and depthbuffer
+	// to init texture and depthbuffer, cannot fail since the target is not primary
 	t.Resize(width, height)
 	return t
 }
@@ -76,6 +78,19 @@ func (t *RenderTarget) Free() {
 
 }
 
+// Estimated amount of GPU memory used in bytes, 3 bytes for color and 4 for depth per sample
+func (t *RenderTarget) MemorySize() uint64 {
+	// the primary target is owned by the window
+	if t.Framebuffer == nil {
+		return 0
+	}
+	samples := uint64(1)
+	if t.Multisample {
+		samples = 4
+	}
+	return uint64(t.width) * uint64(t.height) * 7 * samples
+}
+
 func (t *RenderTarget) Width() uint16 {
 	return t.width
 }
@@ -98,9 +113,9 @@ func (t *RenderTarget) Clear(r uint8, g uint8, b uint8) {
 	t.cxt.Clear(enum.COLOR_BUFFER_BIT | enum.DEPTH_BUFFER_BIT)
 }
 
-func (t *RenderTarget) Resize(width, height uint16) {
-	if t.Framebuffer == 0 {
-		panic("do not resize primary rendertarget")
+func (t *RenderTarget) Resize(width, height uint16) error {
+	if t.Framebuffer == nil {
+		return errors.New("Cannot resize the primary RenderTarget, it always has the size of the window")
 	}
 	t.width = width
 	t.height = height
@@ -110,6 +125,7 @@ func (t *RenderTarget) Resize(width, height uint16) {
 	} else {
 		t.resizeNormal(width, height)
 	}
+	return nil
 }
 
 func (t *RenderTarget) resizeNormal(width, height uint16) {
@@ -140,10 +156,13 @@ func (t *RenderTarget) resizeMultisample(width, height uint16) {
 	t.cxt.FramebufferRenderbuffer(enum.FRAMEBUFFER, enum.DEPTH_ATTACHMENT, enum.RENDERBUFFER, t.DepthBuffer)
 }
 
-func (t *RenderTarget) BlitTo(target render.RenderTarget, x, y int32) {
-	tar, _ := GLRenderTarget(target)
+func (t *RenderTarget) BlitTo(target render.RenderTarget, x, y int32) error {
+	tar, ok := GLRenderTarget(target)
+	if !ok {
+		return errors.New("Cannot blit to a RenderTarget that is not from the gl renderer")
+	}
 	if tar.Multisample {
-		panic("Do not blit to multisampled target!")
+		return errors.New("Cannot blit to a multisampled RenderTarget")
 	}
 	t.cxt.BindFramebuffer(enum.FRAMEBUFFER, nil)
 	t.cxt.BindFramebuffer(enum.READ_FRAMEBUFFER, t.Framebuffer)
@@ -151,4 +170,5 @@ func (t *RenderTarget) BlitTo(target render.RenderTarget, x, y int32) {
 	// using target, because it might be a primarytarget
 	y = int32(target.Height()) - int32(t.Height()) - y
 	t.cxt.BlitFramebuffer(0, 0, int32(t.Width()), int32(t.Height()), int32(x), int32(y), x+int32(t.Width()), y+int32(t.Height()), enum.COLOR_BUFFER_BIT, enum.LINEAR)
+	return nil
 }
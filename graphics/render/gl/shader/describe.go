@@ -0,0 +1,84 @@
+package shader
+
+import (
+	"sort"
+	"strings"
+
+	r "github.com/eliiasg/deltawing/graphics/render"
+)
+
+func describeInter(channels []*interChannel, kind r.ChannelKind) []r.ChannelDescription {
+	res := make([]r.ChannelDescription, 0, len(channels))
+	for _, channel := range channels {
+		res = append(res, r.ChannelDescription{Channel: channel.Channel, Kind: kind, Type: channel.varType, Name: channel.Name(), Expression: channel.expr})
+	}
+	return res
+}
+
+func (s *ShaderBuilder) describeChannels() []r.ChannelDescription {
+	res := make([]r.ChannelDescription, 0)
+	res = append(res, describeInter(s.vertex.interChans, r.ChannelIntermediate)...)
+	res = append(res, describeInter(s.fragment.interChans, r.ChannelFragmentIntermediate)...)
+	res = append(res, describeInter(s.varyingChans, r.ChannelVarying)...)
+	for i, channel := range s.attribChans {
+		res = append(res, r.ChannelDescription{Channel: channel, Kind: r.ChannelAttribute, Type: channel.varType, Name: channel.Name(), Location: uint32(i) + uint32(s.startPos)})
+	}
+	for i, channel := range s.dataChans {
+		res = append(res, r.ChannelDescription{Channel: channel.Channel, Kind: r.ChannelVertexData, Type: channel.varType, Name: channel.Name(), Location: s.dataLocation(i), DataName: channel.dataName})
+	}
+	for _, channel := range s.operChans {
+		res = append(res, r.ChannelDescription{Channel: channel, Kind: r.ChannelOperation, Type: channel.varType, Name: channel.Name()})
+	}
+	// channels were made in order of id, not kind
+	sort.SliceStable(res, func(i, j int) bool {
+		return GLChannel(res[i].Channel).id < GLChannel(res[j].Channel).id
+	})
+	// sorted by name, since builtins are stored in a map
+	names := make([]string, 0, len(s.builtins))
+	for name := range s.builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		channel := s.builtins[name]
+		res = append(res, r.ChannelDescription{Channel: channel, Kind: r.ChannelBuiltin, Type: channel.varType, Name: name})
+	}
+	return res
+}
+
+func (st *stage) describeCalls() []r.CallDescription {
+	res := make([]r.CallDescription, 0, len(st.calls))
+	for _, call := range st.calls {
+		channels := make([]r.Channel, 0, len(call.params))
+		for _, param := range call.params {
+			channels = append(channels, param)
+		}
+		res = append(res, r.CallDescription{Function: call.fun, Fragment: st.typ == FragmentStage, Channels: channels})
+	}
+	return res
+}
+
+func (st *stage) describeOutputs() []r.OutputDescription {
+	res := make([]r.OutputDescription, 0, len(st.varOrder))
+	for _, name := range st.varOrder {
+		variable := st.shaderVars[name]
+		output := r.OutputDescription{Name: name, Type: variable.typ, Value: variable.val}
+		// avoiding a nil *Channel in a non nil interface
+		if variable.channel != nil {
+			output.Channel = variable.channel
+		}
+		res = append(res, output)
+	}
+	return res
+}
+
+// sources are the composed sources of the stages
+func (s *ShaderBuilder) describe(vertex, fragment string) *r.ProcedureDescription {
+	return &r.ProcedureDescription{
+		Channels:       s.describeChannels(),
+		Calls:          append(s.vertex.describeCalls(), s.fragment.describeCalls()...),
+		Outputs:        append(s.vertex.describeOutputs(), s.fragment.describeOutputs()...),
+		VertexSource:   strings.TrimSuffix(vertex, "\x00"),
+		FragmentSource: strings.TrimSuffix(fragment, "\x00"),
+	}
+}
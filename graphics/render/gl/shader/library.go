@@ -0,0 +1,175 @@
+package shader
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	r "github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/render/gl/glsl"
+)
+
+// A Function or Snippet added to the functions section
+type libraryEntry struct {
+	name   string
+	source string
+}
+
+// Orders functions and snippets, so everything is placed after what it uses
+type library struct {
+	order []libraryEntry
+	// true when done, false while dependencies are being added, used to find cycles
+	visited map[any]bool
+	// sources already added, in case different functions has the same source
+	sources map[string]bool
+}
+
+func newLibrary() *library {
+	return &library{
+		order:   make([]libraryEntry, 0),
+		visited: make(map[any]bool),
+		sources: make(map[string]bool),
+	}
+}
+
+// returns true if obj should be added, and marks it as being added
+func (l *library) visit(obj any, name string) (bool, error) {
+	done, ok := l.visited[obj]
+	if !ok {
+		l.visited[obj] = false
+		return true, nil
+	}
+	if !done {
+		return false, errors.New("Dependency cycle including " + name)
+	}
+	return false, nil
+}
+
+func (l *library) add(obj any, name, source string) {
+	l.visited[obj] = true
+	if l.sources[source] {
+		return
+	}
+	l.sources[source] = true
+	l.order = append(l.order, libraryEntry{name, source})
+}
+
+func (l *library) addSnippet(snippet *r.Snippet) error {
+	ok, err := l.visit(snippet, snippet.Name)
+	if !ok {
+		return err
+	}
+	for _, include := range snippet.Includes {
+		if err := l.addSnippet(include); err != nil {
+			return err
+		}
+	}
+	l.add(snippet, snippet.Name, snippet.Source)
+	return nil
+}
+
+func (l *library) addFunction(function *r.Function) error {
+	ok, err := l.visit(function, function.Name)
+	if !ok {
+		return err
+	}
+	for _, include := range function.Includes {
+		if err := l.addSnippet(include); err != nil {
+			return err
+		}
+	}
+	for _, dependency := range function.Dependencies {
+		if err := l.addFunction(dependency); err != nil {
+			return err
+		}
+	}
+	l.add(function, function.Name, function.Source)
+	return nil
+}
+
+// makes the library for all calls in the stage, in the order they are called
+func (st *stage) makeLibrary() (*library, error) {
+	lib := newLibrary()
+	for _, call := range st.calls {
+		if err := lib.addFunction(call.fun); err != nil {
+			return nil, err
+		}
+	}
+	return lib, nil
+}
+
+// parsed sources are saved, since the same function is often called multiple times
+func (s *ShaderBuilder) parse(source string) (*glsl.File, error) {
+	if file, ok := s.parsed[source]; ok {
+		return file, nil
+	}
+	file, err := glsl.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	s.parsed[source] = file
+	return file, nil
+}
+
+// Makes sure no two entries declare the same thing, and that nothing is declared twice in the shader
+// Functions may be overloaded, as long as the parameter types are different
+func (s *ShaderBuilder) checkCollisions(st *stage, lib *library) error {
+	// name or function signature -> index of entry that declares it
+	owners := make(map[string]int)
+	// function name -> index of entry that declares it, since functions may not have the name of a variable
+	funcOwners := make(map[string]int)
+	claim := func(key, display string, owner int, isFunc bool) error {
+		other, ok := owners[key]
+		if !ok && isFunc {
+			other, ok = owners[display]
+		} else if !ok {
+			other, ok = funcOwners[display]
+		}
+		if ok && other != owner {
+			first, second := lib.order[other].name, lib.order[owner].name
+			if first == second {
+				return fmt.Errorf("%v is declared by two different sources named %v", display, first)
+			}
+			return fmt.Errorf("%v is declared by both %v and %v", display, first, second)
+		}
+		if st.declarations.Globals[display] || st.declarations.Types[display] || st.declarations.HasFunction(display) {
+			return fmt.Errorf("%v declared by %v is already declared by the shader", display, lib.order[owner].name)
+		}
+		owners[key] = owner
+		if isFunc {
+			funcOwners[display] = owner
+		}
+		return nil
+	}
+	for i, entry := range lib.order {
+		file, err := s.parse(entry.source)
+		if err != nil {
+			return fmt.Errorf("Invalid source for %v: %w", entry.name, err)
+		}
+		for name := range file.Globals {
+			if err := claim(name, name, i, false); err != nil {
+				return err
+			}
+		}
+		for name := range file.Types {
+			if err := claim(name, name, i, false); err != nil {
+				return err
+			}
+		}
+		for _, function := range file.Functions {
+			// only definitions, since prototypes may be repeated
+			if !function.HasBody() {
+				continue
+			}
+			types := make([]string, 0, len(function.Params))
+			for _, param := range function.Params {
+				types = append(types, param.Type)
+			}
+			key := function.Name + "(" + strings.Join(types, ", ") + ")"
+			if err := claim(key, function.Name, i, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -7,22 +7,65 @@ import (
 	"strings"
 
 	r "github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/render/gl/glsl"
 )
 
+// The shaders of a program, used as a bitmask to say where a channel can be used
+type Stage uint8
+
+const (
+	VertexStage Stage = 1 << iota
+	FragmentStage
+)
+
+func (s Stage) String() string {
+	switch s {
+	case VertexStage:
+		return "vertex"
+	case FragmentStage:
+		return "fragment"
+	}
+	return "vertex and fragment"
+}
+
 type Channel struct {
 	r.ChannelIdentifier
 	id      uint16
 	varType r.ShaderType
+	// only set for builtin channels, since they already exist in the shader
+	name string
+	// stages the channel can be used in
+	stages Stage
+	// stages where functions may write to the channel
+	writable Stage
 }
 
 func (c *Channel) Name() string {
+	if c.name != "" {
+		return c.name
+	}
 	return "c" + strconv.Itoa(int(c.id))
 }
 
+// Returns true if the channel is a builtin channel
+func (c *Channel) Builtin() bool {
+	return c.name != ""
+}
+
 func (c *Channel) ShaderType() r.ShaderType {
 	return c.varType
 }
 
+// Returns the stages the channel can be used in
+func (c *Channel) Stages() Stage {
+	return c.stages
+}
+
+// Returns the stages where functions may write to the channel
+func (c *Channel) Writable() Stage {
+	return c.writable
+}
+
 type funcCall struct {
 	fun    *r.Function
 	params []*Channel
@@ -33,30 +76,54 @@ type interChannel struct {
 	expr string
 }
 
+// channel read from VecSprite.Data
+type dataChannel struct {
+	*Channel
+	dataName string
+}
+
 func GLChannel(channel r.Channel) *Channel {
-	switch c := channel.(type) {
+	switch c := r.UnwrapChannel(channel).(type) {
 	case *Channel:
 		return c
 	case *interChannel:
 		return c.Channel
+	case *dataChannel:
+		return c.Channel
 	}
 	return nil
 }
 
+// the parts of the builder that are different for every shader
+type stage struct {
+	typ        Stage
+	baseSource string
+	version    string
+	interChans []*interChannel
+	calls      []funcCall
+	// declarations in baseSource, used to check functions
+	declarations *glsl.File
+	// storing channel names, since vars can have defaault values
+	// maybe a bit hacky
+	shaderVars map[string]*varValue
+	// names of shaderVars in the order of the ShaderSource
+	varOrder []string
+}
+
 // just collects the data, shader will be composed at end
 type ShaderBuilder struct {
-	baseSource  string
-	version     string
-	chanID      uint16
-	interChans  []*interChannel
-	attribChans []*Channel
-	operChans   []*Channel
-	calls       []funcCall
+	chanID       uint16
+	vertex       *stage
+	fragment     *stage
+	attribChans  []*Channel
+	dataChans    []*dataChannel
+	operChans    []*Channel
+	varyingChans []*interChannel
+	builtins     map[string]*Channel
+	// parsed function and snippet sources
+	parsed map[string]*glsl.File
 	// start position of layout
 	startPos uint8
-	// storing channel names, since vars can have defaault values
-	// maybe a bit hacky
-	shaderVars map[string]*varValue
 }
 
 // A variable existing in a sahder
@@ -69,9 +136,20 @@ type Variable struct {
 	DefaultValue string
 }
 
+// A read-only variable existing in a shader, that can be used as a channel
+// If both shaders has a builtin with the same name, the channel can be used in both
+type Builtin struct {
+	// Variable name in shader, may also be a GLSL built-in like gl_InstanceID
+	Name string
+	// Type of variable
+	Type r.ShaderType
+}
+
 type varValue struct {
 	typ r.ShaderType
 	val string
+	// nil if the default value is used
+	channel *Channel
 }
 
 type ShaderSource struct {
@@ -79,7 +157,8 @@ type ShaderSource struct {
 		The SourceCode is a shader that will be added parts to.
 		The following keywords will be replaced:
 		'<version>' version and possible precision calls, should only be used once
-		'<attributes>' instance data, should only be used once
+		'<attributes>' instance data, should only be used once, only in vertex shaders
+		'<varyings>' data passed from the vertex shader to the fragment shader, should only be used once
 		'<uniforms>' uniforms, should only be used once
 		'<functions>' function declarations, should only be used once
 		'<variables>' variables, should only be used once
@@ -93,10 +172,31 @@ type ShaderSource struct {
 	Version string
 	// Variables used in the shader, should be without <> - keys are varname, values are default values leave value empty to make setting it required
 	Variables []Variable
+	// Variables declared by the shader that can be read by functions, these must be declared before '<variables>'
+	Builtins []Builtin
 }
 
-func NewShaderBuilder(source ShaderSource) *ShaderBuilder {
+// Variable names must be unique across both shaders
+func NewShaderBuilder(vertex, fragment ShaderSource) *ShaderBuilder {
+	s := &ShaderBuilder{
+		// start index of channels, 0 is used unset channels
+		chanID:       1,
+		attribChans:  make([]*Channel, 0),
+		dataChans:    make([]*dataChannel, 0),
+		operChans:    make([]*Channel, 0),
+		varyingChans: make([]*interChannel, 0),
+		builtins:     make(map[string]*Channel),
+		parsed:       make(map[string]*glsl.File),
+		startPos:     vertex.LayoutStartPos,
+	}
+	s.vertex = s.makeStage(VertexStage, vertex)
+	s.fragment = s.makeStage(FragmentStage, fragment)
+	return s
+}
+
+func (s *ShaderBuilder) makeStage(typ Stage, source ShaderSource) *stage {
 	shaderVars := make(map[string]*varValue)
+	varOrder := make([]string, 0, len(source.Variables))
 	for _, variable := range source.Variables {
 		if strings.ContainsAny(variable.Name, "<>") {
 			panic("Error making ShaderBuilder, variable names must be without <> : " + variable.Name)
@@ -104,53 +204,111 @@ func NewShaderBuilder(source ShaderSource) *ShaderBuilder {
 		if variable.Name == "" {
 			panic("Cannot make variable with empty name")
 		}
-		shaderVars[variable.Name] = &varValue{variable.Type, variable.DefaultValue}
+		if s.vertex != nil && s.vertex.shaderVars[variable.Name] != nil {
+			panic("Variable exists in both shaders: " + variable.Name)
+		}
+		shaderVars[variable.Name] = &varValue{variable.Type, variable.DefaultValue, nil}
+		varOrder = append(varOrder, variable.Name)
 	}
-	return &ShaderBuilder{
-		// start index of channels, 0 is used unset channels
-		chanID:      1,
-		interChans:  make([]*interChannel, 0),
-		attribChans: make([]*Channel, 0),
-		operChans:   make([]*Channel, 0),
-		calls:       make([]funcCall, 0),
-		startPos:    source.LayoutStartPos,
-		version:     source.Version,
-		baseSource:  source.SourceCode,
-		shaderVars:  shaderVars,
+	for _, builtin := range source.Builtins {
+		if builtin.Name == "" {
+			panic("Cannot make builtin with empty name")
+		}
+		// builtin shared between shaders
+		if channel, ok := s.builtins[builtin.Name]; ok {
+			if channel.varType != builtin.Type {
+				panic("Builtin has different types in the shaders: " + builtin.Name)
+			}
+			channel.stages |= typ
+			continue
+		}
+		// id 0 since builtins are named
+		s.builtins[builtin.Name] = &Channel{varType: builtin.Type, name: builtin.Name, stages: typ}
+	}
+	declarations, err := glsl.ParseTemplate(source.SourceCode)
+	if err != nil {
+		panic("Error making ShaderBuilder, unable to parse shader: " + err.Error())
+	}
+	return &stage{
+		typ:          typ,
+		baseSource:   source.SourceCode,
+		version:      source.Version,
+		interChans:   make([]*interChannel, 0),
+		calls:        make([]funcCall, 0),
+		declarations: declarations,
+		shaderVars:   shaderVars,
+		varOrder:     varOrder,
+	}
+}
+
+func (s *ShaderBuilder) stage(typ Stage) *stage {
+	if typ == FragmentStage {
+		return s.fragment
 	}
+	return s.vertex
 }
 
-func (s *ShaderBuilder) makeChannel(varType r.ShaderType) *Channel {
+func (s *ShaderBuilder) makeChannel(varType r.ShaderType, stages, writable Stage) *Channel {
 	channel := &Channel{
-		id:      s.chanID,
-		varType: varType,
+		id:       s.chanID,
+		varType:  varType,
+		stages:   stages,
+		writable: writable,
 	}
 	s.chanID++
 	return channel
 }
 
-func (s *ShaderBuilder) AddIntermediateChannel(shaderType r.ShaderType, expression string) r.Channel {
-	channel := s.makeChannel(shaderType)
-	s.interChans = append(s.interChans, &interChannel{
-		channel,
-		expression,
-	})
+// Returns the channel for a builtin given in a ShaderSource, or nil if there is no such builtin
+func (s *ShaderBuilder) BuiltinChannel(name string) r.Channel {
+	channel, ok := s.builtins[name]
+	if !ok {
+		return nil
+	}
 	return channel
 }
 
+// Adds a channel that is local to the given stage
+func (s *ShaderBuilder) AddIntermediateChannel(stage Stage, shaderType r.ShaderType, expression string) r.Channel {
+	channel := &interChannel{
+		s.makeChannel(shaderType, stage, stage),
+		expression,
+	}
+	st := s.stage(stage)
+	st.interChans = append(st.interChans, channel)
+	return channel.Channel
+}
+
 func (s *ShaderBuilder) AddAttributeChannel(shaderType r.ShaderType) r.Channel {
-	channel := s.makeChannel(shaderType)
+	channel := s.makeChannel(shaderType, VertexStage, 0)
 	s.attribChans = append(s.attribChans, channel)
 	return channel
 }
 
+// Adds a channel read per vertex, the layout locations are after the ones from attribute channels
+func (s *ShaderBuilder) AddVertexDataChannel(name string, amount uint8) r.Channel {
+	channel := &dataChannel{s.makeChannel(r.Type(r.ShaderFloat, amount), VertexStage, 0), name}
+	s.dataChans = append(s.dataChans, channel)
+	return channel.Channel
+}
+
 func (s *ShaderBuilder) AddOperationChannel(shaderType r.ShaderType) r.Channel {
-	channel := s.makeChannel(shaderType)
+	channel := s.makeChannel(shaderType, VertexStage|FragmentStage, 0)
 	s.operChans = append(s.operChans, channel)
 	return channel
 }
 
-func (s *ShaderBuilder) CallFunction(function *r.Function, channels ...r.Channel) error {
+// Adds a channel that is written in the vertex shader and read in the fragment shader
+func (s *ShaderBuilder) AddVaryingChannel(shaderType r.ShaderType, expression string) r.Channel {
+	channel := &interChannel{
+		s.makeChannel(shaderType, VertexStage|FragmentStage, VertexStage),
+		expression,
+	}
+	s.varyingChans = append(s.varyingChans, channel)
+	return channel.Channel
+}
+
+func (s *ShaderBuilder) CallFunction(stage Stage, function *r.Function, channels ...r.Channel) error {
 	if len(function.Parameters) != len(channels) {
 		return errors.New("amount of parameters given must match amount of parameters expected")
 	}
@@ -168,20 +326,39 @@ func (s *ShaderBuilder) CallFunction(function *r.Function, channels ...r.Channel
 			typ := glChan.varType
 			return errors.New(fmt.Sprintf("Expected %v, %v, but got %v, %v", param.Type, param.Amount, typ.Type, typ.Amount))
 		}
+		if glChan.stages&stage == 0 {
+			return errors.New(fmt.Sprintf("Channel %v can only be used in %v functions", glChan.Name(), glChan.stages))
+		}
 		call.params = append(call.params, glChan)
 	}
 
-	s.calls = append(s.calls, call)
+	st := s.stage(stage)
+	if err := s.validateCall(st, call); err != nil {
+		return err
+	}
+	st.calls = append(st.calls, call)
 
 	return nil
 }
 
 func (s *ShaderBuilder) SetOutputChannel(varName string, channel r.Channel) error {
 	glChan := GLChannel(channel)
-	if glChan.ShaderType() != s.shaderVars[varName].typ {
+	st := s.vertex
+	if _, ok := st.shaderVars[varName]; !ok {
+		st = s.fragment
+	}
+	variable, ok := st.shaderVars[varName]
+	if !ok {
+		return errors.New("No variable named " + varName)
+	}
+	if glChan.ShaderType() != variable.typ {
 		return errors.New("Invalid type for " + varName)
 	}
-	s.shaderVars[varName].val = glChan.Name()
+	if glChan.stages&st.typ == 0 {
+		return errors.New(fmt.Sprintf("Channel for %v must be usable in the %v shader", varName, st.typ))
+	}
+	variable.val = glChan.Name()
+	variable.channel = glChan
 	return nil
 }
 
@@ -189,6 +366,8 @@ var typeMap = map[r.ChannelShaderType][2]string{
 	r.ShaderFloat:       {"float", "vec"},
 	r.ShaderInt:         {"int", "ivec"},
 	r.ShaderUnsignedInt: {"uint", "uvec"},
+	// there are no vectors of samplers
+	r.ShaderSampler: {"sampler2D", ""},
 }
 
 func getGLSLTypeName(typ r.ShaderType) string {
@@ -203,42 +382,47 @@ func (s *ShaderBuilder) makeAtrribSection() string {
 	for i, channel := range s.attribChans {
 		sb.WriteString(fmt.Sprintf("layout(location=%v) in %v %v;\n", i+int(s.startPos), getGLSLTypeName(channel.varType), channel.Name()))
 	}
+	for i, channel := range s.dataChans {
+		sb.WriteString(fmt.Sprintf("layout(location=%v) in %v %v;\n", s.dataLocation(i), getGLSLTypeName(channel.varType), channel.Name()))
+	}
 	return sb.String()
 }
 
-func (s *ShaderBuilder) makeUniformSection() string {
+func (s *ShaderBuilder) makeVaryingSection(st *stage) string {
+	qualifier := "out"
+	if st.typ == FragmentStage {
+		qualifier = "in"
+	}
 	var sb strings.Builder
-	for _, channel := range s.operChans {
-		sb.WriteString(fmt.Sprintf("uniform %v %v;\n", getGLSLTypeName(channel.varType), channel.Name()))
+	for _, channel := range s.varyingChans {
+		// ints cannot be interpolated
+		if r.IsInt(channel.varType.Type) {
+			sb.WriteString("flat ")
+		}
+		sb.WriteString(fmt.Sprintf("%v %v %v;\n", qualifier, getGLSLTypeName(channel.varType), channel.Name()))
 	}
 	return sb.String()
 }
 
-func (s *ShaderBuilder) getFunctions() []*r.Function {
-	// make set of functions
-	funcSet := make(map[*r.Function]bool, 0)
-	for _, call := range s.calls {
-		funcSet[call.fun] = true
-	}
-	// convert set to list
-	keys := make([]*r.Function, 0, len(funcSet))
-	for f := range funcSet {
-		keys = append(keys, f)
+func (s *ShaderBuilder) makeUniformSection() string {
+	var sb strings.Builder
+	for _, channel := range s.operChans {
+		sb.WriteString(fmt.Sprintf("uniform %v %v;\n", getGLSLTypeName(channel.varType), channel.Name()))
 	}
-	return keys
+	return sb.String()
 }
 
-func (s *ShaderBuilder) makeDeclarationSection() string {
+func (lib *library) makeDeclarationSection() string {
 	var sb strings.Builder
-	for _, function := range s.getFunctions() {
-		sb.WriteString(function.Source + "\n")
+	for _, entry := range lib.order {
+		sb.WriteString(entry.source + "\n")
 	}
 	return sb.String()
 }
 
-func (s *ShaderBuilder) makeVariablesSection() string {
+func (s *ShaderBuilder) makeVariablesSection(st *stage) string {
 	var sb strings.Builder
-	for _, variable := range s.interChans {
+	for _, variable := range st.interChans {
 		// '<type> <name>'
 		sb.WriteString(fmt.Sprintf("%v %v", getGLSLTypeName(variable.varType), variable.Name()))
 		if variable.expr != "" {
@@ -247,13 +431,21 @@ func (s *ShaderBuilder) makeVariablesSection() string {
 		}
 		sb.WriteString(";\n")
 	}
+	// varyings are declared outside main, so only the value is set
+	if st.typ == VertexStage {
+		for _, varying := range s.varyingChans {
+			if varying.expr != "" {
+				sb.WriteString(fmt.Sprintf("%v = %v;\n", varying.Name(), varying.expr))
+			}
+		}
+	}
 	return sb.String()
 }
 
-func (s *ShaderBuilder) makeCallsSection() string {
+func (st *stage) makeCallsSection() string {
 	var sb strings.Builder
 	// calls
-	for _, call := range s.calls {
+	for _, call := range st.calls {
 		sb.WriteString(call.fun.Name + "(")
 		// parameters
 		for i, param := range call.params {
@@ -270,19 +462,22 @@ func (s *ShaderBuilder) makeCallsSection() string {
 
 // takes a shader to then replace section keywords with generated code
 // it expects a shader because other edits might be made to the baseSource before this
-func (s *ShaderBuilder) composeSections(shader string, oldnew *[]string) error {
+func (s *ShaderBuilder) composeSections(st *stage, lib *library, shader string, oldnew *[]string) error {
 	sections := [][2]string{
-		{"<version>", s.version},
-		{"<attributes>", s.makeAtrribSection()},
+		{"<version>", st.version},
+		{"<varyings>", s.makeVaryingSection(st)},
 		{"<uniforms>", s.makeUniformSection()},
-		{"<functions>", s.makeDeclarationSection()},
-		{"<variables>", s.makeVariablesSection()},
-		{"<calls>", s.makeCallsSection()},
+		{"<functions>", lib.makeDeclarationSection()},
+		{"<variables>", s.makeVariablesSection(st)},
+		{"<calls>", st.makeCallsSection()},
+	}
+	if st.typ == VertexStage {
+		sections = append(sections, [2]string{"<attributes>", s.makeAtrribSection()})
 	}
 
 	for _, section := range sections {
 		if count := strings.Count(shader, section[0]); count != 1 {
-			return errors.New(fmt.Sprintf("Expected %v once, found it %v times", section[0], count))
+			return errors.New(fmt.Sprintf("Expected %v once in %v shader, found it %v times", section[0], st.typ, count))
 		}
 		// should only contain section[0] once
 		*oldnew = append(*oldnew, section[0], section[1])
@@ -291,8 +486,8 @@ func (s *ShaderBuilder) composeSections(shader string, oldnew *[]string) error {
 	return nil
 }
 
-func (s *ShaderBuilder) composeVars(oldnew *[]string) error {
-	for name, value := range s.shaderVars {
+func (st *stage) composeVars(oldnew *[]string) error {
+	for name, value := range st.shaderVars {
 		if value.val == "" {
 			return errors.New("Variable '<" + name + ">' must be set")
 		}
@@ -302,25 +497,69 @@ func (s *ShaderBuilder) composeVars(oldnew *[]string) error {
 	return nil
 }
 
-func (s *ShaderBuilder) Finish() (string, map[r.Channel]AttribChannelInfo, []string, error) {
+func (s *ShaderBuilder) composeStage(st *stage) (string, []glsl.Location, error) {
+	lib, err := st.makeLibrary()
+	if err != nil {
+		return "", nil, err
+	}
+	err = s.checkCollisions(st, lib)
+	if err != nil {
+		return "", nil, err
+	}
 	oldnew := make([]string, 0)
 	// sections
-	err := s.composeSections(s.baseSource, &oldnew)
+	err = s.composeSections(st, lib, st.baseSource, &oldnew)
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, err
 	}
 	// vars
-	err = s.composeVars(&oldnew)
+	err = st.composeVars(&oldnew)
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, err
 	}
-	shader := strings.NewReplacer(oldnew...).Replace(s.baseSource)
+	shader := strings.NewReplacer(oldnew...).Replace(st.baseSource)
 	// make sure to properly end shader with escape char
 	if shader[len(shader)-1] != '\x00' {
 		shader += "\x00"
 	}
+	// to find functions in compile errors
+	locations := make([]glsl.Location, 0)
+	for _, entry := range lib.order {
+		if loc, ok := glsl.Locate(shader, entry.source, entry.name); ok {
+			locations = append(locations, loc)
+		}
+	}
+	return shader, locations, nil
+}
 
-	return shader, s.getAttribTypes(), s.getUniformNames(), nil
+// The result of a ShaderBuilder
+type ProgramSource struct {
+	VertexSource   string
+	FragmentSource string
+	// Where functions are in the sources
+	VertexLocations   []glsl.Location
+	FragmentLocations []glsl.Location
+	// Info about every attribute channel
+	AttribChannels map[r.Channel]AttribChannelInfo
+	// Info about every vertex data channel, in the order they were added
+	DataChannels []DataChannelInfo
+	// Names of uniforms from operation channels
+	UniformNames []string
+	// Description of the channels, calls and outputs used to make the sources
+	Description *r.ProcedureDescription
+}
+
+func (s *ShaderBuilder) Finish() (*ProgramSource, error) {
+	vertex, vertLocs, err := s.composeStage(s.vertex)
+	if err != nil {
+		return nil, err
+	}
+	fragment, fragLocs, err := s.composeStage(s.fragment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProgramSource{vertex, fragment, vertLocs, fragLocs, s.getAttribTypes(), s.getDataChannels(), s.getUniformNames(), s.describe(vertex, fragment)}, nil
 }
 
 type AttribChannelInfo struct {
@@ -336,6 +575,25 @@ func (s *ShaderBuilder) getAttribTypes() map[r.Channel]AttribChannelInfo {
 	return res
 }
 
+type DataChannelInfo struct {
+	// Name of the VecSprite.Data entry
+	Name   string
+	Amount uint8
+	Index  uint32
+}
+
+func (s *ShaderBuilder) dataLocation(i int) uint32 {
+	return uint32(i) + uint32(len(s.attribChans)) + uint32(s.startPos)
+}
+
+func (s *ShaderBuilder) getDataChannels() []DataChannelInfo {
+	res := make([]DataChannelInfo, 0, len(s.dataChans))
+	for i, channel := range s.dataChans {
+		res = append(res, DataChannelInfo{channel.dataName, channel.varType.Amount, s.dataLocation(i)})
+	}
+	return res
+}
+
 func (s *ShaderBuilder) getUniformNames() []string {
 	res := make([]string, 0, len(s.operChans))
 	for _, channel := range s.operChans {
@@ -0,0 +1,59 @@
+package shader
+
+import (
+	"fmt"
+
+	"github.com/eliiasg/deltawing/graphics/render/gl/glsl"
+)
+
+// checks the source of the function before the driver does, to give better errors
+func (s *ShaderBuilder) validateCall(st *stage, call funcCall) error {
+	function := call.fun
+	// dependencies are checked too, every entry may use what is before it
+	lib := newLibrary()
+	if err := lib.addFunction(function); err != nil {
+		return err
+	}
+	var file *glsl.File
+	before := make([]*glsl.File, 0, len(lib.order))
+	for _, entry := range lib.order {
+		f, err := s.parse(entry.source)
+		if err != nil {
+			return fmt.Errorf("Invalid source for %v: %w", entry.name, err)
+		}
+		err = f.CheckIdentifiers(func(name string) bool {
+			if st.declarations.Globals[name] {
+				return true
+			}
+			for _, other := range before {
+				if other.Globals[name] || other.Types[name] || other.HasFunction(name) {
+					return true
+				}
+			}
+			return false
+		})
+		if err != nil {
+			return fmt.Errorf("Invalid source for %v: %w", entry.name, err)
+		}
+		before = append(before, f)
+		// function is always last, but its source might also be used by a dependency
+		if entry.source == function.Source {
+			file = f
+		}
+	}
+	decl := file.Function(function.Name, len(function.Parameters))
+	if decl == nil {
+		return fmt.Errorf("No function named %v with %v parameters in source", function.Name, len(function.Parameters))
+	}
+	// signature
+	for i, param := range decl.Params {
+		channel := call.params[i]
+		if typ := getGLSLTypeName(function.Parameters[i]); param.Type != typ {
+			return fmt.Errorf("Parameter %v of %v is declared as %v in source, but %v in Parameters", i, function.Name, param.Type, typ)
+		}
+		if param.Qualifier.Writes() && channel.writable&st.typ == 0 {
+			return fmt.Errorf("Parameter %v of %v is %v, but channel %v is read-only in %v functions", i, function.Name, param.Qualifier, channel.Name(), st.typ)
+		}
+	}
+	return nil
+}
@@ -3,34 +3,59 @@ package gl
 import (
 	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/render/gl/glsl"
 	"github.com/eliiasg/deltawing/graphics/render/gl/shader"
 	"github.com/eliiasg/deltawing/internal/rendering/shader_sources"
 	"github.com/eliiasg/glow/enum"
 )
 
 type procedureBuilder struct {
-	cxt     Context
-	sb      *shader.ShaderBuilder
-	version string
+	programs *programCache
+	sb       *shader.ShaderBuilder
 }
 
 func (r *Renderer) MakeProcedureBuilder() render.ProcedureBuilder {
-	source := shader.ShaderSource{
+	return &procedureBuilder{r.programs, NewShaderBuilder(r.version)}
+}
+
+// Makes the shader builder used by procedure builders, with the base shaders, variables and builtins of the renderer
+// Can be used without a context, like for checking the shaders of functions in tests
+func NewShaderBuilder(version string) *shader.ShaderBuilder {
+	vertex := shader.ShaderSource{
 		SourceCode:     shader_sources.VertexBaseSource,
-		LayoutStartPos: 2,
-		Version:        r.version,
+		LayoutStartPos: shader_sources.VertexBaseInputAmt,
+		Version:        version,
 		Variables: []shader.Variable{
 			{Name: "pos", Type: render.Type(render.ShaderFloat, 2), DefaultValue: ""},
 			{Name: "layer", Type: render.Type(render.ShaderUnsignedInt, 1), DefaultValue: ""},
 			{Name: "color", Type: render.Type(render.ShaderInt, 4), DefaultValue: "vec4(aColor.rgb, 255)"},
 			{Name: "xAxis", Type: render.Type(render.ShaderFloat, 2), DefaultValue: "vec2(1, 0)"},
 			{Name: "yAxis", Type: render.Type(render.ShaderFloat, 2), DefaultValue: "vec2(0, 1)"},
+			{Name: "vertex", Type: render.Type(render.ShaderFloat, 2), DefaultValue: "axisPos"},
+		},
+		Builtins: []shader.Builtin{
+			{Name: "vertexPos", Type: render.Type(render.ShaderFloat, 2)},
+			{Name: "gl_InstanceID", Type: render.Type(render.ShaderInt, 1)},
+			{Name: "gl_VertexID", Type: render.Type(render.ShaderInt, 1)},
+			{Name: "vertexUV", Type: render.Type(render.ShaderFloat, 2)},
+			{Name: "time", Type: render.Type(render.ShaderFloat, 1)},
 		},
 	}
-	return &procedureBuilder{r.cxt, shader.NewShaderBuilder(source), r.version}
+	fragment := shader.ShaderSource{
+		SourceCode: shader_sources.FragmentBaseSource,
+		Version:    version,
+		Variables: []shader.Variable{
+			{Name: "fragColor", Type: render.Type(render.ShaderFloat, 4), DefaultValue: "vertexColor"},
+		},
+		Builtins: []shader.Builtin{
+			{Name: "pixelPos", Type: render.Type(render.ShaderFloat, 2)},
+			{Name: "vertexColor", Type: render.Type(render.ShaderFloat, 4)},
+			{Name: "time", Type: render.Type(render.ShaderFloat, 1)},
+		},
+	}
+	return shader.NewShaderBuilder(vertex, fragment)
 }
 
 func (p *procedureBuilder) AddAttributeChannel(shaderType render.ShaderType) render.Channel {
@@ -38,15 +63,63 @@ func (p *procedureBuilder) AddAttributeChannel(shaderType render.ShaderType) ren
 }
 
 func (p *procedureBuilder) AddIntermediateChannel(shaderType render.ShaderType, expression string) render.Channel {
-	return p.sb.AddIntermediateChannel(shaderType, expression)
+	return p.sb.AddIntermediateChannel(shader.VertexStage, shaderType, expression)
+}
+
+func (p *procedureBuilder) AddFragmentIntermediateChannel(shaderType render.ShaderType, expression string) render.Channel {
+	return p.sb.AddIntermediateChannel(shader.FragmentStage, shaderType, expression)
+}
+
+func (p *procedureBuilder) AddVertexDataChannel(name string, amount uint8) render.Channel {
+	return p.sb.AddVertexDataChannel(name, amount)
+}
+
+func (p *procedureBuilder) AddTextureChannel() render.Channel {
+	return p.sb.AddOperationChannel(render.Type(render.ShaderSampler, 1))
+}
+
+func (p *procedureBuilder) AddVaryingChannel(shaderType render.ShaderType, expression string) render.Channel {
+	return p.sb.AddVaryingChannel(shaderType, expression)
 }
 
 func (p *procedureBuilder) AddOperationChannel(shaderType render.ShaderType) render.Channel {
 	return p.sb.AddOperationChannel(shaderType)
 }
 
+func (p *procedureBuilder) VertexPositionChannel() render.Channel {
+	return p.sb.BuiltinChannel("vertexPos")
+}
+
+func (p *procedureBuilder) InstanceIDChannel() render.Channel {
+	return p.sb.BuiltinChannel("gl_InstanceID")
+}
+
+func (p *procedureBuilder) VertexIDChannel() render.Channel {
+	return p.sb.BuiltinChannel("gl_VertexID")
+}
+
+func (p *procedureBuilder) VertexUVChannel() render.Channel {
+	return p.sb.BuiltinChannel("vertexUV")
+}
+
+func (p *procedureBuilder) TimeChannel() render.Channel {
+	return p.sb.BuiltinChannel("time")
+}
+
+func (p *procedureBuilder) PixelPositionChannel() render.Channel {
+	return p.sb.BuiltinChannel("pixelPos")
+}
+
+func (p *procedureBuilder) PixelColorChannel() render.Channel {
+	return p.sb.BuiltinChannel("vertexColor")
+}
+
 func (p *procedureBuilder) CallFunction(function *render.Function, channels ...render.Channel) error {
-	return p.sb.CallFunction(function, channels...)
+	return p.sb.CallFunction(shader.VertexStage, function, channels...)
+}
+
+func (p *procedureBuilder) CallFragmentFunction(function *render.Function, channels ...render.Channel) error {
+	return p.sb.CallFunction(shader.FragmentStage, function, channels...)
 }
 
 func (p *procedureBuilder) SetColorChannel(channel render.Channel) error {
@@ -69,67 +142,217 @@ func (p *procedureBuilder) SetYAxisChannel(channel render.Channel) error {
 	return p.sb.SetOutputChannel("yAxis", channel)
 }
 
+func (p *procedureBuilder) SetVertexChannel(channel render.Channel) error {
+	return p.sb.SetOutputChannel("vertex", channel)
+}
+
+func (p *procedureBuilder) SetFragmentColorChannel(channel render.Channel) error {
+	return p.sb.SetOutputChannel("fragColor", channel)
+}
+
 func (p *procedureBuilder) Finish() (render.Procedure, error) {
-	vertSource, attribTypes, uniformNames, err := p.sb.Finish()
+	source, err := p.sb.Finish()
 	if err != nil {
 		return nil, err
 	}
-	return compileProgram(p.cxt, p.version, vertSource, attribTypes, uniformNames)
+	prog, err := p.programs.acquire(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Procedure{
+		programs:           p.programs,
+		source:             source,
+		Prog:               prog.prog,
+		ScreenSizeLocation: prog.screenSizeLocation,
+		TimeLocation:       prog.timeLocation,
+		DebugModeLocation:  prog.debugModeLocation,
+		AttribChannels:     source.AttribChannels,
+		DataChannels:       source.DataChannels,
+		UniformLocations:   prog.uniformLocations,
+		TextureNames:       textureNames(source.Description),
+		Description:        source.Description,
+	}, nil
 }
 
+func textureNames(description *render.ProcedureDescription) []string {
+	res := make([]string, 0)
+	for _, channel := range description.ChannelsOf(render.ChannelOperation) {
+		if channel.Type.Type == render.ShaderSampler {
+			res = append(res, channel.Name)
+		}
+	}
+	return res
+}
+
+// Procedures with the same source share the program, so it is only deleted when all of them are freed
 type Procedure struct {
 	render.ProcedureIdentifier
-	cxt Context
+	programs *programCache
+	// nil when freed
+	source *shader.ProgramSource
 	// Shader program object
 	Prog any
 	// Uniform location of screen size
 	ScreenSizeLocation any
+	// Uniform location of time
+	TimeLocation any
+	// Uniform location of the debug mode, see DebugMode
+	DebugModeLocation any
 	// Attribute channels
 	AttribChannels map[render.Channel]shader.AttribChannelInfo
+	// Vertex data channels, read from SpriteBuffer.Data
+	DataChannels []shader.DataChannelInfo
 	// Uniform locations
 	UniformLocations map[string]any
+	// Names of the uniforms from texture channels, the index is the texture unit used
+	TextureNames []string
+	// Channels, calls and sources of the procedure
+	Description *render.ProcedureDescription
+	// for every Replace, the uniform names of the operation channels before it to the names after it
+	// the names come from the order channels were added in, so adding a channel can rename the others
+	renames []map[string]string
 }
 
-func (p *Procedure) Free() {
-	p.cxt.DeleteProgram(p.Prog)
+func GLProcedure(p render.Procedure) (*Procedure, bool) {
+	res, ok := p.(*Procedure)
+	return res, ok
 }
 
-func compileProgram(cxt Context, version string, vertSource string, attribTypes map[render.Channel]shader.AttribChannelInfo, uniformNames []string) (render.Procedure, error) {
-	// vertex shader
-	vert, err := compileShader(cxt, enum.VERTEX_SHADER, vertSource)
-	if err != nil {
-		return nil, err
+func (p *Procedure) Describe() *render.ProcedureDescription {
+	return p.Description
+}
+
+// Uses the program of other, so operations using p draw with it instead
+// Used for hot reloading, the attribute, vertex data and operation channels must have the same types in the same order in both procedures, since operations keep their values
+// Channels of p can still be used with operations and Describe, and other should not be used afterwards
+func (p *Procedure) Replace(other render.Procedure) error {
+	o, ok := GLProcedure(other)
+	if !ok {
+		return errors.New("Cannot replace procedure with one that is not from the gl renderer")
 	}
-	// fragment shader
-	// FIXME fragment shader is not changed, so maybe only compile once
-	fragSource := strings.Replace(shader_sources.FragmentSource, "<version>", version, 1)
-	frag, err := compileShader(cxt, enum.FRAGMENT_SHADER, fragSource)
-	if err != nil {
-		return nil, err
+	if p.source == nil || o.source == nil {
+		return errors.New("Cannot replace freed procedure")
 	}
-	// program
-	prog, err := createProgram(cxt, vert, frag)
-	if err != nil {
-		return nil, err
+	for _, kind := range []render.ChannelKind{render.ChannelAttribute, render.ChannelVertexData, render.ChannelOperation} {
+		before, after := p.Description.ChannelsOf(kind), o.Description.ChannelsOf(kind)
+		if len(before) != len(after) {
+			return fmt.Errorf("Cannot replace procedure with %v %v channels with one with %v", len(before), kind, len(after))
+		}
+		for i := range before {
+			if err := channelChange(before[i], after[i]); err != "" {
+				return fmt.Errorf("Cannot replace procedure, %v channel %v %v", kind, i, err)
+			}
+		}
+	}
+	names := make(map[string]string)
+	after := o.Description.ChannelsOf(render.ChannelOperation)
+	for i, before := range p.Description.ChannelsOf(render.ChannelOperation) {
+		names[before.Name] = after[i].Name
 	}
-	// delete shaders (program is not deleted)
-	cxt.DeleteShader(vert)
-	cxt.DeleteShader(frag)
-	// get size uniform location
-	sizeLoc := cxt.GetUniformLocation(prog, "screenSize")
-	// get uniform locations
-	uniformLocations := make(map[string]any)
-	for _, name := range uniformNames {
-		uniformLocations[name] = cxt.GetUniformLocation(prog, name)
+	p.renames = append(p.renames, names)
+	p.programs.release(p.source)
+	p.source = o.source
+	p.Prog = o.Prog
+	p.ScreenSizeLocation = o.ScreenSizeLocation
+	p.TimeLocation = o.TimeLocation
+	p.DebugModeLocation = o.DebugModeLocation
+	p.UniformLocations = o.UniformLocations
+	p.TextureNames = o.TextureNames
+	p.Description = keepChannels(p.Description, o.Description)
+	// the program now belongs to p
+	o.source = nil
+	return nil
+}
+
+// describes what changed between the channels, empty if nothing did
+// the names are not compared, since they change when channels are added before them
+func channelChange(before, after render.ChannelDescription) string {
+	switch {
+	case before.Type != after.Type:
+		return fmt.Sprintf("changed type from %v to %v", before.Type, after.Type)
+	case before.Location != after.Location:
+		return fmt.Sprintf("changed location from %v to %v", before.Location, after.Location)
+	case before.DataName != after.DataName:
+		return fmt.Sprintf("changed data from %q to %q", before.DataName, after.DataName)
 	}
-	return &Procedure{Prog: prog, ScreenSizeLocation: sizeLoc, AttribChannels: attribTypes, UniformLocations: uniformLocations}, nil
+	return ""
 }
 
-func createProgram(cxt Context, vertShader, fragShader any) (any, error) {
+// copy of the new description using the channels of the old one where the kind and position among the channels of that kind are the same
+// so channels from the builder of the replaced procedure still work with Describe
+// builtins are matched by name instead, since they are only added when used
+func keepChannels(old, new *render.ProcedureDescription) *render.ProcedureDescription {
+	type key struct {
+		kind  render.ChannelKind
+		name  string
+		index int
+	}
+	keyOf := func(desc render.ChannelDescription, counts map[render.ChannelKind]int) key {
+		if desc.Kind == render.ChannelBuiltin {
+			return key{desc.Kind, desc.Name, 0}
+		}
+		counts[desc.Kind]++
+		return key{desc.Kind, "", counts[desc.Kind] - 1}
+	}
+	oldChannels := make(map[key]render.Channel, len(old.Channels))
+	counts := make(map[render.ChannelKind]int)
+	for _, desc := range old.Channels {
+		oldChannels[keyOf(desc, counts)] = desc.Channel
+	}
+	// from channels of the new builder to channels of the old one
+	mapping := make(map[render.Channel]render.Channel)
+	res := *new
+	res.Channels = make([]render.ChannelDescription, 0, len(new.Channels))
+	counts = make(map[render.ChannelKind]int)
+	for _, desc := range new.Channels {
+		if channel, ok := oldChannels[keyOf(desc, counts)]; ok {
+			mapping[desc.Channel] = channel
+			desc.Channel = channel
+		}
+		res.Channels = append(res.Channels, desc)
+	}
+	mapChannel := func(channel render.Channel) render.Channel {
+		if mapped, ok := mapping[channel]; ok {
+			return mapped
+		}
+		return channel
+	}
+	res.Calls = make([]render.CallDescription, 0, len(new.Calls))
+	for _, call := range new.Calls {
+		channels := make([]render.Channel, len(call.Channels))
+		for i, channel := range call.Channels {
+			channels[i] = mapChannel(channel)
+		}
+		call.Channels = channels
+		res.Calls = append(res.Calls, call)
+	}
+	res.Outputs = make([]render.OutputDescription, 0, len(new.Outputs))
+	for _, output := range new.Outputs {
+		if output.Channel != nil {
+			output.Channel = mapChannel(output.Channel)
+		}
+		res.Outputs = append(res.Outputs, output)
+	}
+	return &res
+}
+
+func (p *Procedure) Free() {
+	if p.source == nil {
+		return
+	}
+	p.programs.release(p.source)
+	p.source = nil
+}
+
+// if binaryCxt is not nil, the program is made so it can be saved with it
+func createProgram(cxt Context, vertShader, fragShader any, binaryCxt ProgramBinaryContext) (any, error) {
 	// make and link
 	prog := cxt.CreateProgram()
 	cxt.AttachShader(prog, vertShader)
 	cxt.AttachShader(prog, fragShader)
+	if binaryCxt != nil {
+		binaryCxt.SetProgramBinaryRetrievable(prog)
+	}
 	cxt.LinkProgram(prog)
 
 	// ckeck for error
@@ -137,12 +360,14 @@ func createProgram(cxt Context, vertShader, fragShader any) (any, error) {
 	if status == enum.FALSE {
 		// get error
 		log := cxt.GetProgramInfoLog(prog)
+		cxt.DeleteProgram(prog)
 		return 0, errors.New(fmt.Sprintf("Program linking failed:\n%v", log))
 	}
 	return prog, nil
 }
 
-func compileShader(cxt Context, typ uint32, source string) (any, error) {
+// locations are used to tell what function caused an error
+func compileShader(cxt Context, typ uint32, source string, locations []glsl.Location) (any, error) {
 	// make and compile shader
 	shader := cxt.CreateShader(typ)
 	cxt.ShaderSource(shader, source)
@@ -150,7 +375,8 @@ func compileShader(cxt Context, typ uint32, source string) (any, error) {
 	// check for error
 	err := getShaderError(cxt, shader)
 	if err != "" {
-		return 0, errors.New(fmt.Sprintf("Failed to compile shader:\n%v\nWith error:\n%v", source, err))
+		cxt.DeleteShader(shader)
+		return 0, errors.New(fmt.Sprintf("Failed to compile shader:\n%v\nWith error:\n%v", source, glsl.MapLog(err, locations)))
 	}
 	return shader, nil
 }
@@ -0,0 +1,106 @@
+package gl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+)
+
+type testChannel struct {
+	render.ChannelIdentifier
+	name string
+}
+
+func TestKeepChannels(t *testing.T) {
+	a, b, oldTime := &testChannel{name: "a"}, &testChannel{name: "b"}, &testChannel{name: "time"}
+	x, newA, newB, newTime := &testChannel{name: "x"}, &testChannel{name: "new a"}, &testChannel{name: "new b"}, &testChannel{name: "new time"}
+	old := &render.ProcedureDescription{
+		Channels: []render.ChannelDescription{
+			{Channel: a, Kind: render.ChannelOperation, Name: "c1"},
+			{Channel: oldTime, Kind: render.ChannelBuiltin, Name: "time"},
+			{Channel: b, Kind: render.ChannelOperation, Name: "c2"},
+		},
+	}
+	// an intermediate channel was added first, so the operation channels have new names
+	new := &render.ProcedureDescription{
+		Channels: []render.ChannelDescription{
+			{Channel: x, Kind: render.ChannelIntermediate, Name: "c1"},
+			{Channel: newA, Kind: render.ChannelOperation, Name: "c2"},
+			{Channel: newB, Kind: render.ChannelOperation, Name: "c3"},
+			{Channel: newTime, Kind: render.ChannelBuiltin, Name: "time"},
+		},
+		Calls:   []render.CallDescription{{Channels: []render.Channel{x, newB, newTime}}},
+		Outputs: []render.OutputDescription{{Channel: newA}, {}},
+	}
+	res := keepChannels(old, new)
+	channels := make([]render.Channel, 0)
+	for _, desc := range res.Channels {
+		channels = append(channels, desc.Channel)
+	}
+	if expected := []render.Channel{x, a, b, oldTime}; !reflect.DeepEqual(channels, expected) {
+		t.Fatalf("Channels are %v, expected %v", channels, expected)
+	}
+	if desc, _ := res.Channel(b); desc.Name != "c3" {
+		t.Fatalf("Channel b has name %v, expected the name from the new procedure", desc.Name)
+	}
+	if calls := res.Calls[0].Channels; !reflect.DeepEqual(calls, []render.Channel{x, b, oldTime}) {
+		t.Fatalf("Call channels are %v", calls)
+	}
+	if res.Outputs[0].Channel != render.Channel(a) || res.Outputs[1].Channel != nil {
+		t.Fatalf("Outputs are %v", res.Outputs)
+	}
+	// the description of the new procedure is not changed
+	if new.Channels[1].Channel != render.Channel(newA) || new.Calls[0].Channels[1] != render.Channel(newB) {
+		t.Fatal("New description was changed")
+	}
+}
+
+func TestChannelChange(t *testing.T) {
+	float := render.Type(render.ShaderFloat, 1)
+	before := render.ChannelDescription{Kind: render.ChannelOperation, Type: float, Name: "c1"}
+	tests := []struct {
+		name   string
+		after  render.ChannelDescription
+		change string
+	}{
+		{"renamed", render.ChannelDescription{Kind: render.ChannelOperation, Type: float, Name: "c4"}, ""},
+		{"type", render.ChannelDescription{Kind: render.ChannelOperation, Type: render.Type(render.ShaderFloat, 2), Name: "c1"}, "changed type"},
+		{"location", render.ChannelDescription{Kind: render.ChannelOperation, Type: float, Location: 3}, "changed location"},
+		{"data", render.ChannelDescription{Kind: render.ChannelOperation, Type: float, DataName: "glow"}, "changed data"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			change := channelChange(before, test.after)
+			if (test.change == "") != (change == "") || !strings.HasPrefix(change, test.change) {
+				t.Fatalf("Change is %q, expected %q", change, test.change)
+			}
+		})
+	}
+}
+
+func TestOperationRename(t *testing.T) {
+	tex := &Texture{}
+	proc := &Procedure{}
+	o := &Operation{
+		Proc:          proc,
+		UniformParams: map[string]any{"c1": float32(1), "c2": float32(2)},
+		Textures:      map[string]*Texture{"c3": tex},
+	}
+	// replaced twice before the operation was used again
+	proc.renames = append(proc.renames, map[string]string{"c1": "c2", "c2": "c3", "c3": "c4"})
+	proc.renames = append(proc.renames, map[string]string{"c2": "c2", "c3": "c5", "c4": "c6"})
+	o.rename()
+	if expected := map[string]any{"c2": float32(1), "c5": float32(2)}; !reflect.DeepEqual(o.UniformParams, expected) {
+		t.Fatalf("Params are %v, expected %v", o.UniformParams, expected)
+	}
+	if len(o.Textures) != 1 || o.Textures["c6"] != tex {
+		t.Fatalf("Textures are %v", o.Textures)
+	}
+	// renames are only applied once
+	o.rename()
+	if o.renamed != 2 || o.UniformParams["c2"] != float32(1) {
+		t.Fatalf("Renamed %v times, params are %v", o.renamed, o.UniformParams)
+	}
+}
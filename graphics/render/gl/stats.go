@@ -0,0 +1,262 @@
+package gl
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/eliiasg/glow/enum"
+)
+
+// amount of frames used for Stats.Average
+const statsFrames = 60
+
+// Counters for a single frame
+type FrameStats struct {
+	DrawCalls uint64
+	Instances uint64
+	Triangles uint64
+	// Bytes given to BufferData, including sprite buffers
+	BytesUploaded uint64
+	// Times a different program, vertex array or framebuffer was bound
+	ProgramSwitches     uint64
+	VertexArraySwitches uint64
+	FramebufferSwitches uint64
+	UniformsSet         uint64
+	// Times a RenderTarget was cleared
+	Clears uint64
+}
+
+func (s FrameStats) String() string {
+	return fmt.Sprintf("draws: %v, instances: %v, triangles: %v, uploaded: %v B, program switches: %v, vao switches: %v, framebuffer switches: %v, uniforms: %v, clears: %v",
+		s.DrawCalls, s.Instances, s.Triangles, s.BytesUploaded, s.ProgramSwitches, s.VertexArraySwitches, s.FramebufferSwitches, s.UniformsSet, s.Clears)
+}
+
+func (s *FrameStats) add(other FrameStats) {
+	s.DrawCalls += other.DrawCalls
+	s.Instances += other.Instances
+	s.Triangles += other.Triangles
+	s.BytesUploaded += other.BytesUploaded
+	s.ProgramSwitches += other.ProgramSwitches
+	s.VertexArraySwitches += other.VertexArraySwitches
+	s.FramebufferSwitches += other.FramebufferSwitches
+	s.UniformsSet += other.UniformsSet
+	s.Clears += other.Clears
+}
+
+func (s FrameStats) divide(amount uint64) FrameStats {
+	// rounding instead of flooring
+	div := func(v uint64) uint64 {
+		return (v + amount/2) / amount
+	}
+	return FrameStats{
+		div(s.DrawCalls), div(s.Instances), div(s.Triangles), div(s.BytesUploaded),
+		div(s.ProgramSwitches), div(s.VertexArraySwitches), div(s.FramebufferSwitches), div(s.UniformsSet), div(s.Clears),
+	}
+}
+
+type Stats struct {
+	// Stats of the latest finished frame
+	Last FrameStats
+	// Average of the latest finished frames, up to 60
+	Average FrameStats
+	// Stats of the frame being drawn
+	Current FrameStats
+	// Amount of finished frames
+	Frames uint64
+}
+
+// Context that counts calls for Stats, every object made by the Renderer uses this
+type countingContext struct {
+	Context
+	current FrameStats
+	// ring buffer of finished frames
+	history [statsFrames]FrameStats
+	frames  uint64
+	// currently bound objects, to only count switches
+	program, vertexArray, framebuffer any
+}
+
+// objects from some contexts cannot be compared with ==, like js.Value in WebGL
+func sameObject(a, b any) bool {
+	switch v := a.(type) {
+	case uint32:
+		w, ok := b.(uint32)
+		return ok && v == w
+	case nil:
+		return b == nil
+	}
+	if b == nil || reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+	if reflect.TypeOf(a).Comparable() {
+		return a == b
+	}
+	if equal := reflect.ValueOf(a).MethodByName("Equal"); equal.IsValid() {
+		return equal.Call([]reflect.Value{reflect.ValueOf(b)})[0].Bool()
+	}
+	return false
+}
+
+func (c *countingContext) endFrame() {
+	c.history[c.frames%statsFrames] = c.current
+	c.frames++
+	c.current = FrameStats{}
+}
+
+func (c *countingContext) stats() Stats {
+	res := Stats{Current: c.current, Frames: c.frames}
+	if c.frames == 0 {
+		return res
+	}
+	res.Last = c.history[(c.frames-1)%statsFrames]
+	amount := c.frames
+	if amount > statsFrames {
+		amount = statsFrames
+	}
+	var sum FrameStats
+	for i := uint64(0); i < amount; i++ {
+		sum.add(c.history[i])
+	}
+	res.Average = sum.divide(amount)
+	return res
+}
+
+func dataSize(data any) uint64 {
+	switch d := data.(type) {
+	case []uint8:
+		return uint64(len(d))
+	case []uint16:
+		return uint64(len(d)) * 2
+	case []uint32:
+		return uint64(len(d)) * 4
+	case []uint64:
+		return uint64(len(d)) * 8
+	}
+	return 0
+}
+
+func (c *countingContext) BufferData(target uint32, data any, usage uint32) {
+	c.current.BytesUploaded += dataSize(data)
+	c.Context.BufferData(target, data, usage)
+}
+
+func (c *countingContext) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indexOffset uintptr, instancecount int32) {
+	c.current.DrawCalls++
+	c.current.Instances += uint64(instancecount)
+	if mode == enum.TRIANGLES {
+		c.current.Triangles += uint64(count/3) * uint64(instancecount)
+	}
+	c.Context.DrawElementsInstanced(mode, count, xtype, indexOffset, instancecount)
+}
+
+func (c *countingContext) UseProgram(program any) {
+	if !sameObject(c.program, program) {
+		c.current.ProgramSwitches++
+		c.program = program
+	}
+	c.Context.UseProgram(program)
+}
+
+func (c *countingContext) BindVertexArray(array any) {
+	if !sameObject(c.vertexArray, array) {
+		c.current.VertexArraySwitches++
+		c.vertexArray = array
+	}
+	c.Context.BindVertexArray(array)
+}
+
+func (c *countingContext) BindFramebuffer(target uint32, framebuffer any) {
+	// the read framebuffer is only used for blitting
+	if target != enum.READ_FRAMEBUFFER && !sameObject(c.framebuffer, framebuffer) {
+		c.current.FramebufferSwitches++
+		c.framebuffer = framebuffer
+	}
+	c.Context.BindFramebuffer(target, framebuffer)
+}
+
+func (c *countingContext) Clear(mask uint32) {
+	c.current.Clears++
+	c.Context.Clear(mask)
+}
+
+// deleted objects might get the same name as a new object
+func (c *countingContext) DeleteProgram(program any) {
+	if sameObject(c.program, program) {
+		c.program = nil
+	}
+	c.Context.DeleteProgram(program)
+}
+
+func (c *countingContext) DeleteVertexArray(array any) {
+	if sameObject(c.vertexArray, array) {
+		c.vertexArray = nil
+	}
+	c.Context.DeleteVertexArray(array)
+}
+
+func (c *countingContext) DeleteFramebuffer(framebuffer any) {
+	if sameObject(c.framebuffer, framebuffer) {
+		c.framebuffer = nil
+	}
+	c.Context.DeleteFramebuffer(framebuffer)
+}
+
+func (c *countingContext) Uniform1i(location any, v0 int32) {
+	c.current.UniformsSet++
+	c.Context.Uniform1i(location, v0)
+}
+
+func (c *countingContext) Uniform2i(location any, v0, v1 int32) {
+	c.current.UniformsSet++
+	c.Context.Uniform2i(location, v0, v1)
+}
+
+func (c *countingContext) Uniform3i(location any, v0, v1, v2 int32) {
+	c.current.UniformsSet++
+	c.Context.Uniform3i(location, v0, v1, v2)
+}
+
+func (c *countingContext) Uniform4i(location any, v0, v1, v2, v3 int32) {
+	c.current.UniformsSet++
+	c.Context.Uniform4i(location, v0, v1, v2, v3)
+}
+
+func (c *countingContext) Uniform1ui(location any, v0 uint32) {
+	c.current.UniformsSet++
+	c.Context.Uniform1ui(location, v0)
+}
+
+func (c *countingContext) Uniform2ui(location any, v0, v1 uint32) {
+	c.current.UniformsSet++
+	c.Context.Uniform2ui(location, v0, v1)
+}
+
+func (c *countingContext) Uniform3ui(location any, v0, v1, v2 uint32) {
+	c.current.UniformsSet++
+	c.Context.Uniform3ui(location, v0, v1, v2)
+}
+
+func (c *countingContext) Uniform4ui(location any, v0, v1, v2, v3 uint32) {
+	c.current.UniformsSet++
+	c.Context.Uniform4ui(location, v0, v1, v2, v3)
+}
+
+func (c *countingContext) Uniform1f(location any, v0 float32) {
+	c.current.UniformsSet++
+	c.Context.Uniform1f(location, v0)
+}
+
+func (c *countingContext) Uniform2f(location any, v0, v1 float32) {
+	c.current.UniformsSet++
+	c.Context.Uniform2f(location, v0, v1)
+}
+
+func (c *countingContext) Uniform3f(location any, v0, v1, v2 float32) {
+	c.current.UniformsSet++
+	c.Context.Uniform3f(location, v0, v1, v2)
+}
+
+func (c *countingContext) Uniform4f(location any, v0, v1, v2, v3 float32) {
+	c.current.UniformsSet++
+	c.Context.Uniform4f(location, v0, v1, v2, v3)
+}
@@ -0,0 +1,125 @@
+package gl
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/glow/enum"
+)
+
+type Texture struct {
+	render.TextureIdentifier
+	cxt Context
+	// Texture object
+	Texture       any
+	Options       render.TextureOptions
+	width, height uint16
+}
+
+func GLTexture(t render.Texture) (*Texture, bool) {
+	res, ok := t.(*Texture)
+	return res, ok
+}
+
+func (r *Renderer) MakeTexture(img image.Image, options render.TextureOptions) (render.Texture, error) {
+	t := &Texture{cxt: r.cxt, Texture: r.cxt.CreateTexture()}
+	if err := t.SetImage(img); err != nil {
+		t.Free()
+		return nil, err
+	}
+	t.SetOptions(options)
+	return t, nil
+}
+
+func (t *Texture) Free() {
+	t.cxt.DeleteTexture(t.Texture)
+}
+
+// Estimated amount of GPU memory used in bytes, 4 bytes per pixel
+func (t *Texture) MemorySize() uint64 {
+	size := uint64(t.width) * uint64(t.height) * 4
+	if t.Options.Mipmaps {
+		// every level is a quarter of the previous one
+		size += size / 3
+	}
+	return size
+}
+
+func (t *Texture) Width() uint16 {
+	return t.width
+}
+
+func (t *Texture) Height() uint16 {
+	return t.height
+}
+
+func (t *Texture) SetImage(img image.Image) error {
+	bounds := img.Bounds()
+	if bounds.Dx() > math.MaxUint16 || bounds.Dy() > math.MaxUint16 {
+		return fmt.Errorf("Image of size %vx%v is too big for a texture", bounds.Dx(), bounds.Dy())
+	}
+	t.width, t.height = uint16(bounds.Dx()), uint16(bounds.Dy())
+	t.cxt.BindTexture(enum.TEXTURE_2D, t.Texture)
+	t.cxt.TexImage2D(enum.TEXTURE_2D, 0, enum.RGBA8, int32(t.width), int32(t.height), 0, enum.RGBA, enum.UNSIGNED_BYTE, pixels(img))
+	if t.Options.Mipmaps {
+		t.cxt.GenerateMipmap(enum.TEXTURE_2D)
+	}
+	return nil
+}
+
+// the pixels as non-premultiplied rgba without padding, since blending expects colors that are not premultiplied
+func pixels(img image.Image) []uint8 {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil
+	}
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Stride == bounds.Dx()*4 {
+		return nrgba.Pix[:bounds.Dx()*bounds.Dy()*4]
+	}
+	res := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(res, res.Bounds(), img, bounds.Min, draw.Src)
+	return res.Pix
+}
+
+func (t *Texture) SetOptions(options render.TextureOptions) {
+	makeMipmaps := options.Mipmaps && !t.Options.Mipmaps
+	t.Options = options
+	t.cxt.BindTexture(enum.TEXTURE_2D, t.Texture)
+	if makeMipmaps {
+		t.cxt.GenerateMipmap(enum.TEXTURE_2D)
+	}
+	t.cxt.TexParameteri(enum.TEXTURE_2D, enum.TEXTURE_MIN_FILTER, minFilter(options))
+	t.cxt.TexParameteri(enum.TEXTURE_2D, enum.TEXTURE_MAG_FILTER, glFilter(options.MagFilter))
+	t.cxt.TexParameteri(enum.TEXTURE_2D, enum.TEXTURE_WRAP_S, glWrap(options.WrapX))
+	t.cxt.TexParameteri(enum.TEXTURE_2D, enum.TEXTURE_WRAP_T, glWrap(options.WrapY))
+}
+
+func glFilter(filter render.TextureFilter) int32 {
+	if filter == render.FilterNearest {
+		return enum.NEAREST
+	}
+	return enum.LINEAR
+}
+
+func minFilter(options render.TextureOptions) int32 {
+	if !options.Mipmaps {
+		return glFilter(options.MinFilter)
+	}
+	if options.MinFilter == render.FilterNearest {
+		return enum.NEAREST_MIPMAP_NEAREST
+	}
+	return enum.LINEAR_MIPMAP_LINEAR
+}
+
+func glWrap(wrap render.TextureWrap) int32 {
+	switch wrap {
+	case render.WrapRepeat:
+		return enum.REPEAT
+	case render.WrapMirror:
+		return enum.MIRRORED_REPEAT
+	}
+	return enum.CLAMP_TO_EDGE
+}
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"sort"
+
 	"github.com/eliiasg/deltawing/graphics/vecsprite"
 	"github.com/eliiasg/deltawing/util/buffers"
 )
@@ -52,6 +54,83 @@ func addSprite(verts, inds *[]uint32, sprite *vecsprite.VecSprite) {
 	}
 }
 
+// returns the uvs of every vertex, in the same order as CompileVecSpriteBuffer
+// returns nil if no sprite has uvs, sprites without uvs use (0, 0)
+func CompileVecSpriteUVs(sprites []*vecsprite.VecSprite) []uint32 {
+	textured := false
+	for _, sprite := range sprites {
+		textured = textured || sprite.UVs != nil
+	}
+	if !textured {
+		return nil
+	}
+	numVerts, _ := countSizes(sprites)
+	uvs := make([]uint32, 0, numVerts*2)
+	for _, sprite := range sprites {
+		for i := range sprite.Vertices {
+			var uv [2]float32
+			if i < len(sprite.UVs) {
+				uv = sprite.UVs[i]
+			}
+			buffers.AddTo(&uvs, uv[0])
+			buffers.AddTo(&uvs, uv[1])
+		}
+	}
+	return uvs
+}
+
+// Position of a VecSprite.Data entry in the buffer from CompileVecSpriteData
+type DataLayout struct {
+	// Offset in bytes from the start of a vertex
+	Offset uint32
+	// Floats per vertex
+	Amount uint8
+}
+
+// returns the extra data of every vertex interleaved, in the same order as CompileVecSpriteBuffer
+// every name used by any sprite gets the largest amount any sprite has for it, sprites without the name use zeros
+// entries with an amount that is not 1 to 4 are skipped, VecSprite.Validate returns an error for them
+// returns nil if no sprite has data
+func CompileVecSpriteData(sprites []*vecsprite.VecSprite) (data []uint32, layout map[string]DataLayout, stride uint32) {
+	amounts := make(map[string]uint8)
+	for _, sprite := range sprites {
+		for name, values := range sprite.Data {
+			if values.Amount < 1 || values.Amount > 4 {
+				continue
+			}
+			amounts[name] = max(amounts[name], values.Amount)
+		}
+	}
+	if len(amounts) == 0 {
+		return nil, nil, 0
+	}
+	// sorted so the layout is the same every time
+	names := make([]string, 0, len(amounts))
+	for name := range amounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	layout = make(map[string]DataLayout, len(names))
+	for _, name := range names {
+		layout[name] = DataLayout{stride, amounts[name]}
+		stride += uint32(amounts[name]) * 4
+	}
+	numVerts, _ := countSizes(sprites)
+	data = make([]uint32, 0, numVerts*stride/4)
+	for _, sprite := range sprites {
+		for i := range sprite.Vertices {
+			for _, name := range names {
+				// missing entries are the zero VertexData, which gives zeros
+				values := sprite.Data[name].At(i)
+				for j := uint8(0); j < amounts[name]; j++ {
+					buffers.AddTo(&data, values[j])
+				}
+			}
+		}
+	}
+	return data, layout, stride
+}
+
 func countSizes(sprites []*vecsprite.VecSprite) (verts uint32, inds uint32) {
 	for _, sprite := range sprites {
 		verts += uint32(len(sprite.Vertices))
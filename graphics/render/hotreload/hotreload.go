@@ -0,0 +1,231 @@
+// Reloads procedures and sprites when their files change, meant for development
+// Files are polled, so no extra goroutines are used and everything happens on the render thread
+package hotreload
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/render/procfile"
+	"github.com/eliiasg/deltawing/graphics/text"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+// Implemented by procedures that can be rebuilt in place, like the ones from the gl renderer
+type replaceable interface {
+	Replace(other render.Procedure) error
+}
+
+type entry struct {
+	// used in errors
+	name   string
+	files  []string
+	reload func() error
+	err    error
+}
+
+type spriteBuffer struct {
+	builder render.SpriteBufferBuilder
+	buffer  render.SpriteBuffer
+}
+
+type Watcher struct {
+	// Minimum time between checking files, defaults to half a second
+	Interval time.Duration
+
+	renderer  render.Renderer
+	fsys      fs.FS
+	lastCheck time.Time
+	modTimes  map[string]time.Time
+	entries   []*entry
+	// buffers that must be reallocated after the sprites are reloaded
+	dirty map[spriteBuffer]bool
+}
+
+// Files are read from fsys, use os.DirFS for files on disk
+func NewWatcher(renderer render.Renderer, fsys fs.FS) *Watcher {
+	return &Watcher{
+		Interval: time.Second / 2,
+		renderer: renderer,
+		fsys:     fsys,
+		modTimes: make(map[string]time.Time),
+		entries:  make([]*entry, 0),
+		dirty:    make(map[spriteBuffer]bool),
+	}
+}
+
+func (w *Watcher) modTime(name string) time.Time {
+	info, err := fs.Stat(w.fsys, name)
+	// a missing file might be in the middle of being saved, so it is seen as unchanged
+	if err != nil {
+		return w.modTimes[name]
+	}
+	return info.ModTime()
+}
+
+func (w *Watcher) add(name string, files []string, reload func() error) *entry {
+	e := &entry{name, files, reload, nil}
+	for _, file := range files {
+		w.modTimes[file] = w.modTime(file)
+	}
+	w.entries = append(w.entries, e)
+	return e
+}
+
+// Rebuilds proc when one of the files changes, build should add everything to the builder except calling Finish
+// build is called again on every reload, so it should read the files itself
+// If the new procedure fails to build, the old one is kept and the error is available from Errors
+func (w *Watcher) WatchProcedure(proc render.Procedure, build func(render.ProcedureBuilder) error, files ...string) {
+	w.add(strings.Join(files, ", "), files, func() error {
+		return w.replace(proc, build)
+	})
+}
+
+func (w *Watcher) replace(proc render.Procedure, build func(render.ProcedureBuilder) error) error {
+	target, ok := proc.(replaceable)
+	if !ok {
+		return fmt.Errorf("Procedure of type %T cannot be reloaded", proc)
+	}
+	builder := w.renderer.MakeProcedureBuilder()
+	if err := build(builder); err != nil {
+		return err
+	}
+	newProc, err := builder.Finish()
+	if err != nil {
+		return err
+	}
+	if err := target.Replace(newProc); err != nil {
+		newProc.Free()
+		return err
+	}
+	return nil
+}
+
+// Same as WatchProcedure, but for a procedure loaded with procfile.Load
+// Function files used by the definition are also watched
+func (w *Watcher) WatchProcfile(proc render.Procedure, name string) error {
+	files, err := w.procfileFiles(name)
+	if err != nil {
+		return err
+	}
+	var e *entry
+	e = w.add(name, files, func() error {
+		files, err := w.procfileFiles(name)
+		if err != nil {
+			return err
+		}
+		// the definition might use other files now
+		for _, file := range files {
+			if _, ok := w.modTimes[file]; !ok {
+				w.modTimes[file] = w.modTime(file)
+			}
+		}
+		e.files = files
+		return w.replace(proc, func(builder render.ProcedureBuilder) error {
+			def, err := procfile.ReadFile(w.fsys, name)
+			if err != nil {
+				return err
+			}
+			_, err = def.Build(builder, w.fsys, path.Dir(name))
+			return err
+		})
+	})
+	return nil
+}
+
+func (w *Watcher) procfileFiles(name string) ([]string, error) {
+	def, err := procfile.ReadFile(w.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{name}
+	for _, function := range def.Functions {
+		if function.File != "" {
+			files = append(files, path.Join(path.Dir(name), function.File))
+		}
+	}
+	return files, nil
+}
+
+// Replaces the sprite with the given id when the .tris file changes, and reallocates the buffer
+// If the file cannot be parsed the old sprite is kept and the error is available from Errors
+func (w *Watcher) WatchSprite(builder render.SpriteBufferBuilder, buffer render.SpriteBuffer, id uint32, name string) {
+	w.add(name, []string{name}, func() error {
+		file, err := w.fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		sprite, err := vecsprite.FromBytes(bufio.NewReader(file))
+		if err != nil {
+			return err
+		}
+		if err := builder.ReplaceSprite(id, sprite); err != nil {
+			return err
+		}
+		w.dirty[spriteBuffer{builder, buffer}] = true
+		return nil
+	})
+}
+
+// Checks for changed files and reloads what uses them, should be called every frame on the render thread
+// Files are only checked once every Interval
+func (w *Watcher) Update() {
+	now := time.Now()
+	if now.Sub(w.lastCheck) < w.Interval {
+		return
+	}
+	w.lastCheck = now
+	changed := make(map[string]bool)
+	for file, last := range w.modTimes {
+		if t := w.modTime(file); !t.Equal(last) {
+			w.modTimes[file] = t
+			changed[file] = true
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	for _, e := range w.entries {
+		for _, file := range e.files {
+			if changed[file] {
+				e.err = e.reload()
+				break
+			}
+		}
+	}
+	// once per buffer, even if multiple sprites changed
+	for buf := range w.dirty {
+		buf.builder.Reallocate(buf.buffer)
+		delete(w.dirty, buf)
+	}
+}
+
+// Returns the errors from the latest reload of everything that failed, sorted by file name
+func (w *Watcher) Errors() []error {
+	res := make([]error, 0)
+	for _, e := range w.entries {
+		if e.err != nil {
+			res = append(res, fmt.Errorf("%v: %w", e.name, e.err))
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Error() < res[j].Error()
+	})
+	return res
+}
+
+// Adds the errors to a TextRenderer, one per line, so they can be shown on screen
+// UpdateText must still be called on the TextRenderer
+func (w *Watcher) AddErrorText(renderer *text.TextRenderer, x, y float32) {
+	for _, err := range w.Errors() {
+		renderer.AddText(x, y, err.Error())
+		y += renderer.LineSpacing * float32(strings.Count(err.Error(), "\n")+1)
+	}
+}
@@ -0,0 +1,277 @@
+package hotreload
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+// the methods that are not overridden panic, since they are nil
+type fakeRenderer struct {
+	render.Renderer
+	built int
+}
+
+func (r *fakeRenderer) MakeProcedureBuilder() render.ProcedureBuilder {
+	return fakeBuilder{renderer: r}
+}
+
+type fakeBuilder struct {
+	render.ProcedureBuilder
+	renderer *fakeRenderer
+}
+
+func (b fakeBuilder) Finish() (render.Procedure, error) {
+	b.renderer.built++
+	return &fakeProcedure{version: b.renderer.built}, nil
+}
+
+type fakeProcedure struct {
+	render.ProcedureIdentifier
+	version int
+	freed   bool
+	// returned by Replace
+	err error
+}
+
+func (p *fakeProcedure) Free() {
+	p.freed = true
+}
+
+func (p *fakeProcedure) Describe() *render.ProcedureDescription {
+	return nil
+}
+
+func (p *fakeProcedure) Replace(other render.Procedure) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.version = other.(*fakeProcedure).version
+	return nil
+}
+
+type fakeSpriteBuilder struct {
+	render.SpriteBufferBuilder
+	sprites     map[uint32]*vecsprite.VecSprite
+	reallocated int
+}
+
+func (b *fakeSpriteBuilder) ReplaceSprite(id uint32, sprite *vecsprite.VecSprite) error {
+	if _, ok := b.sprites[id]; !ok {
+		return errors.New("No sprite with the id")
+	}
+	b.sprites[id] = sprite
+	return nil
+}
+
+func (b *fakeSpriteBuilder) Reallocate(buffer render.SpriteBuffer) {
+	b.reallocated++
+}
+
+// a watcher for files in a temp dir, with files checked on every Update
+func newTestWatcher(t *testing.T, renderer render.Renderer) (*Watcher, string) {
+	dir := t.TempDir()
+	w := NewWatcher(renderer, os.DirFS(dir))
+	w.Interval = 0
+	return w, dir
+}
+
+// writes the file with a modification time that is different for every call, since the file system might not have a fine enough resolution
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modTime = modTime.Add(time.Second)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var modTime = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestPolling(t *testing.T) {
+	w, dir := newTestWatcher(t, nil)
+	writeFile(t, dir, "a.glsl", "a")
+	writeFile(t, dir, "b.glsl", "b")
+	reloads := make(map[string]int)
+	var fail error
+	watch := func(name string, files ...string) {
+		w.add(name, files, func() error {
+			reloads[name]++
+			return fail
+		})
+	}
+	watch("a", "a.glsl")
+	watch("both", "a.glsl", "b.glsl")
+	expect := func(a, both int) {
+		t.Helper()
+		if reloads["a"] != a || reloads["both"] != both {
+			t.Fatalf("Reloaded a %v and both %v times, expected %v and %v", reloads["a"], reloads["both"], a, both)
+		}
+	}
+
+	w.Update()
+	expect(0, 0)
+	writeFile(t, dir, "b.glsl", "b2")
+	w.Update()
+	expect(0, 1)
+	// an entry is only reloaded once, even if multiple of its files changed
+	writeFile(t, dir, "a.glsl", "a2")
+	writeFile(t, dir, "b.glsl", "b3")
+	w.Update()
+	expect(1, 2)
+
+	// a missing file is seen as unchanged, since it might be in the middle of being saved
+	if err := os.Remove(filepath.Join(dir, "a.glsl")); err != nil {
+		t.Fatal(err)
+	}
+	w.Update()
+	expect(1, 2)
+
+	fail = errors.New("broken")
+	writeFile(t, dir, "a.glsl", "a3")
+	w.Update()
+	expect(2, 3)
+	errs := w.Errors()
+	if len(errs) != 2 || errs[0].Error() != "a: broken" || errs[1].Error() != "both: broken" {
+		t.Fatalf("Errors are %v", errs)
+	}
+	// errors are from the latest reload
+	fail = nil
+	writeFile(t, dir, "b.glsl", "b4")
+	w.Update()
+	if errs := w.Errors(); len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "a:") {
+		t.Fatalf("Errors are %v", errs)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	w, dir := newTestWatcher(t, nil)
+	w.Interval = time.Hour
+	writeFile(t, dir, "a.glsl", "a")
+	reloads := 0
+	w.add("a", []string{"a.glsl"}, func() error {
+		reloads++
+		return nil
+	})
+	// the first Update checks, since nothing was checked before
+	w.Update()
+	writeFile(t, dir, "a.glsl", "a2")
+	w.Update()
+	if reloads != 0 {
+		t.Fatalf("Reloaded %v times before the interval passed", reloads)
+	}
+}
+
+func TestWatchProcedure(t *testing.T) {
+	renderer := &fakeRenderer{}
+	w, dir := newTestWatcher(t, renderer)
+	writeFile(t, dir, "shader.glsl", "1")
+	proc := &fakeProcedure{}
+	builds := 0
+	var buildErr error
+	w.WatchProcedure(proc, func(render.ProcedureBuilder) error {
+		builds++
+		return buildErr
+	}, "shader.glsl")
+
+	writeFile(t, dir, "shader.glsl", "2")
+	w.Update()
+	if builds != 1 || proc.version != 1 {
+		t.Fatalf("Built %v times and the procedure has version %v", builds, proc.version)
+	}
+
+	// the old procedure is kept if the new one fails to build
+	buildErr = errors.New("syntax error")
+	writeFile(t, dir, "shader.glsl", "3")
+	w.Update()
+	if proc.version != 1 || renderer.built != 1 {
+		t.Fatalf("Procedure was replaced with version %v after a failed build", proc.version)
+	}
+	if errs := w.Errors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "syntax error") {
+		t.Fatalf("Errors are %v", errs)
+	}
+
+	// the new procedure is freed if it cannot replace the old one
+	buildErr = nil
+	proc.err = errors.New("channels changed")
+	writeFile(t, dir, "shader.glsl", "4")
+	w.Update()
+	if proc.version != 1 || renderer.built != 2 {
+		t.Fatalf("Procedure has version %v after %v builds", proc.version, renderer.built)
+	}
+	if errs := w.Errors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "channels changed") {
+		t.Fatalf("Errors are %v", errs)
+	}
+}
+
+func TestWatchProcedureNotReplaceable(t *testing.T) {
+	w, dir := newTestWatcher(t, &fakeRenderer{})
+	writeFile(t, dir, "shader.glsl", "1")
+	proc := struct{ render.Procedure }{}
+	w.WatchProcedure(proc, func(render.ProcedureBuilder) error { return nil }, "shader.glsl")
+	writeFile(t, dir, "shader.glsl", "2")
+	w.Update()
+	if errs := w.Errors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "cannot be reloaded") {
+		t.Fatalf("Errors are %v", errs)
+	}
+}
+
+// width is the x of the second vertex, so the sprites can be told apart
+func spriteFile(t *testing.T, width float32) string {
+	red := color.FromRGBA(255, 0, 0, 255)
+	sprite := &vecsprite.VecSprite{
+		Vertices: [][2]float32{{0, 0}, {width, 0}, {0, 1}},
+		Colors:   []color.Color{red, red, red},
+		Layers:   []uint8{0, 0, 0},
+		Indices:  []uint32{0, 1, 2},
+	}
+	data, err := sprite.ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestWatchSprite(t *testing.T) {
+	w, dir := newTestWatcher(t, nil)
+	writeFile(t, dir, "a.tris", spriteFile(t, 0.5))
+	writeFile(t, dir, "b.tris", spriteFile(t, 0.5))
+	writeFile(t, dir, "missing.tris", spriteFile(t, 0.5))
+	builder := &fakeSpriteBuilder{sprites: map[uint32]*vecsprite.VecSprite{0: nil, 1: nil}}
+	w.WatchSprite(builder, nil, 0, "a.tris")
+	w.WatchSprite(builder, nil, 1, "b.tris")
+	w.WatchSprite(builder, nil, 5, "missing.tris")
+
+	// the buffer is reallocated once, even if multiple sprites changed
+	writeFile(t, dir, "a.tris", spriteFile(t, 1))
+	writeFile(t, dir, "b.tris", spriteFile(t, 2))
+	w.Update()
+	if builder.reallocated != 1 {
+		t.Fatalf("Reallocated %v times", builder.reallocated)
+	}
+	if builder.sprites[0].Vertices[1][0] != 1 || builder.sprites[1].Vertices[1][0] != 2 {
+		t.Fatal("Sprites were not replaced")
+	}
+
+	// the old sprite is kept if the file cannot be parsed
+	writeFile(t, dir, "a.tris", "not a sprite")
+	writeFile(t, dir, "missing.tris", spriteFile(t, 3))
+	w.Update()
+	if builder.reallocated != 1 || builder.sprites[0].Vertices[1][0] != 1 {
+		t.Fatalf("Sprite was replaced with an invalid file")
+	}
+	errs := w.Errors()
+	if len(errs) != 2 || !strings.HasPrefix(errs[0].Error(), "a.tris") || !strings.Contains(errs[1].Error(), "No sprite") {
+		t.Fatalf("Errors are %v", errs)
+	}
+}
@@ -0,0 +1,243 @@
+// Procedures for common ways of drawing sprites
+// A preset is a ProcedureBuilder with channels and calls already added, more can be added before Finish
+package presets
+
+import (
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/render/functions"
+)
+
+var (
+	float1 = render.Type(render.ShaderFloat, 1)
+	float2 = render.Type(render.ShaderFloat, 2)
+	float4 = render.Type(render.ShaderFloat, 4)
+	int4   = render.Type(render.ShaderInt, 4)
+	uint1  = render.Type(render.ShaderUnsignedInt, 1)
+)
+
+// output channels are intermediate so functions can modify them, so the attributes must be copied
+var copyVec2 = render.NewFunction(`void dwCopyVec2(in vec2 from, out vec2 to) {
+    to = from;
+}`, "dwCopyVec2", float2, float2)
+
+var copyVec4 = render.NewFunction(`void dwCopyVec4(in vec4 from, out vec4 to) {
+    to = from;
+}`, "dwCopyVec4", float4, float4)
+
+var copyIVec4 = render.NewFunction(`void dwCopyIVec4(in ivec4 from, out ivec4 to) {
+    to = from;
+}`, "dwCopyIVec4", int4, int4)
+
+type Preset struct {
+	// Builder with the channels and calls of the preset, functions added to it are called after the ones from the preset
+	Builder render.ProcedureBuilder
+	// Layout of the DataBuffer, every attribute is interleaved in a single buffer
+	Layout []render.InputType
+	// Attribute channels, in the same order as Layout
+	Attributes []render.Channel
+
+	// Intermediate channels used for the output of the procedure, these can be modified by functions
+	Position render.Channel
+	XAxis    render.Channel
+	YAxis    render.Channel
+	// Color from 0 to 255, starts as the color of the sprite
+	Color render.Channel
+	// Operation channel for the layer, 1 uint - set it with Operation.SetChannelValue
+	Layer render.Channel
+}
+
+// Makes a preset with the output channels, attributes are added by the presets
+func newPreset(renderer render.Renderer) (*Preset, error) {
+	builder := renderer.MakeProcedureBuilder()
+	p := &Preset{
+		Builder:    builder,
+		Layout:     make([]render.InputType, 0),
+		Attributes: make([]render.Channel, 0),
+		Position:   builder.AddIntermediateChannel(float2, "vec2(0, 0)"),
+		XAxis:      builder.AddIntermediateChannel(float2, "vec2(1, 0)"),
+		YAxis:      builder.AddIntermediateChannel(float2, "vec2(0, 1)"),
+		Color:      builder.AddIntermediateChannel(int4, "ivec4(aColor.rgb, 255)"),
+		Layer:      builder.AddOperationChannel(uint1),
+	}
+	setters := []func(render.Channel) error{builder.SetPositionChannel, builder.SetXAxisChannel, builder.SetYAxisChannel, builder.SetColorChannel, builder.SetLayerChannel}
+	for i, channel := range []render.Channel{p.Position, p.XAxis, p.YAxis, p.Color, p.Layer} {
+		if err := setters[i](channel); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+func (p *Preset) addAttribute(shaderType render.ShaderType, inputType render.InputType) render.Channel {
+	channel := p.Builder.AddAttributeChannel(shaderType)
+	p.Attributes = append(p.Attributes, channel)
+	p.Layout = append(p.Layout, inputType)
+	return channel
+}
+
+// Same as Builder.Finish()
+func (p *Preset) Finish() (render.Procedure, error) {
+	return p.Builder.Finish()
+}
+
+// Makes a DataBuffer with the layout of the preset
+func (p *Preset) MakeDataBuffer(renderer render.Renderer, static bool) render.DataBuffer {
+	buffer := renderer.MakeDataBuffer(static)
+	buffer.SetLayout(p.Layout...)
+	return buffer
+}
+
+// Sets every attribute of the operation to use the buffer, offset is the first instance to draw
+// The buffer should be made with MakeDataBuffer, and the operation with the procedure of this preset
+func (p *Preset) SetBuffer(operation render.Operation, buffer render.DataBuffer, offset uint32) error {
+	for i, channel := range p.Attributes {
+		if err := operation.SetInstanceAttribute(channel, buffer, offset, uint16(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Data for a single sprite drawn with Positioned, add it to a buffer with buffers.AddAll
+type PositionedInstance struct {
+	Position [2]float32
+}
+
+// Draws sprites at a position
+// Attributes: position (2 floats)
+func Positioned(renderer render.Renderer) (*Preset, error) {
+	p, err := newPreset(renderer)
+	if err != nil {
+		return nil, err
+	}
+	position := p.addAttribute(float2, render.Input(render.InputFloat, 2))
+	if err := p.Builder.CallFunction(copyVec2, position, p.Position); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Data for a single sprite drawn with Transformed, add it to a buffer with buffers.AddAll
+type TransformedInstance struct {
+	Position [2]float32
+	// In radians
+	Angle float32
+	Scale [2]float32
+}
+
+// Draws sprites at a position, with rotation and scale
+// Attributes: position (2 floats), angle in radians (1 float), scale (2 floats)
+func Transformed(renderer render.Renderer) (*Preset, error) {
+	p, err := Positioned(renderer)
+	if err != nil {
+		return nil, err
+	}
+	angle := p.addAttribute(float1, render.Input(render.InputFloat, 1))
+	scale := p.addAttribute(float2, render.Input(render.InputFloat, 2))
+	if err := p.Builder.CallFunction(functions.RotateScale, angle, scale, p.XAxis, p.YAxis); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Data for a single sprite drawn with Tinted, add it to a buffer with buffers.AddAll
+type TintedInstance struct {
+	Position [2]float32
+	// In radians
+	Angle float32
+	Scale [2]float32
+	// Multiplied with the color of the sprite, use white for no tint
+	Tint color.Color
+}
+
+// Same as Transformed, but with a tint multiplied with the color of the sprite
+// Attributes: position (2 floats), angle in radians (1 float), scale (2 floats), tint (4 unsigned bytes, rgba)
+func Tinted(renderer render.Renderer) (*Preset, error) {
+	p, err := Transformed(renderer)
+	if err != nil {
+		return nil, err
+	}
+	tint := p.addAttribute(int4, render.Input(render.InputUnsignedByte, 4))
+	if err := p.Builder.CallFunction(functions.Tint, tint, p.Color); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+var textTransform = render.NewFunction(`void dwTextTransform(in vec2 offset, in float size, inout vec2 pos, inout vec2 xAxis, inout vec2 yAxis) {
+    pos = offset + pos * size;
+    xAxis *= size;
+    yAxis *= size;
+}`, "dwTextTransform", float2, float1, float2, float2, float2)
+
+type TextPreset struct {
+	*Preset
+	// Operation channel for the position of the text, 2 floats
+	Offset render.Channel
+	// Operation channel for the size of the text, 1 float - the font is multiplied by this
+	Size render.Channel
+	// Operation channel for the color of the text, 4 ints from 0 to 255
+	TextColor render.Channel
+}
+
+// Draws glyphs for a text.TextRenderer, use Attributes[0] as the PositionChannel of the TextRenderer
+// The TextRenderer sets the layout of its own buffer, so MakeDataBuffer and SetBuffer should not be used
+// Attributes: position of glyph (2 floats)
+func Text(renderer render.Renderer) (*TextPreset, error) {
+	p, err := Positioned(renderer)
+	if err != nil {
+		return nil, err
+	}
+	t := &TextPreset{
+		Preset:    p,
+		Offset:    p.Builder.AddOperationChannel(float2),
+		Size:      p.Builder.AddOperationChannel(float1),
+		TextColor: p.Builder.AddOperationChannel(int4),
+	}
+	if err := p.Builder.CallFunction(textTransform, t.Offset, t.Size, p.Position, p.XAxis, p.YAxis); err != nil {
+		return nil, err
+	}
+	if err := p.Builder.CallFunction(copyIVec4, t.TextColor, p.Color); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+type TexturedPreset struct {
+	*Preset
+	// Texture channel, set it with Operation.SetTexture - the color of the sprite and the tint are multiplied with it
+	Texture render.Channel
+	// Varying channel for the texture coordinate, starts as VertexUVChannel and can be modified by vertex functions
+	UV render.Channel
+	// Fragment intermediate channel for the color of the pixel, 4 floats from 0 to 1 - the texture is already applied
+	PixelColor render.Channel
+}
+
+// Same as Tinted, but the sprites are textured using VecSprite.UVs
+// Attributes: position (2 floats), angle in radians (1 float), scale (2 floats), tint (4 unsigned bytes, rgba)
+func Textured(renderer render.Renderer) (*TexturedPreset, error) {
+	p, err := Tinted(renderer)
+	if err != nil {
+		return nil, err
+	}
+	t := &TexturedPreset{
+		Preset:     p,
+		Texture:    p.Builder.AddTextureChannel(),
+		UV:         p.Builder.AddVaryingChannel(float2, ""),
+		PixelColor: p.Builder.AddFragmentIntermediateChannel(float4, ""),
+	}
+	if err := p.Builder.CallFunction(copyVec2, p.Builder.VertexUVChannel(), t.UV); err != nil {
+		return nil, err
+	}
+	if err := p.Builder.CallFragmentFunction(copyVec4, p.Builder.PixelColorChannel(), t.PixelColor); err != nil {
+		return nil, err
+	}
+	if err := p.Builder.CallFragmentFunction(functions.SampleTexture, t.Texture, t.UV, t.PixelColor); err != nil {
+		return nil, err
+	}
+	if err := p.Builder.SetFragmentColorChannel(t.PixelColor); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,349 @@
+// Procedures defined in JSON files instead of Go code
+// Only JSON is supported, since the standard library has no TOML parser
+//
+// Example:
+//
+//	{
+//		"channels": [
+//			{"name": "pos", "kind": "attribute", "type": "float2"},
+//			{"name": "angle", "kind": "attribute", "type": "float1"},
+//			{"name": "xAxis", "kind": "intermediate", "type": "float2", "expression": "vec2(1, 0)"},
+//			{"name": "yAxis", "kind": "intermediate", "type": "float2", "expression": "vec2(0, 1)"}
+//		],
+//		"functions": [
+//			{"name": "rotate", "file": "rotate.glsl", "parameters": ["float1", "float2", "float2"]}
+//		],
+//		"calls": [
+//			{"function": "rotate", "channels": ["angle", "xAxis", "yAxis"]}
+//		],
+//		"outputs": {"pos": "pos", "xAxis": "xAxis", "yAxis": "yAxis"}
+//	}
+package procfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+)
+
+type Channel struct {
+	Name string `json:"name"`
+	// intermediate, fragment intermediate, attribute, operation, varying or vertex data
+	Kind string `json:"kind"`
+	// like float2 or int4, or texture for operation channels set with Operation.SetTexture
+	Type string `json:"type"`
+	// default value for intermediate and varying channels
+	Expression string `json:"expression,omitempty"`
+	// name of the VecSprite.Data entry read by vertex data channels, the name of the channel is used if empty
+	Data string `json:"data,omitempty"`
+}
+
+type Function struct {
+	// name of the GLSL function
+	Name string `json:"name"`
+	// either Source or File must be set, File is relative to the definition
+	Source string `json:"source,omitempty"`
+	File   string `json:"file,omitempty"`
+	// types of the parameters, like float2 or int4
+	Parameters []string `json:"parameters"`
+	// names of other functions in the definition used by this one
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+type Call struct {
+	// name of a function in the definition
+	Function string `json:"function"`
+	// names of channels, builtin channels like time can be used without being declared
+	Channels []string `json:"channels"`
+	// true to call it for every pixel
+	Fragment bool `json:"fragment,omitempty"`
+}
+
+// A procedure described in a file
+type Definition struct {
+	// in the order they are added, so attributes get buffer indices in this order
+	Channels  []Channel  `json:"channels"`
+	Functions []Function `json:"functions"`
+	// in the order they are called
+	Calls []Call `json:"calls"`
+	// output name -> channel name, outputs are pos, layer, color, xAxis, yAxis, vertex and fragColor
+	Outputs map[string]string `json:"outputs"`
+}
+
+var builtinChannels = map[string]func(render.ProcedureBuilder) render.Channel{
+	"vertexPos":  render.ProcedureBuilder.VertexPositionChannel,
+	"instanceID": render.ProcedureBuilder.InstanceIDChannel,
+	"vertexID":   render.ProcedureBuilder.VertexIDChannel,
+	"vertexUV":   render.ProcedureBuilder.VertexUVChannel,
+	"time":       render.ProcedureBuilder.TimeChannel,
+	"pixelPos":   render.ProcedureBuilder.PixelPositionChannel,
+	"pixelColor": render.ProcedureBuilder.PixelColorChannel,
+}
+
+var outputs = map[string]func(render.ProcedureBuilder, render.Channel) error{
+	"pos":       render.ProcedureBuilder.SetPositionChannel,
+	"layer":     render.ProcedureBuilder.SetLayerChannel,
+	"color":     render.ProcedureBuilder.SetColorChannel,
+	"xAxis":     render.ProcedureBuilder.SetXAxisChannel,
+	"yAxis":     render.ProcedureBuilder.SetYAxisChannel,
+	"vertex":    render.ProcedureBuilder.SetVertexChannel,
+	"fragColor": render.ProcedureBuilder.SetFragmentColorChannel,
+}
+
+var typeNames = map[string]render.ChannelShaderType{
+	"float": render.ShaderFloat,
+	"int":   render.ShaderInt,
+	"uint":  render.ShaderUnsignedInt,
+	// sampler2D, channels with it must be operation channels
+	"texture": render.ShaderSampler,
+}
+
+// Parses names like float2, the amount may be left out for 1
+func ParseType(name string) (render.ShaderType, error) {
+	amount := uint8(1)
+	base := name
+	if last := len(name) - 1; last > 0 && name[last] >= '1' && name[last] <= '4' {
+		amount = name[last] - '0'
+		base = name[:last]
+	}
+	typ, ok := typeNames[base]
+	// texture is always a single sampler
+	if !ok || (typ == render.ShaderSampler && base != name) {
+		return render.ShaderType{}, fmt.Errorf("Unknown type %v, expected float, int or uint followed by 1-4, or texture", name)
+	}
+	return render.Type(typ, amount), nil
+}
+
+// Parses a definition without reading function files
+// Unknown fields are errors, so misspelled fields are not silently ignored
+func Parse(data []byte) (*Definition, error) {
+	def := &Definition{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(def); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("Unexpected data after the definition")
+	}
+	// the source is needed to build the procedure, so it is checked here instead of when building
+	for _, function := range def.Functions {
+		if err := function.checkSource(); err != nil {
+			return nil, err
+		}
+	}
+	return def, nil
+}
+
+// Names of the attribute channels in the order they are added, use the index as bufferIndex in Operation.SetInstanceAttribute
+func (d *Definition) Attributes() []string {
+	res := make([]string, 0)
+	for _, channel := range d.Channels {
+		if channel.Kind == "attribute" {
+			res = append(res, channel.Name)
+		}
+	}
+	return res
+}
+
+// Reads and parses the definition at the given path
+func ReadFile(fsys fs.FS, name string) (*Definition, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	def, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", name, err)
+	}
+	return def, nil
+}
+
+// Reads the definition, builds it and finishes the procedure
+// Returns the procedure, and the channels by their name in the file
+func Load(renderer render.Renderer, fsys fs.FS, name string) (render.Procedure, map[string]render.Channel, error) {
+	def, err := ReadFile(fsys, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	builder := renderer.MakeProcedureBuilder()
+	channels, err := def.Build(builder, fsys, path.Dir(name))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v: %w", name, err)
+	}
+	proc, err := builder.Finish()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v: %w", name, err)
+	}
+	return proc, channels, nil
+}
+
+// Adds the channels, calls and outputs of the definition to the builder, without finishing it
+// Function files are read from dir in fsys, fsys may be nil if no functions use files
+// Returns the channels by their name in the file, including builtin channels that were used
+func (d *Definition) Build(builder render.ProcedureBuilder, fsys fs.FS, dir string) (map[string]render.Channel, error) {
+	channels, err := d.addChannels(builder)
+	if err != nil {
+		return nil, err
+	}
+	functions, err := d.makeFunctions(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	lookup := func(name string) (render.Channel, error) {
+		if channel, ok := channels[name]; ok {
+			return channel, nil
+		}
+		if builtin, ok := builtinChannels[name]; ok {
+			channels[name] = builtin(builder)
+			return channels[name], nil
+		}
+		return nil, errors.New("Unknown channel " + name)
+	}
+	for i, call := range d.Calls {
+		function, ok := functions[call.Function]
+		if !ok {
+			return nil, fmt.Errorf("call %v: unknown function %v", i, call.Function)
+		}
+		args := make([]render.Channel, 0, len(call.Channels))
+		for _, name := range call.Channels {
+			channel, err := lookup(name)
+			if err != nil {
+				return nil, fmt.Errorf("call %v to %v: %w", i, call.Function, err)
+			}
+			args = append(args, channel)
+		}
+		if call.Fragment {
+			err = builder.CallFragmentFunction(function, args...)
+		} else {
+			err = builder.CallFunction(function, args...)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("call %v to %v: %w", i, call.Function, err)
+		}
+	}
+	// sorted so errors are the same every time
+	names := make([]string, 0, len(d.Outputs))
+	for output := range d.Outputs {
+		names = append(names, output)
+	}
+	sort.Strings(names)
+	for _, output := range names {
+		name := d.Outputs[output]
+		set, ok := outputs[output]
+		if !ok {
+			return nil, errors.New("Unknown output " + output)
+		}
+		channel, err := lookup(name)
+		if err != nil {
+			return nil, fmt.Errorf("output %v: %w", output, err)
+		}
+		if err := set(builder, channel); err != nil {
+			return nil, fmt.Errorf("output %v: %w", output, err)
+		}
+	}
+	return channels, nil
+}
+
+func (d *Definition) addChannels(builder render.ProcedureBuilder) (map[string]render.Channel, error) {
+	channels := make(map[string]render.Channel)
+	for _, channel := range d.Channels {
+		if _, ok := channels[channel.Name]; ok || builtinChannels[channel.Name] != nil {
+			return nil, errors.New("Channel declared twice: " + channel.Name)
+		}
+		typ, err := ParseType(channel.Type)
+		if err != nil {
+			return nil, fmt.Errorf("channel %v: %w", channel.Name, err)
+		}
+		if typ.Type == render.ShaderSampler {
+			if channel.Kind != "operation" {
+				return nil, fmt.Errorf("channel %v: textures must be operation channels", channel.Name)
+			}
+			channels[channel.Name] = builder.AddTextureChannel()
+			continue
+		}
+		switch channel.Kind {
+		case "intermediate":
+			channels[channel.Name] = builder.AddIntermediateChannel(typ, channel.Expression)
+		case "fragment intermediate":
+			channels[channel.Name] = builder.AddFragmentIntermediateChannel(typ, channel.Expression)
+		case "attribute":
+			channels[channel.Name] = builder.AddAttributeChannel(typ)
+		case "operation":
+			channels[channel.Name] = builder.AddOperationChannel(typ)
+		case "varying":
+			channels[channel.Name] = builder.AddVaryingChannel(typ, channel.Expression)
+		case "vertex data":
+			if typ.Type != render.ShaderFloat {
+				return nil, fmt.Errorf("channel %v: vertex data must be floats", channel.Name)
+			}
+			data := channel.Data
+			if data == "" {
+				data = channel.Name
+			}
+			channels[channel.Name] = builder.AddVertexDataChannel(data, typ.Amount)
+		default:
+			return nil, fmt.Errorf("channel %v: unknown kind %v", channel.Name, channel.Kind)
+		}
+	}
+	return channels, nil
+}
+
+func (f *Function) checkSource() error {
+	if f.Source == "" && f.File == "" {
+		return fmt.Errorf("function %v: either source or file must be set", f.Name)
+	}
+	if f.Source != "" && f.File != "" {
+		return fmt.Errorf("function %v: only one of source and file can be set", f.Name)
+	}
+	return nil
+}
+
+func (d *Definition) makeFunctions(fsys fs.FS, dir string) (map[string]*render.Function, error) {
+	functions := make(map[string]*render.Function)
+	for _, function := range d.Functions {
+		if _, ok := functions[function.Name]; ok {
+			return nil, errors.New("Function declared twice: " + function.Name)
+		}
+		if err := function.checkSource(); err != nil {
+			return nil, err
+		}
+		source := function.Source
+		if function.File != "" {
+			if fsys == nil {
+				return nil, fmt.Errorf("function %v: cannot read %v without a file system", function.Name, function.File)
+			}
+			data, err := fs.ReadFile(fsys, path.Join(dir, function.File))
+			if err != nil {
+				return nil, fmt.Errorf("function %v: %w", function.Name, err)
+			}
+			source = string(data)
+		}
+		params := make([]render.ShaderType, 0, len(function.Parameters))
+		for _, param := range function.Parameters {
+			typ, err := ParseType(param)
+			if err != nil {
+				return nil, fmt.Errorf("function %v: %w", function.Name, err)
+			}
+			params = append(params, typ)
+		}
+		functions[function.Name] = render.NewFunction(source, function.Name, params...)
+	}
+	// after every function is made, so the order in the file does not matter
+	for _, function := range d.Functions {
+		for _, name := range function.Dependencies {
+			dependency, ok := functions[name]
+			if !ok {
+				return nil, fmt.Errorf("function %v: unknown dependency %v", function.Name, name)
+			}
+			functions[function.Name].DependsOn(dependency)
+		}
+	}
+	return functions, nil
+}
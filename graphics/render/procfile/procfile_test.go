@@ -0,0 +1,90 @@
+package procfile
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"empty", `{}`, ""},
+		{"example", `{
+			"channels": [{"name": "pos", "kind": "attribute", "type": "float2"}],
+			"functions": [{"name": "f", "file": "f.glsl", "parameters": ["float2"]}],
+			"calls": [{"function": "f", "channels": ["pos"]}],
+			"outputs": {"pos": "pos"}
+		}`, ""},
+		{"source", `{"functions": [{"name": "f", "source": "void f() {}", "parameters": []}]}`, ""},
+		{"unknown field", `{"chanels": []}`, "unknown field"},
+		{"unknown channel field", `{"channels": [{"name": "pos", "knd": "attribute"}]}`, "unknown field"},
+		{"unknown function field", `{"functions": [{"name": "f", "source": "x", "params": []}]}`, "unknown field"},
+		{"wrong type", `{"channels": {}}`, "cannot unmarshal"},
+		{"number as name", `{"channels": [{"name": 5}]}`, "cannot unmarshal"},
+		{"fragment as string", `{"calls": [{"function": "f", "fragment": "yes"}]}`, "cannot unmarshal"},
+		{"outputs as list", `{"outputs": ["pos"]}`, "cannot unmarshal"},
+		{"no source or file", `{"functions": [{"name": "f", "parameters": []}]}`, "function f: either source or file"},
+		{"source and file", `{"functions": [{"name": "f", "source": "x", "file": "f.glsl"}]}`, "function f: only one"},
+		{"data after", `{} {}`, "Unexpected data"},
+		{"not json", `channels`, "invalid character"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse([]byte(test.data))
+			if test.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Error is %v, expected %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParseType(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  render.ShaderType
+		err  bool
+	}{
+		{"float", render.Type(render.ShaderFloat, 1), false},
+		{"float1", render.Type(render.ShaderFloat, 1), false},
+		{"int4", render.Type(render.ShaderInt, 4), false},
+		{"uint2", render.Type(render.ShaderUnsignedInt, 2), false},
+		{"texture", render.Type(render.ShaderSampler, 1), false},
+		{"float5", render.ShaderType{}, true},
+		{"float0", render.ShaderType{}, true},
+		{"texture2", render.ShaderType{}, true},
+		{"vec2", render.ShaderType{}, true},
+		{"2", render.ShaderType{}, true},
+		{"", render.ShaderType{}, true},
+	}
+	for _, test := range tests {
+		typ, err := ParseType(test.name)
+		if (err != nil) != test.err || typ != test.typ {
+			t.Errorf("Type %q is %v with error %v", test.name, typ, err)
+		}
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	def, err := Parse([]byte(`{"channels": [
+		{"name": "pos", "kind": "attribute", "type": "float2"},
+		{"name": "tint", "kind": "operation", "type": "int4"},
+		{"name": "angle", "kind": "attribute", "type": "float"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attributes := def.Attributes(); len(attributes) != 2 || attributes[0] != "pos" || attributes[1] != "angle" {
+		t.Fatalf("Attributes are %v", attributes)
+	}
+}
@@ -1,6 +1,8 @@
 package render
 
 import (
+	"image"
+
 	"github.com/eliiasg/deltawing/graphics/vecsprite"
 )
 
@@ -31,6 +33,8 @@ const (
 	ShaderUnsignedInt
 	ShaderFloat
 	// Double missing since it is not avalibe in GLSL ES 300
+	// A texture, sampler2D in GLSL, amount must be 1 - only used for channels from AddTextureChannel
+	ShaderSampler
 )
 
 func IsInt(t ChannelShaderType) bool {
@@ -77,6 +81,20 @@ type Function struct {
 	Parameters []ShaderType
 	Source     string
 	Name       string
+	// Functions called from Source, these are added to the shader before this function
+	Dependencies []*Function
+	// Snippets used by Source, these are also added before this function
+	Includes []*Snippet
+}
+
+// Shared GLSL code, like constants, structs and helper functions, that can be included by Functions
+// A snippet is only added to a shader once, no matter how many functions include it
+type Snippet struct {
+	// Used in error messages
+	Name   string
+	Source string
+	// Snippets used by Source
+	Includes []*Snippet
 }
 
 func Type(t ChannelShaderType, amt uint8) ShaderType {
@@ -88,7 +106,23 @@ func Input(t ChannelInputType, amt uint8) InputType {
 }
 
 func NewFunction(source, name string, params ...ShaderType) *Function {
-	return &Function{params, source, name}
+	return &Function{Parameters: params, Source: source, Name: name}
+}
+
+func NewSnippet(name, source string, includes ...*Snippet) *Snippet {
+	return &Snippet{name, source, includes}
+}
+
+// Adds functions called by the function, returns the function so it can be used with NewFunction
+func (f *Function) DependsOn(functions ...*Function) *Function {
+	f.Dependencies = append(f.Dependencies, functions...)
+	return f
+}
+
+// Adds snippets used by the function, returns the function so it can be used with NewFunction
+func (f *Function) Include(snippets ...*Snippet) *Function {
+	f.Includes = append(f.Includes, snippets...)
+	return f
 }
 
 type RendererObject interface {
@@ -130,21 +164,75 @@ func (s SpriteBufferIdentifier) spriteBuffer() {
 type SpriteBufferBuilder interface {
 	// adds a sprite to the buffer and returns the ID
 	AddSprite(sprite *vecsprite.VecSprite) uint32
+	// adds sprites that were already compiled, like sprites loaded with graphics/spritepack, and returns the ID of the first one - the rest follow in order
+	// compiled is only uploaded as is if these are the only sprites in the builder and none are replaced, otherwise the sprites are compiled like normal
+	AddCompiled(sprites []*vecsprite.VecSprite, compiled *vecsprite.Compiled) uint32
+	// replaces the sprite with the given ID, buffers must be reallocated to use the new sprite
+	// returns an error if no sprite has the ID, like after Clear
+	ReplaceSprite(id uint32, sprite *vecsprite.VecSprite) error
 	// static specifies whether the buffer is optimized to not be reallocated
 	MakeBuffer(static bool) SpriteBuffer
+	// does nothing if the buffer is not from the same renderer as the builder
 	Reallocate(buffer SpriteBuffer)
 	Clear()
 }
 
+type TextureFilter uint8
+
+const (
+	FilterLinear TextureFilter = iota
+	// Pixelated, good for pixel art
+	FilterNearest
+)
+
+// What happens when a texture is read outside of 0 to 1
+type TextureWrap uint8
+
+const (
+	WrapClamp TextureWrap = iota
+	WrapRepeat
+	WrapMirror
+)
+
+type TextureOptions struct {
+	// Used when the texture is drawn smaller than its size
+	MinFilter TextureFilter
+	// Used when the texture is drawn bigger than its size
+	MagFilter TextureFilter
+	WrapX     TextureWrap
+	WrapY     TextureWrap
+	// Makes smaller versions of the texture, so it looks better when drawn much smaller, uses a third more memory
+	Mipmaps bool
+}
+
+// An image that procedures can read from, set it with Operation.SetTexture
+type Texture interface {
+	RendererObject
+	Width() uint16
+	Height() uint16
+	// Replaces the image, the size may be different, returns an error if the image is too big
+	SetImage(img image.Image) error
+	SetOptions(options TextureOptions)
+	// the hack yet again
+	texture()
+}
+
+type TextureIdentifier struct{}
+
+func (s TextureIdentifier) texture() {
+	panic("should never be called")
+}
+
 // An image/screen/texture/framebuffer/whatever that can be drawn to.
 type RenderTarget interface {
 	RendererObject
 	Width() uint16
 	Height() uint16
 	Clear(r, g, b uint8)
-	Resize(width, height uint16)
-	// Draw on other RenderTarget using bliting
-	BlitTo(target RenderTarget, x, y int32)
+	// Returns an error for the primary RenderTarget, since its size is the size of the window
+	Resize(width, height uint16) error
+	// Draw on other RenderTarget using bliting, returns an error if target is multisampled
+	BlitTo(target RenderTarget, x, y int32) error
 	// Draw on other RenderTarget with given shader,position, size, rotation and pivot, pivot is realative to given size
 	// Disabled for now, i'll need a proper FragmentShader system sometime
 	//DrawTo(target RenderTarget, x, y int32, width, height, pivotX, pivotY uint16, rotation float32, shader FragmentShader)
@@ -153,6 +241,8 @@ type RenderTarget interface {
 // Describes how to transform a sprite from the given data
 type Procedure interface {
 	RendererObject
+	// Describes the channels, calls, outputs and generated source of the procedure
+	Describe() *ProcedureDescription
 	// the hack again
 	procedure()
 }
@@ -188,9 +278,50 @@ type ProcedureBuilder interface {
 	// These channels may only be read from
 	AddOperationChannel(shaderType ShaderType) Channel
 
+	// A channel initialized per vertex from VecSprite.Data, with amount floats read from the entry with the given name
+	// Vertices of sprites without the entry get zeros, but if no sprite in the buffer has it the 4th float is 1 like in OpenGL
+	// These channels may only be read from, and only in vertex functions
+	AddVertexDataChannel(name string, amount uint8) Channel
+
+	// A texture set per operation with Operation.SetTexture, it is a sampler2D in GLSL and should be read with the texture function
+	// Usable in both vertex and fragment functions, and may only be read from
+	AddTextureChannel() Channel
+
+	// A channel written per vertex and read per pixel, the value is interpolated between the vertices unless it is an int
+	// 'expression' specifies the default value, as a GLSL expression, may be empty
+	// These channels may only be written by vertex functions, and are read-only in fragment functions
+	AddVaryingChannel(shaderType ShaderType, expression string) Channel
+
+	// Same as AddIntermediateChannel, but the channel is only usable in fragment functions
+	// Intermediate channels from AddIntermediateChannel are only usable in vertex functions
+	AddFragmentIntermediateChannel(shaderType ShaderType, expression string) Channel
+
+	// Builtin channels, these exist in every procedure and may only be read from
+	// Position of the vertex in the sprite, 2 floats with y pointing down like the final position
+	VertexPositionChannel() Channel
+	// Index of the sprite being drawn in the operation, 1 int
+	InstanceIDChannel() Channel
+	// Index of the vertex in the sprite buffer, 1 int
+	VertexIDChannel() Channel
+	// Texture coordinate of the vertex from VecSprite.UVs, 2 floats - (0, 0) if the sprite has none
+	VertexUVChannel() Channel
+	// Seconds since the program started, 1 float, this is also usable in fragment functions
+	TimeChannel() Channel
+
+	// Builtin fragment channels, these may only be read from
+	// Position of the pixel, 2 floats with (0, 0) in the top left
+	PixelPositionChannel() Channel
+	// Color of the pixel before fragment functions, 4 floats from 0 to 1
+	PixelColorChannel() Channel
+
 	// Adds a function, keep in mind that order matters
 	CallFunction(function *Function, channels ...Channel) error
 
+	// Adds a function that runs for every pixel, order also matters here
+	// Fragment functions may use varying, operation, fragment intermediate and builtin fragment channels
+	// The GLSL 'discard' keyword can be used to not draw the pixel
+	CallFragmentFunction(function *Function, channels ...Channel) error
+
 	// Sets the channel to use for the position, must be 2 floats - final position is (0, 0) in top left and (width-1, height-1) in bottom right
 	SetPositionChannel(channel Channel) error
 
@@ -205,6 +336,14 @@ type ProcedureBuilder interface {
 	SetXAxisChannel(channel Channel) error
 	SetYAxisChannel(channel Channel) error
 
+	// Sets the channel to use for the vertex position before translation, must be 2 floats
+	// This replaces (XAxis * x + YAxis * y), so the axis channels are ignored when this is set
+	// Use together with VertexPositionChannel for per vertex effects
+	SetVertexChannel(channel Channel) error
+
+	// Sets the channel to use for the final color of the pixel, must be 4 floats from 0 to 1 - if not set the interpolated vertex color is used
+	SetFragmentColorChannel(channel Channel) error
+
 	// Used to "compile" the Procedure
 	Finish() (Procedure, error)
 }
@@ -214,19 +353,26 @@ type Operation interface {
 	RendererObject
 	// Supply an attribute for the procedure, this should be called as many times as the procedure has attributes
 	// Offset says where in the DataBuffer to start, and bufferIndex says what data from the DataBufferLayout to use
-	SetInstanceAttribute(channel Channel, buffer DataBuffer, offset uint32, bufferIndex uint16)
+	// Returns an error if the channel is not an attribute of the procedure, or the buffer has no layout or a different type at bufferIndex
+	SetInstanceAttribute(channel Channel, buffer DataBuffer, offset uint32, bufferIndex uint16) error
 
 	// Set a OperationChannel returned by ProcedureBuilder.AddOperationChannel()
-	SetChannelValue(channel Channel, data any)
+	// Returns an error if the channel is not an operation channel of the procedure, or data has the wrong type
+	SetChannelValue(channel Channel, data any) error
+
+	// Set a channel returned by ProcedureBuilder.AddTextureChannel()
+	// Returns an error if the channel is not a texture channel of the procedure
+	SetTexture(channel Channel, texture Texture) error
 
-	// Set sprite given buffer and index returned by SpriteBufferBuilder.AddSprite()
-	SetSprite(buffer SpriteBuffer, id uint32)
+	// Set sprite given buffer and index returned by SpriteBufferBuilder.AddSprite(), returns an error if there is no sprite with the id
+	SetSprite(buffer SpriteBuffer, id uint32) error
 
-	// Set the amount of sprites to draw, if this is longer than the avalible buffers the result is undefined
+	// Set the amount of sprites to draw, this is checked against the length of the buffers when drawing
 	SetAmount(amount uint32)
 
 	// Runs the operation and reads the buffers
-	DrawTo(target RenderTarget)
+	// Returns an error without drawing if a sprite or attribute is not set, or the buffers are shorter than the amount
+	DrawTo(target RenderTarget) error
 }
 
 type FragmentShader interface {
@@ -246,7 +392,9 @@ type Renderer interface {
 	MakeSpriteBufferBuilder() SpriteBufferBuilder
 	MakeRenderTarget(width, height uint16, multisample bool) RenderTarget
 	MakeProcedureBuilder() ProcedureBuilder
-	MakeOperation(procedure Procedure) Operation
+	MakeOperation(procedure Procedure) (Operation, error)
+	// The image is copied, so it can be changed afterwards, returns an error if the image is bigger than 65535 pixels in either direction
+	MakeTexture(img image.Image, options TextureOptions) (Texture, error)
 	// Only allows simple shaders for small effects, because the input data is not modifiable
 	// Expects a function that with the following parameters:
 	// in original: sampler2d
@@ -0,0 +1,326 @@
+// Scopes that free every RendererObject made through them at once, like everything used by a level
+package resources
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"runtime"
+	"strings"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+)
+
+// Implemented by objects that know how much GPU memory they use, like the ones from the gl renderer
+type sized interface {
+	MemorySize() uint64
+}
+
+type Stats struct {
+	DataBuffers   int
+	SpriteBuffers int
+	RenderTargets int
+	Procedures    int
+	Operations    int
+	Textures      int
+	// Other objects added with Track
+	Other int
+	// Estimated GPU memory in bytes, only objects that can report their size are counted
+	MemorySize uint64
+}
+
+func (s Stats) Objects() int {
+	return s.DataBuffers + s.SpriteBuffers + s.RenderTargets + s.Procedures + s.Operations + s.Textures + s.Other
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%v data buffers, %v sprite buffers, %v render targets, %v procedures, %v operations, %v textures, %v other, ~%v KiB",
+		s.DataBuffers, s.SpriteBuffers, s.RenderTargets, s.Procedures, s.Operations, s.Textures, s.Other, s.MemorySize/1024)
+}
+
+var _ render.Renderer = (*Scope)(nil)
+
+// A Scope is a render.Renderer, so it can be given to anything that makes objects, like presets or text.LoadFont
+// Objects made through the scope should be freed with Release or Free, not by calling Free on the object
+type Scope struct {
+	*scope
+	// Called for every object left if the scope is garbage collected before Free is called, defaults to log.Print
+	OnLeak func(message string)
+}
+
+// parents and children point to this instead of the Scope, since finalizers are not guaranteed to run for cycles
+type scope struct {
+	renderer render.Renderer
+	// in the order they were made
+	list     []tracked
+	children []*scope
+	// nil if the scope is not a child
+	parent *scope
+	// false after the child is freed, it is added to the parent again when used
+	attached bool
+}
+
+// An object in a scope
+type tracked struct {
+	object render.RendererObject
+	// file and line of the code that made the object, used when it is leaked
+	origin string
+}
+
+func NewScope(renderer render.Renderer) *Scope {
+	return newScope(&scope{renderer: renderer, list: make([]tracked, 0), children: make([]*scope, 0)})
+}
+
+func newScope(state *scope) *Scope {
+	s := &Scope{scope: state}
+	runtime.SetFinalizer(s, (*Scope).finalize)
+	return s
+}
+
+// objects are only garbage collected with their scope, so every object left is leaked
+func (s *Scope) finalize() {
+	// the parent frees it, or warns about it
+	if s.parent != nil && s.attached {
+		return
+	}
+	for _, t := range s.leaked(make([]tracked, 0)) {
+		message := fmt.Sprintf("Resource scope garbage collected without being freed, leaked %v made at %v", objectName(t.object), t.origin)
+		if s.OnLeak != nil {
+			s.OnLeak(message)
+		} else {
+			log.Print(message)
+		}
+	}
+}
+
+func (s *scope) leaked(res []tracked) []tracked {
+	res = append(res, s.list...)
+	for _, child := range s.children {
+		res = child.leaked(res)
+	}
+	return res
+}
+
+// Makes a scope that is freed when s is freed, but can also be freed by itself
+// Freeing the child removes it from s, it is added again if it is used afterwards
+func (s *Scope) Child() *Scope {
+	child := &scope{renderer: s.renderer, list: make([]tracked, 0), children: make([]*scope, 0), parent: s.scope, attached: true}
+	s.children = append(s.children, child)
+	s.attach()
+	res := newScope(child)
+	res.OnLeak = s.OnLeak
+	return res
+}
+
+// Adds an object made elsewhere, so it is freed with the scope
+func (s *Scope) Track(object render.RendererObject) {
+	s.track(object)
+}
+
+// the caller of the method calling track is saved as the origin
+func (s *scope) track(object render.RendererObject) {
+	origin := "unknown location"
+	if _, file, line, ok := runtime.Caller(2); ok {
+		origin = fmt.Sprintf("%v:%v", file, line)
+	}
+	s.list = append(s.list, tracked{object, origin})
+	s.attach()
+}
+
+// adds a freed child to its parent again
+func (s *scope) attach() {
+	if s.parent == nil || s.attached {
+		return
+	}
+	s.attached = true
+	s.parent.children = append(s.parent.children, s)
+	s.parent.attach()
+}
+
+// Frees an object in the scope, does nothing if the object is not in the scope
+func (s *Scope) Release(object render.RendererObject) {
+	for i, t := range s.list {
+		if t.object == object {
+			s.list = append(s.list[:i], s.list[i+1:]...)
+			object.Free()
+			return
+		}
+	}
+}
+
+// Frees every object in the scope and its children, in the opposite order of how they were made
+// The scope can still be used afterwards
+func (s *Scope) Free() {
+	s.free()
+	if s.parent != nil && s.attached {
+		siblings := s.parent.children
+		for i, child := range siblings {
+			if child == s.scope {
+				s.parent.children = append(siblings[:i], siblings[i+1:]...)
+				break
+			}
+		}
+	}
+	s.attached = false
+}
+
+// leaves s in its parent, since the parent removes every child itself
+func (s *scope) free() {
+	for _, child := range s.children {
+		child.free()
+		child.attached = false
+	}
+	s.children = s.children[:0]
+	for i := len(s.list) - 1; i >= 0; i-- {
+		s.list[i].object.Free()
+	}
+	s.list = s.list[:0]
+}
+
+// Counts the objects in the scope and its children
+func (s *Scope) Stats() Stats {
+	res := Stats{}
+	s.addStats(&res)
+	return res
+}
+
+func (s *scope) addStats(res *Stats) {
+	s.addOwnStats(res)
+	for _, child := range s.children {
+		child.addStats(res)
+	}
+}
+
+// only the objects of s, not its children
+func (s *scope) addOwnStats(res *Stats) {
+	for _, t := range s.list {
+		switch t.object.(type) {
+		case render.DataBuffer:
+			res.DataBuffers++
+		case render.SpriteBuffer:
+			res.SpriteBuffers++
+		case render.RenderTarget:
+			res.RenderTargets++
+		case render.Procedure:
+			res.Procedures++
+		case render.Operation:
+			res.Operations++
+		case render.Texture:
+			res.Textures++
+		default:
+			res.Other++
+		}
+		if obj, ok := t.object.(sized); ok {
+			res.MemorySize += obj.MemorySize()
+		}
+	}
+}
+
+func (s *Scope) MakeDataBuffer(static bool) render.DataBuffer {
+	buffer := s.renderer.MakeDataBuffer(static)
+	s.track(buffer)
+	return buffer
+}
+
+func (s *Scope) MakeSpriteBufferBuilder() render.SpriteBufferBuilder {
+	return &spriteBufferBuilder{s.renderer.MakeSpriteBufferBuilder(), s}
+}
+
+func (s *Scope) MakeRenderTarget(width, height uint16, multisample bool) render.RenderTarget {
+	target := s.renderer.MakeRenderTarget(width, height, multisample)
+	s.track(target)
+	return target
+}
+
+func (s *Scope) MakeProcedureBuilder() render.ProcedureBuilder {
+	return &procedureBuilder{s.renderer.MakeProcedureBuilder(), s}
+}
+
+func (s *Scope) MakeOperation(procedure render.Procedure) (render.Operation, error) {
+	operation, err := s.renderer.MakeOperation(procedure)
+	if err != nil {
+		return nil, err
+	}
+	s.track(operation)
+	return operation, nil
+}
+
+func (s *Scope) MakeTexture(img image.Image, options render.TextureOptions) (render.Texture, error) {
+	texture, err := s.renderer.MakeTexture(img, options)
+	if err != nil {
+		return nil, err
+	}
+	s.track(texture)
+	return texture, nil
+}
+
+// The renderer the scope makes objects with
+func (s *Scope) Unwrap() render.Renderer {
+	return s.renderer
+}
+
+// Not tracked, since it is owned by the window
+func (s *Scope) PrimaryRenderTarget() render.RenderTarget {
+	return s.renderer.PrimaryRenderTarget()
+}
+
+// Only MakeBuffer is different, so buffers are tracked
+type spriteBufferBuilder struct {
+	render.SpriteBufferBuilder
+	scope *Scope
+}
+
+func (b *spriteBufferBuilder) MakeBuffer(static bool) render.SpriteBuffer {
+	buffer := b.SpriteBufferBuilder.MakeBuffer(static)
+	b.scope.track(buffer)
+	return buffer
+}
+
+// Only Finish is different, so procedures are tracked
+type procedureBuilder struct {
+	render.ProcedureBuilder
+	scope *Scope
+}
+
+func (b *procedureBuilder) Finish() (render.Procedure, error) {
+	procedure, err := b.ProcedureBuilder.Finish()
+	if err != nil {
+		return nil, err
+	}
+	b.scope.track(procedure)
+	return procedure, nil
+}
+
+func objectName(object render.RendererObject) string {
+	switch object.(type) {
+	case render.DataBuffer:
+		return "data buffer"
+	case render.SpriteBuffer:
+		return "sprite buffer"
+	case render.RenderTarget:
+		return "render target"
+	case render.Procedure:
+		return "procedure"
+	case render.Operation:
+		return "operation"
+	case render.Texture:
+		return "texture"
+	}
+	return fmt.Sprintf("%T", object)
+}
+
+// Lists the stats of the scope and every child, indented by depth
+func (s *Scope) Report() string {
+	var sb strings.Builder
+	s.report(&sb, 0)
+	return sb.String()
+}
+
+func (s *scope) report(sb *strings.Builder, depth int) {
+	own := Stats{}
+	s.addOwnStats(&own)
+	sb.WriteString(strings.Repeat("  ", depth) + own.String() + "\n")
+	for _, child := range s.children {
+		child.report(sb, depth+1)
+	}
+}
@@ -0,0 +1,223 @@
+package resources
+
+import (
+	"errors"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+)
+
+// the methods that are not overridden panic, since they are nil
+type fakeRenderer struct {
+	render.Renderer
+	// names of the objects in the order they were freed
+	freed []string
+}
+
+func (r *fakeRenderer) object(name string, size uint64) *fakeObject {
+	return &fakeObject{name, size, &r.freed}
+}
+
+func (r *fakeRenderer) MakeDataBuffer(static bool) render.DataBuffer {
+	return &fakeDataBuffer{fakeObject: r.object("data", 100)}
+}
+
+func (r *fakeRenderer) MakeRenderTarget(width, height uint16, multisample bool) render.RenderTarget {
+	return &fakeRenderTarget{fakeObject: r.object("target", uint64(width)*uint64(height)*4)}
+}
+
+func (r *fakeRenderer) MakeTexture(img image.Image, options render.TextureOptions) (render.Texture, error) {
+	if img == nil {
+		return nil, errors.New("No image")
+	}
+	return &fakeTexture{fakeObject: r.object("texture", 0)}, nil
+}
+
+func (r *fakeRenderer) MakeProcedureBuilder() render.ProcedureBuilder {
+	return fakeBuilder{renderer: r}
+}
+
+type fakeBuilder struct {
+	render.ProcedureBuilder
+	renderer *fakeRenderer
+}
+
+func (b fakeBuilder) Finish() (render.Procedure, error) {
+	return &fakeProcedure{fakeObject: b.renderer.object("procedure", 0)}, nil
+}
+
+type fakeObject struct {
+	name  string
+	size  uint64
+	freed *[]string
+}
+
+func (o *fakeObject) free() {
+	*o.freed = append(*o.freed, o.name)
+}
+
+func (o *fakeObject) MemorySize() uint64 {
+	return o.size
+}
+
+type fakeDataBuffer struct {
+	render.DataBuffer
+	*fakeObject
+}
+
+func (b *fakeDataBuffer) Free() { b.free() }
+
+type fakeRenderTarget struct {
+	render.RenderTarget
+	*fakeObject
+}
+
+func (t *fakeRenderTarget) Free() { t.free() }
+
+type fakeTexture struct {
+	render.Texture
+	*fakeObject
+}
+
+func (t *fakeTexture) Free() { t.free() }
+
+type fakeProcedure struct {
+	render.Procedure
+	*fakeObject
+}
+
+func (p *fakeProcedure) Free() { p.free() }
+
+// objects made elsewhere, not one of the render types
+type other struct {
+	*fakeObject
+}
+
+func (o other) Free() { o.free() }
+
+func TestFree(t *testing.T) {
+	r := &fakeRenderer{}
+	s := NewScope(r)
+	s.MakeDataBuffer(false)
+	s.MakeRenderTarget(2, 2, false)
+	if _, err := s.MakeTexture(image.NewRGBA(image.Rect(0, 0, 1, 1)), render.TextureOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// failed objects are not tracked
+	if _, err := s.MakeTexture(nil, render.TextureOptions{}); err == nil {
+		t.Fatal("Made a texture without an image")
+	}
+	if _, err := s.MakeProcedureBuilder().Finish(); err != nil {
+		t.Fatal(err)
+	}
+	s.Track(other{r.object("other", 0)})
+	expected := Stats{DataBuffers: 1, RenderTargets: 1, Textures: 1, Procedures: 1, Other: 1, MemorySize: 100 + 16}
+	if stats := s.Stats(); stats != expected {
+		t.Fatalf("Stats are %v, expected %v", stats, expected)
+	}
+	if n := s.Stats().Objects(); n != 5 {
+		t.Fatalf("Scope has %v objects", n)
+	}
+	s.Free()
+	if order := []string{"other", "procedure", "texture", "target", "data"}; !reflect.DeepEqual(r.freed, order) {
+		t.Fatalf("Freed %v, expected %v", r.freed, order)
+	}
+	if stats := s.Stats(); stats != (Stats{}) {
+		t.Fatalf("Stats after Free are %v", stats)
+	}
+	// the scope can still be used, and objects are only freed once
+	s.MakeDataBuffer(true)
+	s.Free()
+	if len(r.freed) != 6 {
+		t.Fatalf("Freed %v", r.freed)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	r := &fakeRenderer{}
+	s := NewScope(r)
+	data := s.MakeDataBuffer(false)
+	s.MakeRenderTarget(1, 1, false)
+	s.Release(data)
+	if !reflect.DeepEqual(r.freed, []string{"data"}) {
+		t.Fatalf("Freed %v", r.freed)
+	}
+	if stats := s.Stats(); stats.DataBuffers != 0 || stats.RenderTargets != 1 {
+		t.Fatalf("Stats after Release are %v", stats)
+	}
+	// not in the scope anymore, or never in it
+	s.Release(data)
+	s.Release(&fakeDataBuffer{fakeObject: r.object("outside", 0)})
+	if len(r.freed) != 1 {
+		t.Fatalf("Freed %v", r.freed)
+	}
+	s.Free()
+	if !reflect.DeepEqual(r.freed, []string{"data", "target"}) {
+		t.Fatalf("Freed %v", r.freed)
+	}
+}
+
+func TestChild(t *testing.T) {
+	r := &fakeRenderer{}
+	parent := NewScope(r)
+	parent.MakeDataBuffer(false)
+	child := parent.Child()
+	child.MakeRenderTarget(1, 1, false)
+	grandchild := child.Child()
+	grandchild.Track(other{r.object("other", 0)})
+	if stats := parent.Stats(); stats.Objects() != 3 {
+		t.Fatalf("Parent stats are %v", stats)
+	}
+	if stats := child.Stats(); stats.Objects() != 2 {
+		t.Fatalf("Child stats are %v", stats)
+	}
+	// freeing the child detaches it, so the parent does not count or free it
+	child.Free()
+	if !reflect.DeepEqual(r.freed, []string{"other", "target"}) {
+		t.Fatalf("Freed %v", r.freed)
+	}
+	if stats := parent.Stats(); stats.Objects() != 1 {
+		t.Fatalf("Parent stats after freeing the child are %v", stats)
+	}
+	// using the grandchild adds it and the child to the parent again
+	grandchild.MakeRenderTarget(1, 1, false)
+	if stats := parent.Stats(); stats.Objects() != 2 || stats.RenderTargets != 1 {
+		t.Fatalf("Parent stats after using the grandchild are %v", stats)
+	}
+	if report := parent.Report(); strings.Count(report, "\n") != 3 || !strings.Contains(report, "    0 data buffers, 0 sprite buffers, 1 render targets") {
+		t.Fatalf("Report is %q", report)
+	}
+	parent.Free()
+	if !reflect.DeepEqual(r.freed, []string{"other", "target", "target", "data"}) {
+		t.Fatalf("Freed %v", r.freed)
+	}
+	if stats := grandchild.Stats(); stats.Objects() != 0 {
+		t.Fatalf("Grandchild stats after freeing the parent are %v", stats)
+	}
+}
+
+func TestLeak(t *testing.T) {
+	r := &fakeRenderer{}
+	s := NewScope(r)
+	messages := make([]string, 0)
+	s.OnLeak = func(message string) { messages = append(messages, message) }
+	child := s.Child()
+	child.MakeRenderTarget(1, 1, false)
+	// attached children are reported by the parent
+	child.finalize()
+	if len(messages) != 0 {
+		t.Fatalf("Attached child reported %v", messages)
+	}
+	s.finalize()
+	if len(messages) != 1 || !strings.Contains(messages[0], "render target made at") || !strings.Contains(messages[0], "resources_test.go") {
+		t.Fatalf("Leak messages are %v", messages)
+	}
+	s.Free()
+	s.finalize()
+	if len(messages) != 1 {
+		t.Fatalf("Freed scope reported %v", messages[1:])
+	}
+}
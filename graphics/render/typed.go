@@ -0,0 +1,205 @@
+package render
+
+// Go types that can be stored in a channel, these are the same types accepted by Operation.SetChannelValue
+type ShaderValue interface {
+	int32 | uint32 | float32 |
+		[2]int32 | [2]uint32 | [2]float32 |
+		[3]int32 | [3]uint32 | [3]float32 |
+		[4]int32 | [4]uint32 | [4]float32
+}
+
+// Returns the ShaderType matching T
+func ShaderTypeOf[T ShaderValue]() ShaderType {
+	var v T
+	switch any(v).(type) {
+	case int32:
+		return Type(ShaderInt, 1)
+	case uint32:
+		return Type(ShaderUnsignedInt, 1)
+	case float32:
+		return Type(ShaderFloat, 1)
+	case [2]int32:
+		return Type(ShaderInt, 2)
+	case [2]uint32:
+		return Type(ShaderUnsignedInt, 2)
+	case [2]float32:
+		return Type(ShaderFloat, 2)
+	case [3]int32:
+		return Type(ShaderInt, 3)
+	case [3]uint32:
+		return Type(ShaderUnsignedInt, 3)
+	case [3]float32:
+		return Type(ShaderFloat, 3)
+	case [4]int32:
+		return Type(ShaderInt, 4)
+	case [4]uint32:
+		return Type(ShaderUnsignedInt, 4)
+	default:
+		return Type(ShaderFloat, 4)
+	}
+}
+
+// Implemented by channels that wrap a channel made by a ProcedureBuilder, like the typed channels
+// Renderers should call UnwrapChannel before using a channel
+type ChannelWrapper interface {
+	Channel
+	Unwrap() Channel
+}
+
+// Returns the channel made by the ProcedureBuilder, no matter how many times it is wrapped
+func UnwrapChannel(channel Channel) Channel {
+	for {
+		wrapper, ok := channel.(ChannelWrapper)
+		if !ok {
+			return channel
+		}
+		channel = wrapper.Unwrap()
+	}
+}
+
+// A channel known to contain values of type T
+// Can be used anywhere a Channel is expected
+type TypedChannel[T ShaderValue] interface {
+	ChannelWrapper
+	// only used to carry T
+	value() T
+}
+
+type typedChannel[T ShaderValue] struct {
+	ChannelIdentifier
+	inner Channel
+}
+
+func (c typedChannel[T]) Unwrap() Channel {
+	return c.inner
+}
+
+func (c typedChannel[T]) value() T {
+	var v T
+	return v
+}
+
+// Typed version of the channel returned by ProcedureBuilder.AddIntermediateChannel
+type IntermediateChannel[T ShaderValue] struct {
+	typedChannel[T]
+}
+
+// Typed version of the channel returned by ProcedureBuilder.AddAttributeChannel
+type AttributeChannel[T ShaderValue] struct {
+	typedChannel[T]
+}
+
+// Typed version of the channel returned by ProcedureBuilder.AddOperationChannel
+type OperationChannel[T ShaderValue] struct {
+	typedChannel[T]
+}
+
+// Typed version of the channel returned by ProcedureBuilder.AddVaryingChannel
+type VaryingChannel[T ShaderValue] struct {
+	typedChannel[T]
+}
+
+// Generic methods are not a thing, so these are functions taking the builder
+
+// Same as ProcedureBuilder.AddIntermediateChannel, but the type is given by T
+func AddIntermediateChannelOf[T ShaderValue](builder ProcedureBuilder, expression string) IntermediateChannel[T] {
+	return IntermediateChannel[T]{typedChannel[T]{inner: builder.AddIntermediateChannel(ShaderTypeOf[T](), expression)}}
+}
+
+// Same as ProcedureBuilder.AddAttributeChannel, but the type is given by T
+func AddAttributeChannelOf[T ShaderValue](builder ProcedureBuilder) AttributeChannel[T] {
+	return AttributeChannel[T]{typedChannel[T]{inner: builder.AddAttributeChannel(ShaderTypeOf[T]())}}
+}
+
+// Same as ProcedureBuilder.AddOperationChannel, but the type is given by T
+func AddOperationChannelOf[T ShaderValue](builder ProcedureBuilder) OperationChannel[T] {
+	return OperationChannel[T]{typedChannel[T]{inner: builder.AddOperationChannel(ShaderTypeOf[T]())}}
+}
+
+// Same as ProcedureBuilder.AddVaryingChannel, but the type is given by T
+func AddVaryingChannelOf[T ShaderValue](builder ProcedureBuilder, expression string) VaryingChannel[T] {
+	return VaryingChannel[T]{typedChannel[T]{inner: builder.AddVaryingChannel(ShaderTypeOf[T](), expression)}}
+}
+
+// Same as ProcedureBuilder.AddFragmentIntermediateChannel, but the type is given by T
+func AddFragmentIntermediateChannelOf[T ShaderValue](builder ProcedureBuilder, expression string) IntermediateChannel[T] {
+	return IntermediateChannel[T]{typedChannel[T]{inner: builder.AddFragmentIntermediateChannel(ShaderTypeOf[T](), expression)}}
+}
+
+// Same as Operation.SetChannelValue, but the type of value is checked at compile time
+func SetChannelValue[T ShaderValue](operation Operation, channel OperationChannel[T], value T) error {
+	return operation.SetChannelValue(channel, value)
+}
+
+// Same as Operation.SetInstanceAttribute, but only accepts attribute channels
+func SetInstanceAttribute[T ShaderValue](operation Operation, channel AttributeChannel[T], buffer DataBuffer, offset uint32, bufferIndex uint16) error {
+	return operation.SetInstanceAttribute(channel, buffer, offset, bufferIndex)
+}
+
+// Functions with typed parameters, the Parameters of the Function are derived from the type parameters
+// Call and CallFragment check the types of the channels at compile time
+// Use the plain Function for more than 4 parameters
+
+type Function1[A ShaderValue] struct {
+	*Function
+}
+
+func NewFunction1[A ShaderValue](source, name string) Function1[A] {
+	return Function1[A]{NewFunction(source, name, ShaderTypeOf[A]())}
+}
+
+func (f Function1[A]) Call(builder ProcedureBuilder, a TypedChannel[A]) error {
+	return builder.CallFunction(f.Function, a)
+}
+
+func (f Function1[A]) CallFragment(builder ProcedureBuilder, a TypedChannel[A]) error {
+	return builder.CallFragmentFunction(f.Function, a)
+}
+
+type Function2[A, B ShaderValue] struct {
+	*Function
+}
+
+func NewFunction2[A, B ShaderValue](source, name string) Function2[A, B] {
+	return Function2[A, B]{NewFunction(source, name, ShaderTypeOf[A](), ShaderTypeOf[B]())}
+}
+
+func (f Function2[A, B]) Call(builder ProcedureBuilder, a TypedChannel[A], b TypedChannel[B]) error {
+	return builder.CallFunction(f.Function, a, b)
+}
+
+func (f Function2[A, B]) CallFragment(builder ProcedureBuilder, a TypedChannel[A], b TypedChannel[B]) error {
+	return builder.CallFragmentFunction(f.Function, a, b)
+}
+
+type Function3[A, B, C ShaderValue] struct {
+	*Function
+}
+
+func NewFunction3[A, B, C ShaderValue](source, name string) Function3[A, B, C] {
+	return Function3[A, B, C]{NewFunction(source, name, ShaderTypeOf[A](), ShaderTypeOf[B](), ShaderTypeOf[C]())}
+}
+
+func (f Function3[A, B, C]) Call(builder ProcedureBuilder, a TypedChannel[A], b TypedChannel[B], c TypedChannel[C]) error {
+	return builder.CallFunction(f.Function, a, b, c)
+}
+
+func (f Function3[A, B, C]) CallFragment(builder ProcedureBuilder, a TypedChannel[A], b TypedChannel[B], c TypedChannel[C]) error {
+	return builder.CallFragmentFunction(f.Function, a, b, c)
+}
+
+type Function4[A, B, C, D ShaderValue] struct {
+	*Function
+}
+
+func NewFunction4[A, B, C, D ShaderValue](source, name string) Function4[A, B, C, D] {
+	return Function4[A, B, C, D]{NewFunction(source, name, ShaderTypeOf[A](), ShaderTypeOf[B](), ShaderTypeOf[C](), ShaderTypeOf[D]())}
+}
+
+func (f Function4[A, B, C, D]) Call(builder ProcedureBuilder, a TypedChannel[A], b TypedChannel[B], c TypedChannel[C], d TypedChannel[D]) error {
+	return builder.CallFunction(f.Function, a, b, c, d)
+}
+
+func (f Function4[A, B, C, D]) CallFragment(builder ProcedureBuilder, a TypedChannel[A], b TypedChannel[B], c TypedChannel[C], d TypedChannel[D]) error {
+	return builder.CallFragmentFunction(f.Function, a, b, c, d)
+}
@@ -0,0 +1,383 @@
+package spritepack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+	"github.com/eliiasg/deltawing/internal/zstd"
+)
+
+/*
+	Format, every number is little endian:
+	magic "DWSP", version (uint16), compression (uint8, 0 is none, 1 is deflate and 2 is zstd)
+	then the body, compressed if compression is not 0:
+	amount of sprites (uint32), then for every sprite:
+		name, bounds (4 float32), pivot (2 float32), amount of tags (uint16), tags
+		amount of vertices (uint32), then x, y (float32), rgba (4 uint8) and layer (uint8) for every vertex
+		amount of indices (uint32), indices (uint32)
+		1 if the sprite has uvs and 0 otherwise (uint8), then u, v (float32) for every vertex
+		amount of data entries (uint16), then name, amount (uint8), amount of values (uint32) and values (float32) for every entry
+	1 if the pack is compiled and 0 otherwise (uint8), then vertices, indices and index positions
+		each as an amount (uint32) followed by that many uint32
+	strings are a length (uint16) followed by that many bytes
+*/
+
+// Bytes at the start of every pack
+const Magic = "DWSP"
+
+// Version written by WriteTo, Read can read this and every earlier version
+const Version uint16 = 1
+
+// slices are grown while reading instead of trusting amounts from the file, so broken files cannot allocate too much memory
+const maxPrealloc = 1 << 16
+
+// Writes the pack, with the compression of the pack
+func (p *Pack) WriteTo(writer io.Writer) (int64, error) {
+	body, err := p.body()
+	if err != nil {
+		return 0, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(Magic)
+	buf.Write(binary.LittleEndian.AppendUint16(nil, Version))
+	buf.WriteByte(byte(p.Compression))
+	switch p.Compression {
+	case CompressionNone:
+		buf.Write(body)
+	case CompressionDeflate:
+		w, err := flate.NewWriter(&buf, flate.BestCompression)
+		if err != nil {
+			return 0, err
+		}
+		w.Write(body)
+		if err := w.Close(); err != nil {
+			return 0, err
+		}
+	case CompressionZstd:
+		buf.Write(zstd.Compress(body))
+	default:
+		return 0, fmt.Errorf("Unknown compression %v", p.Compression)
+	}
+	return buf.WriteTo(writer)
+}
+
+func (p *Pack) body() ([]byte, error) {
+	w := &writer{}
+	w.uint32(uint32(len(p.Sprites)))
+	for _, sprite := range p.Sprites {
+		if err := sprite.Sprite.Validate(); err != nil {
+			return nil, fmt.Errorf("sprite %v: %w", sprite.Name, err)
+		}
+		if err := w.sprite(sprite); err != nil {
+			return nil, fmt.Errorf("sprite %v: %w", sprite.Name, err)
+		}
+	}
+	if p.Compiled == nil {
+		w.uint8(0)
+		return w.data, nil
+	}
+	w.uint8(1)
+	for _, part := range [][]uint32{p.Compiled.Vertices, p.Compiled.Indices, p.Compiled.IdxPositions} {
+		w.uint32(uint32(len(part)))
+		for _, v := range part {
+			w.uint32(v)
+		}
+	}
+	return w.data, nil
+}
+
+type writer struct {
+	data []byte
+}
+
+func (w *writer) uint8(v uint8) {
+	w.data = append(w.data, v)
+}
+
+func (w *writer) uint16(v uint16) {
+	w.data = binary.LittleEndian.AppendUint16(w.data, v)
+}
+
+func (w *writer) uint32(v uint32) {
+	w.data = binary.LittleEndian.AppendUint32(w.data, v)
+}
+
+func (w *writer) float32(v float32) {
+	w.uint32(math.Float32bits(v))
+}
+
+func (w *writer) string(s string) error {
+	if len(s) > math.MaxUint16 {
+		return fmt.Errorf("String of length %v is too long", len(s))
+	}
+	w.uint16(uint16(len(s)))
+	w.data = append(w.data, s...)
+	return nil
+}
+
+func (w *writer) sprite(s *Sprite) error {
+	if err := w.string(s.Name); err != nil {
+		return err
+	}
+	for _, v := range []float32{s.Bounds.MinX, s.Bounds.MinY, s.Bounds.MaxX, s.Bounds.MaxY, s.Pivot[0], s.Pivot[1]} {
+		w.float32(v)
+	}
+	if len(s.Tags) > math.MaxUint16 {
+		return errors.New("Too many tags")
+	}
+	w.uint16(uint16(len(s.Tags)))
+	for _, tag := range s.Tags {
+		if err := w.string(tag); err != nil {
+			return err
+		}
+	}
+	v := s.Sprite
+	w.uint32(uint32(len(v.Vertices)))
+	for i, vert := range v.Vertices {
+		w.float32(vert[0])
+		w.float32(vert[1])
+		c := v.Colors[i]
+		w.data = append(w.data, c.R, c.G, c.B, c.A, v.Layers[i])
+	}
+	w.uint32(uint32(len(v.Indices)))
+	for _, idx := range v.Indices {
+		w.uint32(idx)
+	}
+	if v.UVs == nil {
+		w.uint8(0)
+	} else {
+		w.uint8(1)
+		for i := range v.Vertices {
+			var uv [2]float32
+			if i < len(v.UVs) {
+				uv = v.UVs[i]
+			}
+			w.float32(uv[0])
+			w.float32(uv[1])
+		}
+	}
+	if len(v.Data) > math.MaxUint16 {
+		return errors.New("Too many data entries")
+	}
+	// sorted so the same sprite is always written the same way
+	names := make([]string, 0, len(v.Data))
+	for name := range v.Data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w.uint16(uint16(len(names)))
+	for _, name := range names {
+		data := v.Data[name]
+		if err := w.string(name); err != nil {
+			return err
+		}
+		w.uint8(data.Amount)
+		w.uint32(uint32(len(data.Values)))
+		for _, value := range data.Values {
+			w.float32(value)
+		}
+	}
+	return nil
+}
+
+// Reads a pack written by Pack.WriteTo, sprites are checked with VecSprite.Validate
+func Read(reader io.Reader) (*Pack, error) {
+	header := make([]byte, len(Magic)+3)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, readError(err)
+	}
+	if string(header[:len(Magic)]) != Magic {
+		return nil, errors.New("Invalid sprite pack: wrong magic bytes")
+	}
+	if version := binary.LittleEndian.Uint16(header[len(Magic):]); version > Version {
+		return nil, fmt.Errorf("Sprite pack has version %v, but only versions up to %v can be read", version, Version)
+	}
+	p := New()
+	p.Compression = Compression(header[len(header)-1])
+	var body io.Reader
+	switch p.Compression {
+	case CompressionNone:
+		body = reader
+	case CompressionDeflate:
+		decompressor := flate.NewReader(reader)
+		defer decompressor.Close()
+		body = decompressor
+	case CompressionZstd:
+		body = zstd.NewReader(reader)
+	default:
+		return nil, fmt.Errorf("Invalid sprite pack: unknown compression %v", p.Compression)
+	}
+	r := &packReader{r: bufio.NewReader(body)}
+	amount := r.uint32()
+	for i := uint32(0); i < amount && r.err == nil; i++ {
+		sprite := r.sprite()
+		if r.err != nil {
+			break
+		}
+		if err := sprite.Sprite.Validate(); err != nil {
+			return nil, fmt.Errorf("sprite %v: %w", sprite.Name, err)
+		}
+		if _, ok := p.ids[sprite.Name]; ok {
+			return nil, errors.New("Invalid sprite pack: sprite name used twice: " + sprite.Name)
+		}
+		p.ids[sprite.Name] = uint32(len(p.Sprites))
+		p.Sprites = append(p.Sprites, sprite)
+	}
+	if compiled := r.uint8(); compiled == 1 {
+		p.Compiled = &vecsprite.Compiled{Vertices: r.uint32s(), Indices: r.uint32s(), IdxPositions: r.uint32s()}
+	}
+	// the compressed stream must end properly, otherwise the file might be truncated
+	if p.Compression != CompressionNone && r.err == nil {
+		if _, err := r.r.ReadByte(); err != io.EOF {
+			r.err = err
+			if err == nil {
+				r.err = errors.New("Invalid sprite pack: data after the last sprite")
+			}
+		}
+	}
+	if r.err != nil {
+		return nil, readError(r.err)
+	}
+	if err := p.checkCompiled(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func readError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errors.New("Invalid sprite pack: unexpected end of input")
+	}
+	return err
+}
+
+// compiled data is uploaded as is, so it must not make the renderer read outside the buffers
+func (p *Pack) checkCompiled() error {
+	c := p.Compiled
+	if c == nil {
+		return nil
+	}
+	var verts, inds uint32
+	for _, sprite := range p.Sprites {
+		verts += uint32(len(sprite.Sprite.Vertices))
+		inds += uint32(len(sprite.Sprite.Indices))
+	}
+	if len(c.Vertices) != int(verts)*3 || len(c.Indices) != int(inds) || len(c.IdxPositions) != len(p.Sprites)+1 {
+		return errors.New("Invalid sprite pack: compiled sprites do not match the sprites")
+	}
+	for i := 1; i < len(c.IdxPositions); i++ {
+		if c.IdxPositions[i] < c.IdxPositions[i-1] || c.IdxPositions[i] > inds {
+			return errors.New("Invalid sprite pack: compiled index positions are out of range")
+		}
+	}
+	for _, idx := range c.Indices {
+		if idx >= verts {
+			return errors.New("Invalid sprite pack: compiled index is out of range")
+		}
+	}
+	return nil
+}
+
+// keeps the first error, so values can be read without checking every time
+type packReader struct {
+	r   *bufio.Reader
+	err error
+	buf [4]byte
+}
+
+func (r *packReader) read(n int) []byte {
+	if r.err != nil {
+		return r.buf[:n]
+	}
+	_, r.err = io.ReadFull(r.r, r.buf[:n])
+	return r.buf[:n]
+}
+
+func (r *packReader) uint8() uint8 {
+	return r.read(1)[0]
+}
+
+func (r *packReader) uint16() uint16 {
+	return binary.LittleEndian.Uint16(r.read(2))
+}
+
+func (r *packReader) uint32() uint32 {
+	return binary.LittleEndian.Uint32(r.read(4))
+}
+
+func (r *packReader) float32() float32 {
+	return math.Float32frombits(r.uint32())
+}
+
+func (r *packReader) string() string {
+	length := int(r.uint16())
+	if r.err != nil {
+		return ""
+	}
+	data := make([]byte, length)
+	_, r.err = io.ReadFull(r.r, data)
+	return string(data)
+}
+
+func (r *packReader) uint32s() []uint32 {
+	amount := r.uint32()
+	res := make([]uint32, 0, min(amount, maxPrealloc))
+	for i := uint32(0); i < amount && r.err == nil; i++ {
+		res = append(res, r.uint32())
+	}
+	return res
+}
+
+func (r *packReader) sprite() *Sprite {
+	s := &Sprite{Name: r.string(), Sprite: &vecsprite.VecSprite{}}
+	s.Bounds = Bounds{r.float32(), r.float32(), r.float32(), r.float32()}
+	s.Pivot = [2]float32{r.float32(), r.float32()}
+	tags := r.uint16()
+	s.Tags = make([]string, 0, tags)
+	for i := uint16(0); i < tags && r.err == nil; i++ {
+		s.Tags = append(s.Tags, r.string())
+	}
+	v := s.Sprite
+	amount := r.uint32()
+	prealloc := min(amount, maxPrealloc)
+	v.Vertices = make([][2]float32, 0, prealloc)
+	v.Colors = make([]color.Color, 0, prealloc)
+	v.Layers = make([]uint8, 0, prealloc)
+	for i := uint32(0); i < amount && r.err == nil; i++ {
+		v.Vertices = append(v.Vertices, [2]float32{r.float32(), r.float32()})
+		rgba := r.read(4)
+		v.Colors = append(v.Colors, color.FromRGBA(rgba[0], rgba[1], rgba[2], rgba[3]))
+		v.Layers = append(v.Layers, r.uint8())
+	}
+	v.Indices = r.uint32s()
+	if r.uint8() == 1 {
+		v.UVs = make([][2]float32, 0, len(v.Vertices))
+		for range v.Vertices {
+			v.UVs = append(v.UVs, [2]float32{r.float32(), r.float32()})
+		}
+	}
+	entries := r.uint16()
+	if entries > 0 {
+		v.Data = make(map[string]vecsprite.VertexData, entries)
+	}
+	for i := uint16(0); i < entries && r.err == nil; i++ {
+		name := r.string()
+		data := vecsprite.VertexData{Amount: r.uint8()}
+		values := r.uint32()
+		data.Values = make([]float32, 0, min(values, maxPrealloc))
+		for j := uint32(0); j < values && r.err == nil; j++ {
+			data.Values = append(data.Values, r.float32())
+		}
+		v.Data[name] = data
+	}
+	return s
+}
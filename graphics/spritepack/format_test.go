@@ -0,0 +1,129 @@
+package spritepack
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+func testPack(compression Compression, compiled bool) *Pack {
+	p := New()
+	p.Compression = compression
+	red := color.FromRGBA(255, 0, 0, 255)
+	square := p.Add("square", &vecsprite.VecSprite{
+		Vertices: [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+		Colors:   []color.Color{red, red, red, red},
+		Layers:   []uint8{0, 0, 1, 1},
+		Indices:  []uint32{0, 1, 2, 0, 2, 3},
+		UVs:      [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+	})
+	square.Pivot = [2]float32{0.5, 0.5}
+	square.Tags = append(square.Tags, "block", "solid")
+	blue := color.FromRGBA(0, 0, 255, 128)
+	p.Add("triangle", &vecsprite.VecSprite{
+		Vertices: [][2]float32{{-1, 0}, {1, 0}, {0, 2}},
+		Colors:   []color.Color{blue, blue, blue},
+		Layers:   []uint8{3, 3, 3},
+		Indices:  []uint32{0, 1, 2},
+		Data: map[string]vecsprite.VertexData{
+			"glow":   {Amount: 1, Values: []float32{0, 0.5, 1}},
+			"offset": {Amount: 2, Values: []float32{1, 2, 3, 4, 5, 6}},
+		},
+	})
+	if compiled {
+		p.Compile()
+	}
+	return p
+}
+
+func write(t *testing.T, p *Pack) []byte {
+	var buf bytes.Buffer
+	if _, err := p.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+var roundTrips = []struct {
+	name        string
+	compression Compression
+	compiled    bool
+}{
+	{"none", CompressionNone, false},
+	{"deflate", CompressionDeflate, false},
+	{"compiled", CompressionNone, true},
+	{"compiled deflate", CompressionDeflate, true},
+	{"zstd", CompressionZstd, false},
+	{"compiled zstd", CompressionZstd, true},
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, test := range roundTrips {
+		t.Run(test.name, func(t *testing.T) {
+			p := testPack(test.compression, test.compiled)
+			read, err := Read(bytes.NewReader(write(t, p)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p, read) {
+				t.Fatalf("Read %+v, wrote %+v", read, p)
+			}
+			if id, ok := read.ID("triangle"); !ok || id != 1 {
+				t.Fatalf("ID of triangle is %v, %v", id, ok)
+			}
+		})
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	for _, test := range roundTrips {
+		t.Run(test.name, func(t *testing.T) {
+			data := write(t, testPack(test.compression, test.compiled))
+			for i := 0; i < len(data); i++ {
+				if _, err := Read(bytes.NewReader(data[:i])); err == nil {
+					t.Fatalf("Read %v of %v bytes without an error", i, len(data))
+				}
+			}
+		})
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	valid := write(t, testPack(CompressionNone, true))
+	withByte := func(i int, b byte) []byte {
+		data := bytes.Clone(valid)
+		data[i] = b
+		return data
+	}
+	// a changed byte in the middle of the compressed body fails the checksum
+	zstd := write(t, testPack(CompressionZstd, true))
+	zstd[len(zstd)/2] ^= 0xff
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"magic", withByte(0, 'X'), "wrong magic"},
+		{"version", withByte(4, 2), "version 2"},
+		{"not zstd", withByte(6, byte(CompressionZstd)), "zstd"},
+		{"zstd checksum", zstd, "zstd"},
+		{"compression", withByte(6, 3), "unknown compression"},
+		// the last index of the compiled indices
+		{"compiled index", withByte(len(valid)-4*4-4, 255), "out of range"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Read(bytes.NewReader(test.data))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Error %q does not contain %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Many named sprites in a single file, with metadata and the sprites already compiled for the gl renderer
+//
+// Usage:
+//
+//	pack, err := spritepack.Read(file)
+//	builder := renderer.MakeSpriteBufferBuilder()
+//	first := pack.AddTo(builder)
+//	buffer := builder.MakeBuffer(true)
+//	id, ok := pack.ID("player")
+//	operation.SetSprite(buffer, first+id)
+package spritepack
+
+import (
+	"math"
+
+	"github.com/eliiasg/deltawing/graphics/render"
+	"github.com/eliiasg/deltawing/graphics/render/gl/util"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+type Compression uint8
+
+const (
+	CompressionNone Compression = iota
+	CompressionDeflate
+	// Written with a simple encoder that only uses the predefined tables, so it can compress less than deflate
+	CompressionZstd
+)
+
+type Bounds struct {
+	MinX, MinY, MaxX, MaxY float32
+}
+
+func (b Bounds) Width() float32 {
+	return b.MaxX - b.MinX
+}
+
+func (b Bounds) Height() float32 {
+	return b.MaxY - b.MinY
+}
+
+// Bounds of the vertices of the sprite, zero if it has none
+func BoundsOf(sprite *vecsprite.VecSprite) Bounds {
+	if len(sprite.Vertices) == 0 {
+		return Bounds{}
+	}
+	b := Bounds{math.MaxFloat32, math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32}
+	for _, vert := range sprite.Vertices {
+		b.MinX = min(b.MinX, vert[0])
+		b.MinY = min(b.MinY, vert[1])
+		b.MaxX = max(b.MaxX, vert[0])
+		b.MaxY = max(b.MaxY, vert[1])
+	}
+	return b
+}
+
+type Sprite struct {
+	Name   string
+	Sprite *vecsprite.VecSprite
+	// Set by Pack.Add, but not updated if the sprite is changed afterwards
+	Bounds Bounds
+	// Point the sprite is placed and rotated around, in the coordinates of the vertices - not used by the renderer
+	Pivot [2]float32
+	Tags  []string
+}
+
+// Returns true if the sprite has the tag
+func (s *Sprite) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+type Pack struct {
+	// In the order they were added, the index is the id used by ID
+	Sprites []*Sprite
+	// Compression used by WriteTo, set to the compression of the file by Read
+	Compression Compression
+	// The sprites compiled by Compile, written with the pack and used by AddTo - nil if not compiled
+	// Cleared by Add, since it would be missing the new sprite
+	Compiled *vecsprite.Compiled
+
+	ids map[string]uint32
+}
+
+func New() *Pack {
+	return &Pack{
+		Sprites:     make([]*Sprite, 0),
+		Compression: CompressionDeflate,
+		ids:         make(map[string]uint32),
+	}
+}
+
+// Adds a sprite with bounds from its vertices, using the name of an existing sprite replaces it
+// The returned Sprite can be used to set the pivot and tags
+func (p *Pack) Add(name string, sprite *vecsprite.VecSprite) *Sprite {
+	s := &Sprite{Name: name, Sprite: sprite, Bounds: BoundsOf(sprite), Tags: make([]string, 0)}
+	p.Compiled = nil
+	if id, ok := p.ids[name]; ok {
+		p.Sprites[id] = s
+		return s
+	}
+	p.ids[name] = uint32(len(p.Sprites))
+	p.Sprites = append(p.Sprites, s)
+	return s
+}
+
+// Returns the id of the sprite with the name, this is the index in Sprites and the offset from the id returned by AddTo
+func (p *Pack) ID(name string) (uint32, bool) {
+	id, ok := p.ids[name]
+	return id, ok
+}
+
+// Returns the sprite with the name, or nil if there is none
+func (p *Pack) Sprite(name string) *Sprite {
+	if id, ok := p.ids[name]; ok {
+		return p.Sprites[id]
+	}
+	return nil
+}
+
+// Returns every sprite with the tag, in the order they were added
+func (p *Pack) Tagged(tag string) []*Sprite {
+	res := make([]*Sprite, 0)
+	for _, sprite := range p.Sprites {
+		if sprite.HasTag(tag) {
+			res = append(res, sprite)
+		}
+	}
+	return res
+}
+
+func (p *Pack) vecSprites() []*vecsprite.VecSprite {
+	res := make([]*vecsprite.VecSprite, 0, len(p.Sprites))
+	for _, sprite := range p.Sprites {
+		res = append(res, sprite.Sprite)
+	}
+	return res
+}
+
+// Compiles the sprites, so they are saved compiled and AddTo does not have to compile them
+// Should be called again if a sprite is modified
+func (p *Pack) Compile() {
+	verts, inds, idxPositions := util.CompileVecSpriteBuffer(p.vecSprites())
+	p.Compiled = &vecsprite.Compiled{Vertices: verts, Indices: inds, IdxPositions: idxPositions}
+}
+
+// Adds every sprite to the builder, and returns the id of the first one - add the result of ID to get the id of a sprite
+// The compiled sprites are used if the pack is compiled and the builder is empty
+func (p *Pack) AddTo(builder render.SpriteBufferBuilder) uint32 {
+	if p.Compiled != nil {
+		return builder.AddCompiled(p.vecSprites(), p.Compiled)
+	}
+	first := uint32(0)
+	for i, sprite := range p.Sprites {
+		id := builder.AddSprite(sprite.Sprite)
+		if i == 0 {
+			first = id
+		}
+	}
+	return first
+}
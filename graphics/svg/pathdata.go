@@ -0,0 +1,228 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/eliiasg/deltawing/graphics/path"
+)
+
+// reads numbers and flags from attributes like d and points, where separators are optional in many places
+type scanner struct {
+	s   string
+	pos int
+}
+
+func (s *scanner) skipSeparators() {
+	for s.pos < len(s.s) {
+		switch s.s[s.pos] {
+		case ' ', '\t', '\n', '\r', ',':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *scanner) done() bool {
+	s.skipSeparators()
+	return s.pos >= len(s.s)
+}
+
+// true if the next thing is a number, used to repeat commands without repeating the letter
+func (s *scanner) numberNext() bool {
+	if s.done() {
+		return false
+	}
+	c := s.s[s.pos]
+	return c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9')
+}
+
+func (s *scanner) number() (float64, error) {
+	s.skipSeparators()
+	start := s.pos
+	if s.pos < len(s.s) && (s.s[s.pos] == '-' || s.s[s.pos] == '+') {
+		s.pos++
+	}
+	// numbers like 1.5.5 are two numbers, 1.5 and .5
+	dot := false
+	digits := false
+	for s.pos < len(s.s) {
+		c := s.s[s.pos]
+		if c >= '0' && c <= '9' {
+			digits = true
+		} else if c == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+		s.pos++
+	}
+	if digits && s.pos < len(s.s) && (s.s[s.pos] == 'e' || s.s[s.pos] == 'E') {
+		exp := s.pos + 1
+		if exp < len(s.s) && (s.s[exp] == '-' || s.s[exp] == '+') {
+			exp++
+		}
+		if exp < len(s.s) && s.s[exp] >= '0' && s.s[exp] <= '9' {
+			s.pos = exp
+			for s.pos < len(s.s) && s.s[s.pos] >= '0' && s.s[s.pos] <= '9' {
+				s.pos++
+			}
+		}
+	}
+	if !digits {
+		return 0, fmt.Errorf("Expected number at position %v", start)
+	}
+	return strconv.ParseFloat(s.s[start:s.pos], 64)
+}
+
+// arc flags are a single 0 or 1, and are often written without separators like 1 1 0 01 5 5
+func (s *scanner) flag() (bool, error) {
+	s.skipSeparators()
+	if s.pos < len(s.s) && (s.s[s.pos] == '0' || s.s[s.pos] == '1') {
+		s.pos++
+		return s.s[s.pos-1] == '1', nil
+	}
+	return false, fmt.Errorf("Expected flag at position %v", s.pos)
+}
+
+func (s *scanner) numbers(amount int) ([]float64, error) {
+	res := make([]float64, amount)
+	for i := range res {
+		v, err := s.number()
+		if err != nil {
+			return nil, err
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+
+// Parses the d attribute of a path element
+func parsePathData(d string) (*path.Path, error) {
+	p := path.New()
+	s := &scanner{s: d}
+	var cur, start, lastControl [2]float64
+	// the previous command, for the reflected control points of S and T
+	var prev byte
+	var cmd byte
+	for !s.done() {
+		if !s.numberNext() {
+			cmd = s.s[s.pos]
+			s.pos++
+		} else if cmd == 0 {
+			return nil, fmt.Errorf("Path data must start with a command")
+		}
+		rel := cmd >= 'a' && cmd <= 'z'
+		upper := cmd &^ 0x20
+		offset := func(x, y float64) [2]float64 {
+			if rel {
+				return [2]float64{cur[0] + x, cur[1] + y}
+			}
+			return [2]float64{x, y}
+		}
+		var err error
+		var v []float64
+		switch upper {
+		case 'M':
+			if v, err = s.numbers(2); err == nil {
+				cur = offset(v[0], v[1])
+				start = cur
+				p.MoveTo(float32(cur[0]), float32(cur[1]))
+				// more coordinates after M are lines
+				if rel {
+					cmd = 'l'
+				} else {
+					cmd = 'L'
+				}
+			}
+		case 'L':
+			if v, err = s.numbers(2); err == nil {
+				cur = offset(v[0], v[1])
+				p.LineTo(float32(cur[0]), float32(cur[1]))
+			}
+		case 'H':
+			if v, err = s.numbers(1); err == nil {
+				if rel {
+					cur[0] += v[0]
+				} else {
+					cur[0] = v[0]
+				}
+				p.LineTo(float32(cur[0]), float32(cur[1]))
+			}
+		case 'V':
+			if v, err = s.numbers(1); err == nil {
+				if rel {
+					cur[1] += v[0]
+				} else {
+					cur[1] = v[0]
+				}
+				p.LineTo(float32(cur[0]), float32(cur[1]))
+			}
+		case 'C', 'S':
+			c1 := cur
+			if upper == 'C' {
+				if v, err = s.numbers(6); err == nil {
+					c1 = offset(v[0], v[1])
+					v = v[2:]
+				}
+			} else {
+				if prev == 'C' || prev == 'S' {
+					c1 = [2]float64{2*cur[0] - lastControl[0], 2*cur[1] - lastControl[1]}
+				}
+				v, err = s.numbers(4)
+			}
+			if err == nil {
+				c2, end := offset(v[0], v[1]), offset(v[2], v[3])
+				p.CubicTo(float32(c1[0]), float32(c1[1]), float32(c2[0]), float32(c2[1]), float32(end[0]), float32(end[1]))
+				lastControl, cur = c2, end
+			}
+		case 'Q', 'T':
+			c := cur
+			if upper == 'Q' {
+				if v, err = s.numbers(4); err == nil {
+					c = offset(v[0], v[1])
+					v = v[2:]
+				}
+			} else {
+				if prev == 'Q' || prev == 'T' {
+					c = [2]float64{2*cur[0] - lastControl[0], 2*cur[1] - lastControl[1]}
+				}
+				v, err = s.numbers(2)
+			}
+			if err == nil {
+				end := offset(v[0], v[1])
+				p.QuadTo(float32(c[0]), float32(c[1]), float32(end[0]), float32(end[1]))
+				lastControl, cur = c, end
+			}
+		case 'A':
+			var r []float64
+			var large, sweep bool
+			if r, err = s.numbers(3); err != nil {
+				break
+			}
+			if large, err = s.flag(); err != nil {
+				break
+			}
+			if sweep, err = s.flag(); err != nil {
+				break
+			}
+			if v, err = s.numbers(2); err == nil {
+				end := offset(v[0], v[1])
+				p.ArcTo(float32(r[0]), float32(r[1]), float32(r[2]*math.Pi/180), large, sweep, float32(end[0]), float32(end[1]))
+				cur = end
+			}
+		case 'Z':
+			p.Close()
+			cur = start
+		default:
+			return nil, fmt.Errorf("Unknown path command %q", cmd)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid path data: %w", err)
+		}
+		prev = upper
+	}
+	return p, nil
+}
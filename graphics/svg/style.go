@@ -0,0 +1,322 @@
+package svg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/path"
+)
+
+// fill or stroke
+type paint struct {
+	none bool
+	// the color property is used instead of rgb
+	current bool
+	rgb     [3]uint8
+}
+
+// properties inherited by children, see https://www.w3.org/TR/SVG/painting.html
+type style struct {
+	fill, stroke  paint
+	color         [3]uint8
+	fillOpacity   float64
+	strokeOpacity float64
+	fillRule      path.FillRule
+	strokeWidth   float64
+	join          path.Join
+	cap           path.Cap
+	miterLimit    float64
+	dashes        []float32
+	dashOffset    float64
+	hidden        bool
+	// opacity is not inherited, but children are multiplied by it, which is the same when shapes do not overlap
+	opacity float64
+}
+
+func defaultStyle() style {
+	return style{
+		fill:          paint{},
+		stroke:        paint{none: true},
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		strokeWidth:   1,
+		miterLimit:    4,
+		opacity:       1,
+	}
+}
+
+// the properties from attributes and the style attribute, the style attribute has priority
+func properties(attrs map[string]string) map[string]string {
+	res := make(map[string]string)
+	for name, value := range attrs {
+		res[name] = strings.TrimSpace(value)
+	}
+	for _, decl := range strings.Split(attrs["style"], ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		res[strings.TrimSpace(name)] = value
+	}
+	return res
+}
+
+// applies the properties of an element to the style of its parent, unknown values are ignored like in browsers
+func (s style) apply(props map[string]string) style {
+	set := func(name string, f func(value string) error) {
+		if value, ok := props[name]; ok && value != "inherit" && value != "" {
+			// invalid values keep the inherited value
+			f(value)
+		}
+	}
+	set("color", func(v string) error {
+		c, err := parseColor(v, s.color)
+		if err == nil {
+			s.color = c
+		}
+		return err
+	})
+	set("fill", func(v string) (err error) {
+		s.fill, err = parsePaint(v, s.fill)
+		return
+	})
+	set("stroke", func(v string) (err error) {
+		s.stroke, err = parsePaint(v, s.stroke)
+		return
+	})
+	set("fill-opacity", func(v string) (err error) {
+		s.fillOpacity, err = parseOpacity(v, s.fillOpacity)
+		return
+	})
+	set("stroke-opacity", func(v string) (err error) {
+		s.strokeOpacity, err = parseOpacity(v, s.strokeOpacity)
+		return
+	})
+	set("fill-rule", func(v string) error {
+		switch v {
+		case "nonzero":
+			s.fillRule = path.NonZero
+		case "evenodd":
+			s.fillRule = path.EvenOdd
+		}
+		return nil
+	})
+	set("stroke-width", func(v string) error {
+		w, err := parseLength(v, 1)
+		if err == nil && w >= 0 {
+			s.strokeWidth = w
+		}
+		return err
+	})
+	set("stroke-linejoin", func(v string) error {
+		switch v {
+		case "miter", "miter-clip", "arcs":
+			s.join = path.JoinMiter
+		case "round":
+			s.join = path.JoinRound
+		case "bevel":
+			s.join = path.JoinBevel
+		}
+		return nil
+	})
+	set("stroke-linecap", func(v string) error {
+		switch v {
+		case "butt":
+			s.cap = path.CapButt
+		case "round":
+			s.cap = path.CapRound
+		case "square":
+			s.cap = path.CapSquare
+		}
+		return nil
+	})
+	set("stroke-miterlimit", func(v string) error {
+		l, err := strconv.ParseFloat(v, 64)
+		if err == nil && l >= 1 {
+			s.miterLimit = l
+		}
+		return err
+	})
+	set("stroke-dasharray", func(v string) error {
+		if v == "none" {
+			s.dashes = nil
+			return nil
+		}
+		dashes := make([]float32, 0)
+		for _, d := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r' }) {
+			l, err := parseLength(d, 1)
+			if err != nil || l < 0 {
+				// invalid dash arrays are ignored like in browsers
+				return err
+			}
+			dashes = append(dashes, float32(l))
+		}
+		s.dashes = dashes
+		return nil
+	})
+	set("stroke-dashoffset", func(v string) error {
+		o, err := parseLength(v, 1)
+		if err == nil {
+			s.dashOffset = o
+		}
+		return err
+	})
+	// not inherited
+	s.opacity = 1
+	if v, ok := props["opacity"]; ok {
+		s.opacity, _ = parseOpacity(v, 1)
+	}
+	if props["display"] == "none" || props["visibility"] == "hidden" || props["visibility"] == "collapse" {
+		s.hidden = true
+	} else if props["visibility"] == "visible" {
+		s.hidden = false
+	}
+	return s
+}
+
+func (s style) paintColor(p paint, opacity float64) color.Color {
+	rgb := p.rgb
+	if p.current {
+		rgb = s.color
+	}
+	return color.FromRGBA(rgb[0], rgb[1], rgb[2], uint8(math.Round(opacity*255)))
+}
+
+func parseOpacity(v string, old float64) (float64, error) {
+	percent := strings.HasSuffix(v, "%")
+	o, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+	if err != nil {
+		return old, err
+	}
+	if percent {
+		o /= 100
+	}
+	return min(max(o, 0), 1), nil
+}
+
+func parsePaint(v string, old paint) (paint, error) {
+	switch v {
+	case "none", "transparent":
+		return paint{none: true}, nil
+	case "currentColor":
+		return paint{current: true}, nil
+	}
+	if strings.HasPrefix(v, "url(") {
+		// gradients and patterns are not supported, but a fallback color can be given after the url
+		_, fallback, ok := strings.Cut(v, ")")
+		fallback = strings.TrimSpace(fallback)
+		if !ok || fallback == "" {
+			return paint{none: true}, nil
+		}
+		return parsePaint(fallback, old)
+	}
+	rgb, err := parseColor(v, old.rgb)
+	if err != nil {
+		return old, err
+	}
+	return paint{rgb: rgb}, nil
+}
+
+func parseColor(v string, old [3]uint8) ([3]uint8, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if strings.HasPrefix(v, "#") {
+		hex := v[1:]
+		// #rgb is the same as #rrggbb
+		if len(hex) == 3 || len(hex) == 4 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) == 8 {
+			hex = hex[:6]
+		}
+		n, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return old, fmt.Errorf("Invalid color %v", v)
+		}
+		return [3]uint8{uint8(n >> 16), uint8(n >> 8), uint8(n)}, nil
+	}
+	if strings.HasPrefix(v, "rgb(") || strings.HasPrefix(v, "rgba(") {
+		_, args, _ := strings.Cut(v, "(")
+		args = strings.TrimSuffix(args, ")")
+		parts := strings.FieldsFunc(args, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '/'
+		})
+		if len(parts) < 3 {
+			return old, fmt.Errorf("Invalid color %v", v)
+		}
+		var res [3]uint8
+		for i := range res {
+			c, err := parseOpacity(parts[i], 0)
+			if err != nil {
+				return old, fmt.Errorf("Invalid color %v", v)
+			}
+			// parseOpacity clamps to 0-1 and handles percentages, numbers without % are from 0 to 255
+			if !strings.HasSuffix(parts[i], "%") {
+				n, _ := strconv.ParseFloat(parts[i], 64)
+				c = min(max(n, 0), 255) / 255
+			}
+			res[i] = uint8(math.Round(c * 255))
+		}
+		return res, nil
+	}
+	if rgb, ok := namedColors[v]; ok {
+		return rgb, nil
+	}
+	return old, errors.New("Unknown color " + v)
+}
+
+// the most common CSS colors
+var namedColors = map[string][3]uint8{
+	"black":     {0, 0, 0},
+	"white":     {255, 255, 255},
+	"red":       {255, 0, 0},
+	"lime":      {0, 255, 0},
+	"green":     {0, 128, 0},
+	"blue":      {0, 0, 255},
+	"yellow":    {255, 255, 0},
+	"cyan":      {0, 255, 255},
+	"aqua":      {0, 255, 255},
+	"magenta":   {255, 0, 255},
+	"fuchsia":   {255, 0, 255},
+	"silver":    {192, 192, 192},
+	"gray":      {128, 128, 128},
+	"grey":      {128, 128, 128},
+	"darkgray":  {169, 169, 169},
+	"darkgrey":  {169, 169, 169},
+	"lightgray": {211, 211, 211},
+	"lightgrey": {211, 211, 211},
+	"maroon":    {128, 0, 0},
+	"olive":     {128, 128, 0},
+	"purple":    {128, 0, 128},
+	"teal":      {0, 128, 128},
+	"navy":      {0, 0, 128},
+	"orange":    {255, 165, 0},
+	"pink":      {255, 192, 203},
+	"brown":     {165, 42, 42},
+	"gold":      {255, 215, 0},
+	"indigo":    {75, 0, 130},
+	"violet":    {238, 130, 238},
+}
+
+// units are converted to pixels at 96 dpi like in browsers, percentages are of ref
+func parseLength(v string, ref float64) (float64, error) {
+	v = strings.TrimSpace(v)
+	units := map[string]float64{"px": 1, "pt": 96.0 / 72, "pc": 16, "mm": 96 / 25.4, "cm": 96 / 2.54, "in": 96, "%": ref / 100}
+	scale := 1.0
+	for unit, s := range units {
+		if strings.HasSuffix(v, unit) {
+			v = strings.TrimSuffix(v, unit)
+			scale = s
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid length %v", v)
+	}
+	return n * scale, nil
+}
@@ -0,0 +1,403 @@
+// Imports SVG files as VecSprites, supporting paths, basic shapes, groups with transforms, solid fills and strokes
+// Text, gradients, patterns, clipping, masks and use elements are not supported, gradients use their fallback color if there is one
+// The top left of the SVG is at (0, 0) in the sprite, and since y is up in sprites the drawing is below the x axis
+package svg
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/path"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+type Options struct {
+	// Maximum distance between curves and the lines they are turned into, in pixels of the SVG - path.DefaultTolerance if 0
+	Tolerance float32
+	// Multiplied with the size of the SVG, 1 if 0
+	Scale float32
+}
+
+// elements that are never drawn, or not supported, their children are skipped
+var skipped = map[string]bool{
+	"defs": true, "clipPath": true, "mask": true, "symbol": true, "pattern": true, "marker": true,
+	"linearGradient": true, "radialGradient": true, "style": true, "script": true,
+	"title": true, "desc": true, "metadata": true, "text": true, "foreignObject": true,
+}
+
+type importer struct {
+	options Options
+	sprite  *vecsprite.VecSprite
+	// next layer to use, every fill and stroke gets its own layer in document order so later shapes are on top
+	layer int
+	// size of the viewport, for percentages
+	width, height float64
+}
+
+// Reads an SVG document and tessellates every shape into a sprite
+// Every fill and every stroke gets a layer in document order, so at most 256 can be drawn
+// Opacity is stored in the alpha of the colors
+func Import(reader io.Reader, options Options) (*vecsprite.VecSprite, error) {
+	if options.Scale == 0 {
+		options.Scale = 1
+	}
+	imp := &importer{
+		options: options,
+		sprite:  &vecsprite.VecSprite{},
+	}
+	decoder := xml.NewDecoder(reader)
+	// styles, transforms and viewport sizes of the open elements
+	type frame struct {
+		style     style
+		transform path.Matrix
+		// size of the viewport for the children, only changed by svg elements
+		width, height float64
+	}
+	stack := make([]frame, 0)
+	// closes the top element, and goes back to the viewport of its parent
+	pop := func() {
+		stack = stack[:len(stack)-1]
+		if len(stack) > 0 {
+			imp.width, imp.height = stack[len(stack)-1].width, stack[len(stack)-1].height
+		}
+	}
+	root := true
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			attrs := attributes(t)
+			if root {
+				if t.Name.Local != "svg" {
+					return nil, errors.New("Document is not an SVG, the root element is " + t.Name.Local)
+				}
+				s := defaultStyle().apply(properties(attrs))
+				transform := imp.viewBox(attrs, true)
+				root = false
+				stack = append(stack, frame{s, transform, imp.width, imp.height})
+				continue
+			}
+			parent := stack[len(stack)-1]
+			if skipped[t.Name.Local] {
+				if err := decoder.Skip(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			s := parent.style.apply(properties(attrs))
+			s.opacity *= parent.style.opacity
+			transform := parent.transform
+			if value, ok := attrs["transform"]; ok {
+				m, err := parseTransform(value)
+				if err != nil {
+					return nil, fmt.Errorf("%v: %w", t.Name.Local, err)
+				}
+				transform = transform.Multiply(m)
+			}
+			// nested svg elements get their own viewport
+			if t.Name.Local == "svg" {
+				x, _ := parseLength(attrs["x"], imp.width)
+				y, _ := parseLength(attrs["y"], imp.height)
+				transform = transform.Multiply(path.Translate(float32(x), float32(y))).Multiply(imp.viewBox(attrs, false))
+			}
+			stack = append(stack, frame{s, transform, imp.width, imp.height})
+			if s.hidden {
+				// hidden elements can have visible children, but display none cannot
+				if properties(attrs)["display"] == "none" {
+					pop()
+					if err := decoder.Skip(); err != nil {
+						return nil, err
+					}
+				}
+				continue
+			}
+			shape, err := imp.shape(t.Name.Local, attrs)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", t.Name.Local, err)
+			}
+			if shape != nil {
+				if err := imp.draw(shape, s, transform, t.Name.Local == "line"); err != nil {
+					return nil, fmt.Errorf("%v: %w", t.Name.Local, err)
+				}
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				pop()
+			}
+		}
+	}
+	if root {
+		return nil, errors.New("Document is empty")
+	}
+	return imp.sprite, nil
+}
+
+// Same as Import, but reads the file at path
+func ImportFile(name string, options Options) (*vecsprite.VecSprite, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Import(file, options)
+}
+
+func attributes(t xml.StartElement) map[string]string {
+	res := make(map[string]string, len(t.Attr))
+	for _, attr := range t.Attr {
+		// namespaced attributes like inkscape:label are not needed, but xlink:href would be
+		if attr.Name.Space == "" || attr.Name.Space == "http://www.w3.org/2000/svg" {
+			res[attr.Name.Local] = attr.Value
+		}
+	}
+	return res
+}
+
+// transform from the viewBox to the viewport, including flipping y and the scale of the options for the root
+// sets the size used for percentages to the size of the new viewport, which Import restores when the element ends
+func (imp *importer) viewBox(attrs map[string]string, root bool) path.Matrix {
+	box := []float64{0, 0, 0, 0}
+	hasBox := false
+	if v, ok := attrs["viewBox"]; ok {
+		s := &scanner{s: v}
+		if values, err := s.numbers(4); err == nil && values[2] > 0 && values[3] > 0 {
+			box = values
+			hasBox = true
+		}
+	}
+	width, errW := parseLength(attrs["width"], imp.width)
+	height, errH := parseLength(attrs["height"], imp.height)
+	if errW != nil || width <= 0 {
+		width = box[2]
+	}
+	if errH != nil || height <= 0 {
+		height = box[3]
+	}
+	m := path.Identity()
+	if root {
+		m = path.Scale(imp.options.Scale, -imp.options.Scale)
+	}
+	if hasBox {
+		// preserveAspectRatio is always xMidYMid meet, the default
+		scale := min(width/box[2], height/box[3])
+		tx := -box[0]*scale + (width-box[2]*scale)/2
+		ty := -box[1]*scale + (height-box[3]*scale)/2
+		m = m.Multiply(path.Matrix{float32(scale), 0, 0, float32(scale), float32(tx), float32(ty)})
+		imp.width, imp.height = box[2], box[3]
+	} else {
+		imp.width, imp.height = width, height
+	}
+	return m
+}
+
+func (imp *importer) length(attrs map[string]string, name string, ref float64) (float64, error) {
+	v, ok := attrs[name]
+	if !ok {
+		return 0, nil
+	}
+	return parseLength(v, ref)
+}
+
+// returns the lengths in order, the reference for percentages is the width for x, the height for y and the diagonal otherwise
+func (imp *importer) lengths(attrs map[string]string, names ...string) ([]float64, error) {
+	res := make([]float64, 0, len(names))
+	for _, name := range names {
+		ref := math.Hypot(imp.width, imp.height) / math.Sqrt2
+		switch name {
+		case "x", "cx", "x1", "x2", "width", "rx":
+			ref = imp.width
+		case "y", "cy", "y1", "y2", "height", "ry":
+			ref = imp.height
+		}
+		v, err := imp.length(attrs, name, ref)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, nil
+}
+
+// the outline of the element in its own coordinates, nil if the element is not a shape
+func (imp *importer) shape(name string, attrs map[string]string) (*path.Path, error) {
+	p := path.New()
+	switch name {
+	case "path":
+		return parsePathData(attrs["d"])
+	case "rect":
+		v, err := imp.lengths(attrs, "x", "y", "width", "height", "rx", "ry")
+		if err != nil {
+			return nil, err
+		}
+		x, y, w, h := v[0], v[1], v[2], v[3]
+		if w <= 0 || h <= 0 {
+			return nil, nil
+		}
+		// a missing radius is the same as the other one
+		_, hasRX := attrs["rx"]
+		_, hasRY := attrs["ry"]
+		rx, ry := v[4], v[5]
+		if !hasRX {
+			rx = ry
+		}
+		if !hasRY {
+			ry = rx
+		}
+		p.RoundedRect(float32(x), float32(y), float32(w), float32(h), float32(rx), float32(ry))
+		return p, nil
+	case "circle", "ellipse":
+		var v []float64
+		var err error
+		if name == "circle" {
+			v, err = imp.lengths(attrs, "cx", "cy", "r")
+			if err == nil {
+				v = append(v, v[2])
+			}
+		} else {
+			v, err = imp.lengths(attrs, "cx", "cy", "rx", "ry")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if v[2] <= 0 || v[3] <= 0 {
+			return nil, nil
+		}
+		p.Ellipse(float32(v[0]), float32(v[1]), float32(v[2]), float32(v[3]), 0)
+		return p, nil
+	case "line":
+		v, err := imp.lengths(attrs, "x1", "y1", "x2", "y2")
+		if err != nil {
+			return nil, err
+		}
+		p.MoveTo(float32(v[0]), float32(v[1]))
+		p.LineTo(float32(v[2]), float32(v[3]))
+		return p, nil
+	case "polyline", "polygon":
+		s := &scanner{s: attrs["points"]}
+		for i := 0; !s.done(); i++ {
+			v, err := s.numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			if i == 0 {
+				p.MoveTo(float32(v[0]), float32(v[1]))
+			} else {
+				p.LineTo(float32(v[0]), float32(v[1]))
+			}
+		}
+		if name == "polygon" {
+			p.Close()
+		}
+		return p, nil
+	}
+	return nil, nil
+}
+
+// fills and strokes the shape, lines are never filled
+func (imp *importer) draw(shape *path.Path, s style, transform path.Matrix, line bool) error {
+	tolerance := imp.options.Tolerance
+	if tolerance <= 0 {
+		tolerance = path.DefaultTolerance
+	}
+	transformed := shape.Transformed(transform)
+	if !line && !s.fill.none {
+		if err := imp.addLayer(transformed, s.fillRule, s.paintColor(s.fill, s.fillOpacity*s.opacity), tolerance); err != nil {
+			return err
+		}
+	}
+	if !s.stroke.none && s.strokeWidth > 0 {
+		// stroked before transforming, so the width is transformed too
+		scale := transform.MaxScale()
+		if scale == 0 {
+			return nil
+		}
+		style := path.StrokeStyle{Width: float32(s.strokeWidth), Join: s.join, Cap: s.cap, MiterLimit: float32(s.miterLimit), Dashes: s.dashes, DashOffset: float32(s.dashOffset)}
+		outline := shape.Stroke(style, tolerance/scale).Transformed(transform)
+		if err := imp.addLayer(outline, path.NonZero, s.paintColor(s.stroke, s.strokeOpacity*s.opacity), tolerance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (imp *importer) addLayer(p *path.Path, rule path.FillRule, c color.Color, tolerance float32) error {
+	if imp.layer > math.MaxUint8 {
+		return errors.New("SVG has more than 256 fills and strokes, which is the maximum amount of layers in a sprite")
+	}
+	before := len(imp.sprite.Vertices)
+	p.Fill(imp.sprite, rule, tolerance, c, uint8(imp.layer))
+	// empty shapes do not use a layer
+	if len(imp.sprite.Vertices) > before {
+		imp.layer++
+	}
+	return nil
+}
+
+// Parses the transform attribute, like "translate(10 20) rotate(45)"
+func parseTransform(v string) (path.Matrix, error) {
+	res := path.Identity()
+	rest := strings.TrimSpace(v)
+	for rest != "" {
+		name, args, ok := strings.Cut(rest, "(")
+		if !ok {
+			return res, fmt.Errorf("Invalid transform %v", v)
+		}
+		args, rest, ok = strings.Cut(args, ")")
+		if !ok {
+			return res, fmt.Errorf("Invalid transform %v", v)
+		}
+		rest = strings.TrimLeft(rest, " \t\n\r,")
+		s := &scanner{s: args}
+		values := make([]float32, 0, 6)
+		for !s.done() {
+			n, err := s.number()
+			if err != nil {
+				return res, fmt.Errorf("Invalid transform %v: %w", v, err)
+			}
+			values = append(values, float32(n))
+		}
+		arg := func(i int, def float32) float32 {
+			if i < len(values) {
+				return values[i]
+			}
+			return def
+		}
+		var m path.Matrix
+		switch strings.TrimSpace(name) {
+		case "matrix":
+			if len(values) != 6 {
+				return res, fmt.Errorf("Invalid transform %v: matrix needs 6 values", v)
+			}
+			m = path.Matrix(values)
+		case "translate":
+			m = path.Translate(arg(0, 0), arg(1, 0))
+		case "scale":
+			m = path.Scale(arg(0, 1), arg(1, arg(0, 1)))
+		case "rotate":
+			// degrees, clockwise on screen since y is down
+			angle := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			m = path.Translate(cx, cy).Multiply(path.Rotate(angle)).Multiply(path.Translate(-cx, -cy))
+		case "skewX":
+			m = path.SkewX(arg(0, 0) * math.Pi / 180)
+		case "skewY":
+			m = path.SkewY(arg(0, 0) * math.Pi / 180)
+		default:
+			return res, fmt.Errorf("Unknown transform %v", name)
+		}
+		res = res.Multiply(m)
+	}
+	return res, nil
+}
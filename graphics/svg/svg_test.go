@@ -0,0 +1,398 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/path"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+)
+
+// what was drawn on one layer of a sprite
+type layer struct {
+	area           float64
+	minX, minY     float32
+	maxX, maxY     float32
+	color          color.Color
+	multipleColors bool
+}
+
+func layers(t *testing.T, sprite *vecsprite.VecSprite) map[uint8]*layer {
+	t.Helper()
+	if err := sprite.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	res := make(map[uint8]*layer)
+	for i := 0; i < len(sprite.Indices); i += 3 {
+		a, b, c := sprite.Vertices[sprite.Indices[i]], sprite.Vertices[sprite.Indices[i+1]], sprite.Vertices[sprite.Indices[i+2]]
+		id := sprite.Layers[sprite.Indices[i]]
+		l, ok := res[id]
+		if !ok {
+			l = &layer{minX: a[0], minY: a[1], maxX: a[0], maxY: a[1], color: sprite.Colors[sprite.Indices[i]]}
+			res[id] = l
+		}
+		for _, v := range [][2]float32{a, b, c} {
+			l.minX, l.minY = min(l.minX, v[0]), min(l.minY, v[1])
+			l.maxX, l.maxY = max(l.maxX, v[0]), max(l.maxY, v[1])
+			if sprite.Colors[sprite.Indices[i]] != l.color {
+				l.multipleColors = true
+			}
+		}
+		l.area += math.Abs(float64((b[0]-a[0])*(c[1]-a[1])-(c[0]-a[0])*(b[1]-a[1]))) / 2
+	}
+	return res
+}
+
+// curves are flattened with a small tolerance, so their areas are close to the exact ones
+func importString(t *testing.T, svg string) map[uint8]*layer {
+	t.Helper()
+	sprite, err := Import(strings.NewReader(svg), Options{Tolerance: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return layers(t, sprite)
+}
+
+// the area of the only layer of an svg with the element, relative error of 1% to allow for flattened curves
+func expectArea(t *testing.T, element string, area float64) {
+	t.Helper()
+	res := importString(t, `<svg xmlns="http://www.w3.org/2000/svg" width="100" height="100">`+element+`</svg>`)
+	if area == 0 {
+		if len(res) != 0 {
+			t.Fatalf("Drew %v layers, expected nothing", len(res))
+		}
+		return
+	}
+	if len(res) != 1 || res[0] == nil {
+		t.Fatalf("Drew %v layers, expected 1", len(res))
+	}
+	if math.Abs(res[0].area-area) > area/100 {
+		t.Fatalf("Area is %v, expected %v", res[0].area, area)
+	}
+}
+
+func TestShapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		element string
+		area    float64
+	}{
+		{"rect", `<rect x="10" y="10" width="10" height="20"/>`, 200},
+		{"rect percent", `<rect width="50%" height="10%"/>`, 500},
+		{"rect units", `<rect width="1in" height="3pt"/>`, 96 * 4},
+		{"rounded rect", `<rect width="20" height="10" rx="2"/>`, 200 - (4-math.Pi)*4},
+		{"rounded rect rx and ry", `<rect width="20" height="10" rx="4" ry="2"/>`, 200 - (4-math.Pi)*8},
+		{"empty rect", `<rect width="0" height="10"/>`, 0},
+		{"circle", `<circle cx="50" cy="50" r="10"/>`, math.Pi * 100},
+		{"ellipse", `<ellipse cx="50" cy="50" rx="10" ry="5"/>`, math.Pi * 50},
+		{"polygon", `<polygon points="0,0 10,0 10,10"/>`, 50},
+		{"polyline is filled", `<polyline points="0 0 10 0 10 10"/>`, 50},
+		{"line is not filled", `<line x1="0" y1="0" x2="10" y2="0"/>`, 0},
+		{"line stroke", `<line x1="0" y1="0" x2="10" y2="0" stroke="black" stroke-width="2"/>`, 20},
+		{"unknown element", `<foo width="10" height="10"/>`, 0},
+		{"skipped element", `<defs><rect width="10" height="10"/></defs>`, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expectArea(t, test.element, test.area)
+		})
+	}
+}
+
+func TestPathData(t *testing.T) {
+	tests := []struct {
+		name string
+		d    string
+		area float64
+	}{
+		{"lines", "M0 0 L10 0 L10 10 L0 10 Z", 100},
+		{"implicit lines", "M0 0 10 0 10 10 0 10z", 100},
+		{"relative", "m10 10 h10 v10 h-10 z", 100},
+		{"compact numbers", "M0,0H10V10H0Z", 100},
+		{"two subpaths", "M0 0h10v10h-10z M20 0h10v10h-10z", 200},
+		{"quadratic", "M0 0 Q5 10 10 0 Z", 100.0 / 3},
+		{"smooth quadratic", "M0 0 Q5 10 10 0 T20 0 Z", 0},
+		{"cubic", "M0 0 C0 10 10 10 10 0 Z", 60},
+		{"smooth cubic", "M0 0 C0 10 10 10 10 0 S20 -10 20 0 Z", 0},
+		{"arc", "M0 0 A5 5 0 0 1 10 0 Z", math.Pi * 25 / 2},
+		{"large arc", "M0 0 A5 5 0 1 1 10 0 Z", math.Pi * 25 / 2},
+		{"arc flags without separators", "M0 0 A5 5 0 1110 0 Z", math.Pi * 25 / 2},
+		{"scaled arc", "M0 0 A1 1 0 0 0 10 0 Z", math.Pi * 25 / 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the smooth curves go up and down by the same amount, which cancels out with the nonzero rule only if they reflect the control point
+			if test.area == 0 {
+				res := importString(t, fmt.Sprintf(`<svg width="100" height="100"><path d=%q fill-rule="evenodd"/></svg>`, test.d))
+				if len(res) != 1 || res[0].area < 1 {
+					t.Fatalf("Drew %v layers", len(res))
+				}
+				// both halves are the same, so the bounds are symmetric
+				if math.Abs(float64(res[0].maxY+res[0].minY)) > 0.01 {
+					t.Fatalf("Bounds are %v to %v", res[0].minY, res[0].maxY)
+				}
+				return
+			}
+			expectArea(t, fmt.Sprintf(`<path d=%q/>`, test.d), test.area)
+		})
+	}
+}
+
+func TestPathDataErrors(t *testing.T) {
+	tests := []struct {
+		d   string
+		err string
+	}{
+		{"10 10", "must start with a command"},
+		{"M0", "Invalid path data"},
+		{"M0 0 X10 10", "Unknown path command"},
+		{"M0 0 A5 5 0 2 1 10 0", "Invalid path data"},
+	}
+	for _, test := range tests {
+		t.Run(test.d, func(t *testing.T) {
+			_, err := parsePathData(test.d)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Error %q does not contain %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		// bounds of the first layer, y is flipped since it is up in sprites
+		minX, minY, maxX, maxY float32
+	}{
+		{"none", `<svg width="100" height="100"><rect x="1" y="2" width="10" height="10"/></svg>`, 1, -12, 11, -2},
+		{"translate", `<svg width="100" height="100"><rect width="10" height="10" transform="translate(5 5)"/></svg>`, 5, -15, 15, -5},
+		{"translate and scale", `<svg width="100" height="100"><rect width="10" height="10" transform="translate(5,5) scale(2)"/></svg>`, 5, -25, 25, -5},
+		{"group", `<svg width="100" height="100"><g transform="translate(10)"><g transform="scale(2 1)"><rect width="10" height="10"/></g></g></svg>`, 10, -10, 30, 0},
+		{"rotate", `<svg width="100" height="100"><rect width="10" height="20" transform="rotate(90)"/></svg>`, -20, -10, 0, 0},
+		{"rotate around", `<svg width="100" height="100"><rect width="10" height="10" transform="rotate(180 10 10)"/></svg>`, 10, -20, 20, -10},
+		{"matrix", `<svg width="100" height="100"><rect width="10" height="10" transform="matrix(1 0 0 1 3 4)"/></svg>`, 3, -14, 13, -4},
+		{"skewX", `<svg width="100" height="100"><rect width="10" height="10" transform="skewX(45)"/></svg>`, 0, -10, 20, 0},
+		{"viewBox", `<svg width="100" height="100" viewBox="10 10 10 10"><rect x="10" y="10" width="5" height="5"/></svg>`, 0, -50, 50, 0},
+		// the viewBox is centered in the viewport
+		{"viewBox aspect", `<svg width="200" height="100" viewBox="0 0 10 10"><rect width="10" height="10"/></svg>`, 50, -100, 150, 0},
+		{"nested", `<svg width="100" height="100"><svg x="10" y="20" width="4" height="4" viewBox="0 0 2 2"><rect width="2" height="2"/></svg></svg>`, 10, -24, 14, -20},
+		// the root has no size, which is not the same as being the root
+		{"nested in sizeless root", `<svg><svg x="1" y="1" width="4" height="4" viewBox="0 0 2 2"><rect width="2" height="2"/></svg></svg>`, 1, -5, 5, -1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := importString(t, test.svg)
+			l := res[0]
+			if l == nil {
+				t.Fatal("Nothing was drawn")
+			}
+			got := []float32{l.minX, l.minY, l.maxX, l.maxY}
+			expected := []float32{test.minX, test.minY, test.maxX, test.maxY}
+			for i := range got {
+				if math.Abs(float64(got[i]-expected[i])) > 0.01 {
+					t.Fatalf("Bounds are %v, expected %v", got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestNestedViewport(t *testing.T) {
+	// percentages after a nested svg are of the outer viewport again
+	res := importString(t, `<svg width="100" height="100">
+		<svg width="4" height="4" viewBox="0 0 2 2"><rect width="50%" height="50%"/></svg>
+		<rect width="50%" height="50%"/>
+	</svg>`)
+	if len(res) != 2 {
+		t.Fatalf("Drew %v layers", len(res))
+	}
+	if res[0].maxX != 2 || res[1].maxX != 50 {
+		t.Fatalf("Rects are %v and %v wide", res[0].maxX, res[1].maxX)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	sprite, err := Import(strings.NewReader(`<svg width="100" height="100"><circle cx="50" cy="50" r="50"/></svg>`), Options{Scale: 2, Tolerance: 0.01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rough, err := Import(strings.NewReader(`<svg width="100" height="100"><circle cx="50" cy="50" r="50"/></svg>`), Options{Tolerance: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := layers(t, sprite)[0]
+	if l.maxX != 200 || l.minY != -200 {
+		t.Fatalf("Scaled circle goes to %v, %v", l.maxX, l.minY)
+	}
+	if len(rough.Vertices) >= len(sprite.Vertices) {
+		t.Fatalf("Circle with a larger tolerance has %v vertices, and %v with a smaller one", len(rough.Vertices), len(sprite.Vertices))
+	}
+}
+
+func rgba(r, g, b, a uint8) color.Color {
+	return color.FromRGBA(r, g, b, a)
+}
+
+func TestStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		element string
+		color   color.Color
+	}{
+		{"default", `<rect width="10" height="10"/>`, rgba(0, 0, 0, 255)},
+		{"attribute", `<rect width="10" height="10" fill="red"/>`, rgba(255, 0, 0, 255)},
+		{"style attribute wins", `<rect width="10" height="10" fill="red" style="fill: blue"/>`, rgba(0, 0, 255, 255)},
+		{"important", `<rect width="10" height="10" style="fill:#00ff00 !important"/>`, rgba(0, 255, 0, 255)},
+		{"short hex", `<rect width="10" height="10" fill="#f80"/>`, rgba(255, 136, 0, 255)},
+		{"rgb", `<rect width="10" height="10" fill="rgb(10, 20, 30)"/>`, rgba(10, 20, 30, 255)},
+		{"rgb percent", `<rect width="10" height="10" fill="rgb(100%, 0%, 50%)"/>`, rgba(255, 0, 128, 255)},
+		{"inherited", `<g fill="teal"><rect width="10" height="10"/></g>`, rgba(0, 128, 128, 255)},
+		{"current color", `<g color="navy"><rect width="10" height="10" fill="currentColor"/></g>`, rgba(0, 0, 128, 255)},
+		{"invalid keeps inherited", `<g fill="red"><rect width="10" height="10" fill="notacolor"/></g>`, rgba(255, 0, 0, 255)},
+		{"url fallback", `<rect width="10" height="10" fill="url(#gradient) purple"/>`, rgba(128, 0, 128, 255)},
+		{"fill opacity", `<rect width="10" height="10" fill-opacity="0.5"/>`, rgba(0, 0, 0, 128)},
+		{"opacity multiplies", `<g opacity="50%"><rect width="10" height="10" opacity="0.5"/></g>`, rgba(0, 0, 0, 64)},
+		{"visible child of hidden", `<g visibility="hidden"><rect width="10" height="10" visibility="visible" fill="white"/></g>`, rgba(255, 255, 255, 255)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := importString(t, `<svg width="100" height="100">`+test.element+`</svg>`)
+			if len(res) != 1 || res[0] == nil {
+				t.Fatalf("Drew %v layers", len(res))
+			}
+			if res[0].color != test.color || res[0].multipleColors {
+				t.Fatalf("Color is %v, expected %v", res[0].color.ToRGBA(), test.color.ToRGBA())
+			}
+		})
+	}
+}
+
+func TestHidden(t *testing.T) {
+	for _, element := range []string{
+		`<rect width="10" height="10" fill="none"/>`,
+		`<rect width="10" height="10" display="none"/>`,
+		`<g style="display: none"><rect width="10" height="10" visibility="visible"/></g>`,
+		`<g visibility="hidden"><rect width="10" height="10"/></g>`,
+		`<rect width="10" height="10" fill="url(#gradient)"/>`,
+	} {
+		t.Run(element, func(t *testing.T) {
+			expectArea(t, element, 0)
+		})
+	}
+}
+
+func TestStrokeStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		element string
+		area    float64
+	}{
+		{"butt", `<line x2="10" stroke="black" stroke-width="2"/>`, 20},
+		{"square", `<line x2="10" stroke="black" stroke-width="2" stroke-linecap="square"/>`, 24},
+		{"round", `<line x2="10" stroke="black" stroke-width="2" stroke-linecap="round"/>`, 20 + math.Pi},
+		{"dashes", `<line x2="10" stroke="black" stroke-width="2" stroke-dasharray="2 3"/>`, 2 * 2 * 2},
+		{"dash offset", `<line x2="10" stroke="black" stroke-width="2" stroke-dasharray="2 3" stroke-dashoffset="1"/>`, 2 * (1 + 2 + 1)},
+		{"scaled", `<line x2="10" stroke="black" stroke-width="2" transform="scale(2)"/>`, 80},
+		{"no width", `<line x2="10" stroke="black" stroke-width="0"/>`, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expectArea(t, test.element, test.area)
+		})
+	}
+}
+
+func TestLayerOrder(t *testing.T) {
+	res := importString(t, `<svg width="100" height="100">
+		<rect width="10" height="10" fill="red"/>
+		<rect width="0" height="10" fill="white"/>
+		<g fill="lime"><rect width="10" height="10" stroke="blue"/></g>
+		<circle r="5" fill="none" stroke="black"/>
+	</svg>`)
+	// the empty rect does not use a layer, and the fill is below the stroke of the same element
+	expected := []color.Color{rgba(255, 0, 0, 255), rgba(0, 255, 0, 255), rgba(0, 0, 255, 255), rgba(0, 0, 0, 255)}
+	if len(res) != len(expected) {
+		t.Fatalf("Drew %v layers, expected %v", len(res), len(expected))
+	}
+	for i, c := range expected {
+		if res[uint8(i)].color != c {
+			t.Fatalf("Layer %v has color %v, expected %v", i, res[uint8(i)].color.ToRGBA(), c.ToRGBA())
+		}
+	}
+}
+
+func TestTooManyLayers(t *testing.T) {
+	svg := `<svg width="100" height="100">` + strings.Repeat(`<rect width="10" height="10"/>`, 257) + `</svg>`
+	if _, err := Import(strings.NewReader(svg), Options{}); err == nil || !strings.Contains(err.Error(), "256") {
+		t.Fatalf("Error is %v", err)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		err  string
+	}{
+		{"empty", ``, "empty"},
+		{"not svg", `<html></html>`, "not an SVG"},
+		{"transform", `<svg><rect width="1" height="1" transform="spin(1)"/></svg>`, "Unknown transform"},
+		{"matrix", `<svg><rect width="1" height="1" transform="matrix(1 2)"/></svg>`, "6 values"},
+		{"length", `<svg width="10" height="10"><rect width="1x" height="1"/></svg>`, "Invalid length"},
+		{"xml", `<svg><rect></svg>`, "syntax error"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Import(strings.NewReader(test.svg), Options{})
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Error %q does not contain %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	m, err := parseTransform("translate(1, 2) scale(3) rotate(90)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := path.Translate(1, 2).Multiply(path.Scale(3, 3)).Multiply(path.Rotate(math.Pi / 2))
+	for i := range m {
+		if math.Abs(float64(m[i]-expected[i])) > 1e-5 {
+			t.Fatalf("Matrix is %v, expected %v", m, expected)
+		}
+	}
+}
+
+func TestParseLength(t *testing.T) {
+	tests := []struct {
+		value  string
+		length float64
+	}{
+		{"10", 10},
+		{" 10px ", 10},
+		{"1in", 96},
+		{"72pt", 96},
+		{"2.54cm", 96},
+		{"25%", 50},
+		{"-1e1", -10},
+	}
+	for _, test := range tests {
+		if l, err := parseLength(test.value, 200); err != nil || math.Abs(l-test.length) > 1e-9 {
+			t.Fatalf("Length of %q is %v, %v, expected %v", test.value, l, err, test.length)
+		}
+	}
+	if _, err := parseLength("ten", 0); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
@@ -27,6 +27,14 @@ func (t *TextRenderer) Init(renderer render.Renderer) {
 	t.positionData = make(map[rune][]uint64)
 }
 
+// Frees the data buffer made by Init, Operation and GlyphBuffer are not freed
+func (t *TextRenderer) Free() {
+	if t.dataBuffer != nil {
+		t.dataBuffer.Free()
+		t.dataBuffer = nil
+	}
+}
+
 func (t *TextRenderer) Clear() {
 	for k := range t.positionData {
 		delete(t.positionData, k)
@@ -90,7 +98,7 @@ func (t *TextRenderer) AddText(x, y float32, text string) {
 	}
 }
 
-func (t *TextRenderer) DrawTo(target render.RenderTarget) {
+func (t *TextRenderer) DrawTo(target render.RenderTarget) error {
 	for glyph, bufferedGlyph := range t.GlyphBuffer.Glyphs {
 		_, ok := t.indexMap[glyph]
 		if !ok {
@@ -98,13 +106,20 @@ func (t *TextRenderer) DrawTo(target render.RenderTarget) {
 		}
 		//t.dataBuffer.SetData64(t.positionData[glyph])
 		// buffer index
-		t.Operation.SetInstanceAttribute(t.PositionChannel, t.dataBuffer, t.indexMap[glyph], 0)
+		if err := t.Operation.SetInstanceAttribute(t.PositionChannel, t.dataBuffer, t.indexMap[glyph], 0); err != nil {
+			return err
+		}
 		// sprite
-		t.Operation.SetSprite(t.GlyphBuffer.SpriteBuffer, bufferedGlyph.Index)
+		if err := t.Operation.SetSprite(t.GlyphBuffer.SpriteBuffer, bufferedGlyph.Index); err != nil {
+			return err
+		}
 		// get amount by length of positions
 		t.Operation.SetAmount(uint32(len(t.positionData[glyph])))
-		t.Operation.DrawTo(target)
+		if err := t.Operation.DrawTo(target); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func (t *TextRenderer) UpdateText() {
@@ -0,0 +1,122 @@
+package texture
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+)
+
+// Packs many images into a single image, so sprites using different images can be drawn with one texture
+// Usage: Add every image, Pack, make a texture from the result and use Region to get the texture coordinates
+type Atlas struct {
+	// Size of the packed image
+	Width, Height int
+	// Pixels between images, the edges of images are repeated into it so filtering does not blend neighbouring images
+	Padding int
+
+	images  []atlasImage
+	regions map[string]Region
+}
+
+type atlasImage struct {
+	name string
+	img  image.Image
+}
+
+func NewAtlas(width, height, padding int) *Atlas {
+	return &Atlas{
+		Width:   width,
+		Height:  height,
+		Padding: padding,
+		images:  make([]atlasImage, 0),
+		regions: make(map[string]Region),
+	}
+}
+
+// Adds an image to be packed, using the name of an existing image replaces it
+func (a *Atlas) Add(name string, img image.Image) {
+	for i, existing := range a.images {
+		if existing.name == name {
+			a.images[i].img = img
+			return
+		}
+	}
+	a.images = append(a.images, atlasImage{name, img})
+}
+
+// Packs every added image, regions from earlier calls are replaced
+// Images are placed in rows from the tallest to the shortest, which works well for images of similar heights
+func (a *Atlas) Pack() (*image.NRGBA, error) {
+	sorted := make([]atlasImage, len(a.images))
+	copy(sorted, a.images)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].img.Bounds().Dy() > sorted[j].img.Bounds().Dy()
+	})
+	res := image.NewNRGBA(image.Rect(0, 0, a.Width, a.Height))
+	regions := make(map[string]Region, len(sorted))
+	// top left of the next image and height of the current row, including padding
+	x, y, rowHeight := 0, 0, 0
+	for _, img := range sorted {
+		bounds := img.img.Bounds()
+		w, h := bounds.Dx()+a.Padding*2, bounds.Dy()+a.Padding*2
+		if w > a.Width {
+			return nil, fmt.Errorf("Image '%v' is wider than the atlas", img.name)
+		}
+		if x+w > a.Width {
+			x = 0
+			y += rowHeight
+			rowHeight = 0
+		}
+		if y+h > a.Height {
+			return nil, errors.New("Images do not fit in the atlas")
+		}
+		rect := image.Rect(x+a.Padding, y+a.Padding, x+w-a.Padding, y+h-a.Padding)
+		draw.Draw(res, rect, img.img, bounds.Min, draw.Src)
+		a.extrude(res, rect)
+		regions[img.name] = a.region(rect)
+		x += w
+		rowHeight = max(rowHeight, h)
+	}
+	a.regions = regions
+	return res, nil
+}
+
+// repeats the edges of rect into the padding around it
+func (a *Atlas) extrude(img *image.NRGBA, rect image.Rectangle) {
+	if rect.Empty() {
+		return
+	}
+	p := a.Padding
+	for y := rect.Min.Y - p; y < rect.Max.Y+p; y++ {
+		for x := rect.Min.X - p; x < rect.Max.X+p; x++ {
+			if (image.Point{x, y}).In(rect) {
+				continue
+			}
+			img.SetNRGBA(x, y, img.NRGBAAt(clamp(x, rect.Min.X, rect.Max.X-1), clamp(y, rect.Min.Y, rect.Max.Y-1)))
+		}
+	}
+}
+
+func clamp(v, low, high int) int {
+	return min(max(v, low), high)
+}
+
+func (a *Atlas) region(rect image.Rectangle) Region {
+	w, h := float32(a.Width), float32(a.Height)
+	return Region{
+		X:      rect.Min.X,
+		Y:      rect.Min.Y,
+		Width:  rect.Dx(),
+		Height: rect.Dy(),
+		UV0:    [2]float32{float32(rect.Min.X) / w, float32(rect.Min.Y) / h},
+		UV1:    [2]float32{float32(rect.Max.X) / w, float32(rect.Max.Y) / h},
+	}
+}
+
+// Returns the region of the image with the name, ok is false if it was not packed by the latest call to Pack
+func (a *Atlas) Region(name string) (region Region, ok bool) {
+	region, ok = a.regions[name]
+	return
+}
@@ -0,0 +1,57 @@
+// Loading images for render.Renderer.MakeTexture, packing them into atlases, and sprites for drawing them
+package texture
+
+import (
+	"image"
+	"io"
+	"os"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+	"github.com/eliiasg/deltawing/graphics/vecsprite"
+
+	// PNG is the only format that is registered, import other image packages to load those
+	_ "image/png"
+)
+
+// Decodes an image, the result can be given to Renderer.MakeTexture or Atlas.Add
+func Load(reader io.Reader) (image.Image, error) {
+	img, _, err := image.Decode(reader)
+	return img, err
+}
+
+// Same as Load, but reads the file at path
+func LoadFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return Load(file)
+}
+
+// Part of a texture, from Atlas.Region
+type Region struct {
+	// Position and size in pixels, excluding padding
+	X, Y, Width, Height int
+	// Texture coordinates of the top left and bottom right corners
+	UV0, UV1 [2]float32
+}
+
+// Region covering an entire texture
+func Full(width, height int) Region {
+	return Region{Width: width, Height: height, UV1: [2]float32{1, 1}}
+}
+
+// Rectangle of width by height showing the region, with the origin in the bottom left
+// The color is multiplied with the texture when using presets.Textured, so use white to show the texture as it is
+func Quad(region Region, width, height float32, c color.Color) *vecsprite.VecSprite {
+	u0, v0, u1, v1 := region.UV0[0], region.UV0[1], region.UV1[0], region.UV1[1]
+	return &vecsprite.VecSprite{
+		// y is up in sprites, so the top of the region is at height
+		Vertices: [][2]float32{{0, 0}, {width, 0}, {width, height}, {0, height}},
+		Colors:   []color.Color{c, c, c, c},
+		Layers:   []uint8{0, 0, 0, 0},
+		Indices:  []uint32{0, 1, 2, 0, 2, 3},
+		UVs:      [][2]float32{{u0, v1}, {u1, v1}, {u1, v0}, {u0, v0}},
+	}
+}
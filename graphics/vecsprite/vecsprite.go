@@ -3,6 +3,7 @@ package vecsprite
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 
@@ -16,30 +17,103 @@ type VecSprite struct {
 	Layers   []uint8
 	// indices
 	Indices []uint32
+	// Texture coordinates per vertex, nil if the sprite is not textured - (0, 0) is the top left of the texture
+	UVs [][2]float32
+	// Extra per vertex data by name, like bone weights or normals - nil if there is none
+	// Read in procedures with ProcedureBuilder.AddVertexDataChannel
+	Data map[string]VertexData
+}
+
+// Values of a VecSprite.Data entry, Amount floats for every vertex
+type VertexData struct {
+	// Floats per vertex, 1 to 4
+	Amount uint8
+	// Values of every vertex after each other, len(Vertices)*Amount floats
+	Values []float32
+}
+
+// Returns the values of the vertex, with zeros if there are too few values
+func (d VertexData) At(vertex int) [4]float32 {
+	var res [4]float32
+	start := vertex * int(d.Amount)
+	for i := 0; i < int(d.Amount) && i < 4 && start+i < len(d.Values); i++ {
+		res[i] = d.Values[start+i]
+	}
+	return res
+}
+
+// Sprites compiled into the vertex and index format of the gl renderer, from util.CompileVecSpriteBuffer in graphics/render/gl/util
+// Can be saved with the sprites and given to SpriteBufferBuilder.AddCompiled to not compile them again
+type Compiled struct {
+	// 3 uint32 per vertex: x, y and rgb with the layer
+	Vertices []uint32
+	Indices  []uint32
+	// Start of every sprite in Indices, followed by len(Indices)
+	IdxPositions []uint32
+}
+
+// Checks that every vertex has a color and a layer, that every index is a vertex and that vertex data has 1 to 4 floats per vertex
+func (v *VecSprite) Validate() error {
+	if len(v.Colors) != len(v.Vertices) || len(v.Layers) != len(v.Vertices) {
+		return fmt.Errorf("Sprite has %v vertices, but %v colors and %v layers", len(v.Vertices), len(v.Colors), len(v.Layers))
+	}
+	if len(v.Indices)%3 != 0 {
+		return fmt.Errorf("Sprite has %v indices, which is not a multiple of 3", len(v.Indices))
+	}
+	for i, idx := range v.Indices {
+		if int(idx) >= len(v.Vertices) {
+			return fmt.Errorf("Index %v is %v, but sprite only has %v vertices", i, idx, len(v.Vertices))
+		}
+	}
+	for name, data := range v.Data {
+		if data.Amount < 1 || data.Amount > 4 {
+			return fmt.Errorf("Vertex data %v has %v floats per vertex, but it must be 1 to 4", name, data.Amount)
+		}
+	}
+	return nil
 }
 
 // Descrition of tris format can be found at the bottom of this readme https://github.com/EliiasG/MonoGameDrawingApp#readme
+// Returns an error if the input ends early, or if the sprite is not valid according to Validate
 func FromBytes(reader io.ByteReader) (*VecSprite, error) {
 	verts, colors, layers, e := readVerts(reader)
 	if e != nil {
 		return nil, e
 	}
-	inds := readInds(reader)
-	return &VecSprite{verts, colors, layers, inds}, nil
+	inds, e := readInds(reader)
+	if e != nil {
+		return nil, e
+	}
+	sprite := &VecSprite{verts, colors, layers, inds, nil, nil}
+	if e := sprite.Validate(); e != nil {
+		return nil, e
+	}
+	return sprite, nil
 }
 
-func readInds(reader io.ByteReader) []uint32 {
+func readInds(reader io.ByteReader) ([]uint32, error) {
 	inds := make([]uint32, 0)
 	for true {
-		idx, e := readUint(reader)
+		idx, n, e := readUint(reader)
+		// indices continue until the end, so only ending in the middle of one is an error
+		if n == 0 && e == io.EOF {
+			return inds, nil
+		}
 		if e != nil {
-			return inds
+			return nil, readError(e)
 		}
 		inds = append(inds, idx)
 	}
 	//should never happen
-	return nil
+	return nil, nil
+}
 
+// end of input is unexpected everywhere except between indices
+func readError(e error) error {
+	if e == io.EOF {
+		return errors.New("Invalid bytes for vector sprite: unexpected end of input")
+	}
+	return e
 }
 
 func readVerts(reader io.ByteReader) ([][2]float32, []color.Color, []uint8, error) {
@@ -52,18 +126,29 @@ func readVerts(reader io.ByteReader) ([][2]float32, []color.Color, []uint8, erro
 	var curColor color.Color
 	var layer uint8 = 0
 	for true {
-		// ignoring error to catch at end, even if it ends at start it would be fine to continue
 		if colorChanges {
-			curColor, _ = color.ReadARGB(reader)
+			var e error
+			if curColor, e = color.ReadARGB(reader); e != nil {
+				return nil, nil, nil, readError(e)
+			}
 		}
 
-		x, _ := readFloat(reader)
-		y, _ := readFloat(reader)
+		x, e := readFloat(reader)
+		if e != nil {
+			return nil, nil, nil, readError(e)
+		}
+		y, e := readFloat(reader)
+		if e != nil {
+			return nil, nil, nil, readError(e)
+		}
 		// byte specifying what happens next
 		change, e := reader.ReadByte()
+		if e != nil {
+			return nil, nil, nil, readError(e)
+		}
 		// 0 means no color change, 1 means color change, and 2 means no more vertices, so any value over 2 is invalid
-		if e != nil || change > 2 {
-			return nil, nil, nil, errors.New("Invalid bytes for vector sprite")
+		if change > 2 {
+			return nil, nil, nil, fmt.Errorf("Invalid bytes for vector sprite: unknown vertex separator %v", change)
 		}
 
 		verts = append(verts, [2]float32{x, y})
@@ -71,6 +156,9 @@ func readVerts(reader io.ByteReader) ([][2]float32, []color.Color, []uint8, erro
 		layers = append(layers, layer)
 		colorChanges = change == 1
 		if change == 1 {
+			if layer == math.MaxUint8 {
+				return nil, nil, nil, errors.New("Invalid bytes for vector sprite: more than 256 layers")
+			}
 			layer++
 		}
 
@@ -85,17 +173,80 @@ func readVerts(reader io.ByteReader) ([][2]float32, []color.Color, []uint8, erro
 }
 
 func readFloat(reader io.ByteReader) (float32, error) {
-	bytes, e := readUint(reader)
+	bytes, _, e := readUint(reader)
 	return math.Float32frombits(bytes), e
 }
 
-func readUint(reader io.ByteReader) (uint32, error) {
-	var e error
+// also returns the amount of bytes read, to know if the input ended between values
+func readUint(reader io.ByteReader) (uint32, int, error) {
 	bytes := [4]byte{}
-	// only catch last error, if there is any error the last call sould also give an error.
 	for i := 0; i < 4; i++ {
-		bytes[i], e = reader.ReadByte()
+		b, e := reader.ReadByte()
+		if e != nil {
+			return 0, i, e
+		}
+		bytes[i] = b
 	}
 	bits := binary.LittleEndian.Uint32(bytes[:])
-	return bits, e
+	return bits, 4, nil
+}
+
+// Writes the sprite in the tris format read by FromBytes, UVs and Data are not part of the format and are not written
+// Layers must start at 0 and increase by at most 1 between vertices, and the color can only change when the layer does
+// This is how the format stores colors, every color change is a new layer
+func (v *VecSprite) WriteTo(writer io.Writer) (int64, error) {
+	data, e := v.ToBytes()
+	if e != nil {
+		return 0, e
+	}
+	n, e := writer.Write(data)
+	return int64(n), e
+}
+
+// Same as WriteTo, but returns the bytes
+func (v *VecSprite) ToBytes() ([]byte, error) {
+	if e := v.Validate(); e != nil {
+		return nil, e
+	}
+	if len(v.Vertices) == 0 {
+		return nil, errors.New("Cannot write sprite without vertices, the format needs at least one")
+	}
+	if v.Layers[0] != 0 {
+		return nil, fmt.Errorf("Cannot write sprite, the first layer must be 0 but is %v", v.Layers[0])
+	}
+	// color, position and separator per vertex, colors are usually not written every vertex
+	data := make([]byte, 0, len(v.Vertices)*13+len(v.Indices)*4)
+	for i, vert := range v.Vertices {
+		if i == 0 {
+			data = appendColor(data, v.Colors[0])
+		}
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(vert[0]))
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(vert[1]))
+		if i == len(v.Vertices)-1 {
+			data = append(data, 2)
+			break
+		}
+		// as ints, since going from 255 to 0 would be 1 in uint8
+		switch int(v.Layers[i+1]) - int(v.Layers[i]) {
+		case 0:
+			if v.Colors[i+1] != v.Colors[i] {
+				return nil, fmt.Errorf("Cannot write sprite, vertex %v changes color without changing layer", i+1)
+			}
+			data = append(data, 0)
+		case 1:
+			data = append(data, 1)
+			data = appendColor(data, v.Colors[i+1])
+		default:
+			return nil, fmt.Errorf("Cannot write sprite, layer goes from %v to %v at vertex %v, but can only increase by 1", v.Layers[i], v.Layers[i+1], i+1)
+		}
+	}
+	for _, idx := range v.Indices {
+		data = binary.LittleEndian.AppendUint32(data, idx)
+	}
+	return data, nil
+}
+
+func appendColor(data []byte, c color.Color) []byte {
+	argb := c.ToARGB()
+	return append(data, argb[:]...)
 }
@@ -0,0 +1,169 @@
+package vecsprite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/eliiasg/deltawing/graphics/color"
+)
+
+func testSprite() *VecSprite {
+	red := color.FromRGBA(255, 0, 0, 255)
+	blue := color.FromRGBA(0, 0, 255, 128)
+	return &VecSprite{
+		Vertices: [][2]float32{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {2, 2}, {3, 2}},
+		Colors:   []color.Color{red, red, red, red, blue, blue},
+		Layers:   []uint8{0, 0, 0, 0, 1, 1},
+		Indices:  []uint32{0, 1, 2, 0, 2, 3, 3, 4, 5},
+	}
+}
+
+func testBytes(t testing.TB) []byte {
+	data, err := testSprite().ToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// NaN is not equal to itself, so floats are compared by their bits
+func sameSprite(a, b *VecSprite) bool {
+	if len(a.Vertices) != len(b.Vertices) || len(a.Indices) != len(b.Indices) {
+		return false
+	}
+	for i := range a.Vertices {
+		for j := 0; j < 2; j++ {
+			if math.Float32bits(a.Vertices[i][j]) != math.Float32bits(b.Vertices[i][j]) {
+				return false
+			}
+		}
+		if a.Colors[i] != b.Colors[i] || a.Layers[i] != b.Layers[i] {
+			return false
+		}
+	}
+	for i := range a.Indices {
+		if a.Indices[i] != b.Indices[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRoundTrip(t *testing.T) {
+	sprite := testSprite()
+	read, err := FromBytes(bytes.NewReader(testBytes(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameSprite(sprite, read) {
+		t.Fatalf("Read %+v, wrote %+v", read, sprite)
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	f.Add(testBytes(f))
+	f.Add([]byte{})
+	f.Add(make([]byte, 13))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		sprite, err := FromBytes(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		written, err := sprite.ToBytes()
+		if err != nil {
+			t.Fatalf("Could not write sprite that was read: %v", err)
+		}
+		read, err := FromBytes(bytes.NewReader(written))
+		if err != nil {
+			t.Fatalf("Could not read sprite that was written: %v", err)
+		}
+		if !sameSprite(sprite, read) {
+			t.Fatalf("Read %+v after writing %+v", read, sprite)
+		}
+	})
+}
+
+func TestFromBytesErrors(t *testing.T) {
+	valid := testBytes(t)
+	// the vertex section is everything before the indices
+	vertsEnd := len(valid) - len(testSprite().Indices)*4
+	index := func(idx uint32) []byte {
+		return binary.LittleEndian.AppendUint32(nil, idx)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{"empty", []byte{}, "unexpected end of input"},
+		{"truncated color", valid[:2], "unexpected end of input"},
+		{"truncated position", valid[:6], "unexpected end of input"},
+		{"truncated separator", valid[:12], "unexpected end of input"},
+		{"truncated index", valid[:len(valid)-2], "unexpected end of input"},
+		{"unknown separator", append(append([]byte{}, valid[:12]...), 3), "unknown vertex separator"},
+		{"index out of range", append(append([]byte{}, valid[:len(valid)-4]...), index(6)...), "only has 6 vertices"},
+		{"indices not multiple of 3", valid[:len(valid)-4], "not a multiple of 3"},
+		{"only vertices", valid[:vertsEnd+4], "not a multiple of 3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := FromBytes(bytes.NewReader(test.data))
+			if err == nil {
+				t.Fatalf("Expected error containing %q", test.err)
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Expected error containing %q, got %q", test.err, err)
+			}
+		})
+	}
+}
+
+func TestToBytesErrors(t *testing.T) {
+	// 0 to 255 and back to 0 must not be seen as an increase of 1
+	wrapping := &VecSprite{}
+	for i := 0; i <= 256; i++ {
+		wrapping.Vertices = append(wrapping.Vertices, [2]float32{float32(i), 0})
+		wrapping.Colors = append(wrapping.Colors, color.FromRGBA(uint8(i), 0, 0, 255))
+		wrapping.Layers = append(wrapping.Layers, uint8(i))
+	}
+	colorChange := testSprite()
+	colorChange.Colors[1] = color.White()
+	skip := testSprite()
+	skip.Layers[4], skip.Layers[5] = 2, 2
+	start := testSprite()
+	start.Layers = []uint8{1, 1, 1, 1, 2, 2}
+	tests := []struct {
+		name   string
+		sprite *VecSprite
+		err    string
+	}{
+		{"layer wraps", wrapping, "can only increase by 1"},
+		{"color change", colorChange, "changes color without changing layer"},
+		{"layer skip", skip, "can only increase by 1"},
+		{"first layer", start, "first layer must be 0"},
+		{"no vertices", &VecSprite{}, "without vertices"},
+		{"missing colors", &VecSprite{Vertices: [][2]float32{{0, 0}}}, "0 colors"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.sprite.ToBytes()
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("Expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateData(t *testing.T) {
+	for _, amount := range []uint8{0, 1, 4, 5} {
+		sprite := testSprite()
+		sprite.Data = map[string]VertexData{"weight": {Amount: amount, Values: make([]float32, int(amount)*len(sprite.Vertices))}}
+		err := sprite.Validate()
+		if valid := amount >= 1 && amount <= 4; valid != (err == nil) {
+			t.Fatalf("Amount %v gave error %v", amount, err)
+		}
+	}
+}
@@ -135,6 +135,10 @@ func (c context) BindVertexArray(array any) {
 	gl.BindVertexArray(glObj(array))
 }
 
+func (c context) ActiveTexture(texture uint32) {
+	gl.ActiveTexture(texture)
+}
+
 func (c context) AttachShader(program any, shader any) {
 	gl.AttachShader(glObj(program), glObj(shader))
 }
@@ -152,6 +156,10 @@ func (c context) Clear(mask uint32) {
 	gl.Clear(mask)
 }
 
+func (c context) ClearBufferuiv(buffer uint32, drawbuffer int32, value []uint32) {
+	gl.ClearBufferuiv(buffer, drawbuffer, &value[0])
+}
+
 func (c context) ClearColor(r float32, g float32, b float32, a float32) {
 	gl.ClearColor(r, g, b, a)
 }
@@ -160,6 +168,10 @@ func (c context) CompileShader(shader any) {
 	gl.CompileShader(glObj(shader))
 }
 
+func (c context) DisableVertexAttribArray(index uint32) {
+	gl.DisableVertexAttribArray(index)
+}
+
 func (c context) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indexOffset uintptr, instancecount int32) {
 	gl.DrawElementsInstancedWithOffset(mode, count, xtype, indexOffset, instancecount)
 }
@@ -176,6 +188,10 @@ func (c context) FramebufferTexture2D(target uint32, attachment uint32, textarge
 	gl.FramebufferTexture2D(target, attachment, textarget, glObj(texture), level)
 }
 
+func (c context) GenerateMipmap(target uint32) {
+	gl.GenerateMipmap(target)
+}
+
 func (c context) GetProgramInfoLog(program any) string {
 	prog := glObj(program)
 	// get length
@@ -218,6 +234,11 @@ func (c context) LinkProgram(program any) {
 	gl.LinkProgram(glObj(program))
 }
 
+func (c context) ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels any) {
+	pix, _ := glPtr(pixels)
+	gl.ReadPixels(x, y, width, height, format, xtype, pix)
+}
+
 func (c context) RenderbufferStorageMultisample(target uint32, samples int32, internalformat uint32, width int32, height int32) {
 	gl.RenderbufferStorageMultisample(target, samples, internalformat, width, height)
 }
@@ -228,6 +249,10 @@ func (c context) ShaderSource(shader any, source string) {
 	free()
 }
 
+func (c context) TexParameteri(target uint32, pname uint32, param int32) {
+	gl.TexParameteri(target, pname, param)
+}
+
 func (c context) TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels any) {
 	pix, _ := glPtr(pixels)
 	gl.TexImage2D(target, level, internalformat, width, height, border, format, xtype, pix)
@@ -300,3 +325,35 @@ func (c context) Uniform3f(location any, v0 float32, v1 float32, v2 float32) {
 func (c context) Uniform4f(location any, v0 float32, v1 float32, v2 float32, v3 float32) {
 	gl.Uniform4f(glLocation(location), v0, v1, v2, v3)
 }
+
+// program binaries are core in OpenGL 4.1, but might be supported by older versions as an extension
+func (c context) SupportsProgramBinary() bool {
+	var formats int32
+	// stays 0 if the enum is unknown to the driver
+	gl.GetIntegerv(gl.NUM_PROGRAM_BINARY_FORMATS, &formats)
+	return formats > 0
+}
+
+func (c context) SetProgramBinaryRetrievable(program any) {
+	gl.ProgramParameteri(glObj(program), gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+}
+
+func (c context) GetProgramBinary(program any) (uint32, []byte) {
+	prog := glObj(program)
+	var length int32
+	gl.GetProgramiv(prog, gl.PROGRAM_BINARY_LENGTH, &length)
+	if length == 0 {
+		return 0, nil
+	}
+	binary := make([]byte, length)
+	var format uint32
+	gl.GetProgramBinary(prog, length, &length, &format, gl.Ptr(binary))
+	return format, binary[:length]
+}
+
+func (c context) ProgramBinary(program any, format uint32, binary []byte) {
+	if len(binary) == 0 {
+		return
+	}
+	gl.ProgramBinary(glObj(program), format, gl.Ptr(binary), int32(len(binary)))
+}
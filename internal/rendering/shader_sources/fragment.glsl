@@ -4,10 +4,48 @@ out vec4 FragColor;
 
 in vec4 vertexColor;
 flat in uint layer;
+// varyings from channels
+<varyings>
+
+uniform ivec2 screenSize;
+// seconds since start
+uniform float time;
+// set by the renderer, 0 draws normally, see gl.DebugMode
+uniform uint debugMode;
+// uniforms from channels
+<uniforms>
+
+// color for debugMode 1, neighbouring layers get very different hues
+vec3 dwLayerColor(uint l) {
+    // hashed as an int, since floats are not precise enough for high layers
+    float hue = float((l * 2654435761u) >> 8) / 16777216.0;
+    return clamp(abs(mod(hue * 6.0 + vec3(0.0, 4.0, 2.0), 6.0) - 3.0) - 1.0, 0.0, 1.0);
+}
+
+// functions from procedure
+<functions>
 
 void main() {
-    FragColor = vertexColor;
+    // builtin channels
+    // position of pixel with (0, 0) in top left, like the position channel
+    vec2 pixelPos = vec2(gl_FragCoord.x, float(screenSize.y) - gl_FragCoord.y);
+
+    // variables from channels
+    <variables>
+
+    // function calls from channels
+    <calls>
+
+    FragColor = <fragColor>;
     // maybe should be 1 higher but that would be bigger than int
     // 1/(2^24-1)
     gl_FragDepth = float(layer) * 5.96046448e-8;
+
+    if (debugMode == 1u) {
+        FragColor = vec4(dwLayerColor(layer), 1.0);
+    } else if (debugMode == 2u) {
+        // bounding boxes are drawn on top of everything
+        FragColor = vec4(1.0, 0.0, 1.0, 1.0);
+        gl_FragDepth = 1.0;
+    }
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+<version>
+
+// operation id and instance, read back by gl.PickingPass
+out uvec4 FragID;
+
+flat in uint layer;
+flat in int instanceID;
+
+uniform uint pickID;
+
+void main() {
+    FragID = uvec4(pickID, uint(instanceID), 0u, 0u);
+    // same as fragment.glsl, so layers are respected
+    gl_FragDepth = float(layer) * 5.96046448e-8;
+}
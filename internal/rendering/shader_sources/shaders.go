@@ -4,19 +4,23 @@ import (
 	_ "embed"
 )
 
-const VertexBaseInputAmt = 2
+const VertexBaseInputAmt = 3
 
 //go:embed vertex.glsl
 var VertexBaseSource string
 
-// not called base, sice it should not be modified
-//
 //go:embed fragment.glsl
-var FragmentSource string
+var FragmentBaseSource string
+
+// Fragment shader for picking, <version> must be replaced, since it is not made with a ShaderBuilder
+//
+//go:embed picking.glsl
+var PickingSource string
 
 func init() {
-	// to avoid sahder comp error
-	FragmentSource += "\x00"
 	// tecnically not required, since ShaderBuilder adds end automatically, but seems nice to do it here
+	FragmentBaseSource += "\x00"
 	VertexBaseSource += "\x00"
+	// required here, since it is given to the context directly
+	PickingSource += "\x00"
 }
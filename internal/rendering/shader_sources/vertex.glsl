@@ -2,13 +2,21 @@
 layout(location = 0) in vec2 aPos;
 // color.w/a/q is layer
 layout(location = 1) in uvec4 aColor;
+// (0, 0) if the sprite has no uvs
+layout(location = 2) in vec2 aUV;
 // layouts from channels
 <attributes>
 
 out vec4 vertexColor;
 flat out uint layer;
+// only used by the picking fragment shader
+flat out int instanceID;
+// varyings from channels
+<varyings>
 
 uniform ivec2 screenSize;
+// seconds since start
+uniform float time;
 // uniforms from channels
 <uniforms>
 
@@ -16,19 +24,28 @@ uniform ivec2 screenSize;
 <functions>
 
 void main() {
+    // builtin channels, gl_InstanceID and gl_VertexID are also builtin channels
+    // y is flipped to match the axis formula
+    vec2 vertexPos = vec2(aPos.x, -aPos.y);
+    vec2 vertexUV = aUV;
+
     // variables from channels
     <variables>
 
     // function calls from channels
     <calls>
 
+    // scaled and rotated vertex, not used if the vertex channel is set
+    vec2 axisPos = <xAxis> * vertexPos.x + <yAxis> * vertexPos.y;
+
     gl_Position = vec4(
         // position
-        (<xAxis> * aPos.x + <yAxis> * -aPos.y + <pos>) / vec2(screenSize) * vec2(2, -2) + vec2(-1, 1), 
+        (<vertex> + <pos>) / vec2(screenSize) * vec2(2, -2) + vec2(-1, 1), 
         0.0, 1.0
         //0.0, 0.0, 0.0, 1.0
     );
 
     vertexColor = vec4(<color>)/255.0;
     layer = <layer>*256u + aColor.a+1u;
+    instanceID = gl_InstanceID;
 }
\ No newline at end of file
@@ -34,26 +34,32 @@ type context struct {
 	bindRenderbuffer               js.Value
 	bindTexture                    js.Value
 	bindVertexArray                js.Value
+	activeTexture                  js.Value
 	attachShader                   js.Value
 	blitFramebuffer                js.Value
 	bufferData                     js.Value
 	clear                          js.Value
+	clearBufferuiv                 js.Value
 	clearColor                     js.Value
 	clearDepth                     js.Value
 	compileShader                  js.Value
+	disableVertexAttribArray       js.Value
 	drawElementsInstanced          js.Value
 	enableVertexAttribArray        js.Value
 	framebufferRenderbuffer        js.Value
 	framebufferTexture2D           js.Value
+	generateMipmap                 js.Value
 	getProgramInfoLog              js.Value
 	getProgramParameter            js.Value
 	getShaderInfoLog               js.Value
 	getUniformLocation             js.Value
 	getShaderParameter             js.Value
 	linkProgram                    js.Value
+	readPixels                     js.Value
 	renderbufferStorageMultisample js.Value
 	shaderSource                   js.Value
 	texImage2D                     js.Value
+	texParameteri                  js.Value
 	useProgram                     js.Value
 	vertexAttribDivisor            js.Value
 	vertexAttribIPointer           js.Value
@@ -98,26 +104,32 @@ func MakeContext(g js.Value) gl.Context {
 		bindRenderbuffer:               getFunction(g, "bindRenderbuffer"),
 		bindTexture:                    getFunction(g, "bindTexture"),
 		bindVertexArray:                getFunction(g, "bindVertexArray"),
+		activeTexture:                  getFunction(g, "activeTexture"),
 		attachShader:                   getFunction(g, "attachShader"),
 		blitFramebuffer:                getFunction(g, "blitFramebuffer"),
 		bufferData:                     getFunction(g, "bufferData"),
 		clear:                          getFunction(g, "clear"),
+		clearBufferuiv:                 getFunction(g, "clearBufferuiv"),
 		clearColor:                     getFunction(g, "clearColor"),
 		clearDepth:                     getFunction(g, "clearDepth"),
 		compileShader:                  getFunction(g, "compileShader"),
+		disableVertexAttribArray:       getFunction(g, "disableVertexAttribArray"),
 		drawElementsInstanced:          getFunction(g, "drawElementsInstanced"),
 		enableVertexAttribArray:        getFunction(g, "enableVertexAttribArray"),
 		framebufferRenderbuffer:        getFunction(g, "framebufferRenderbuffer"),
 		framebufferTexture2D:           getFunction(g, "framebufferTexture2D"),
+		generateMipmap:                 getFunction(g, "generateMipmap"),
 		getProgramInfoLog:              getFunction(g, "getProgramInfoLog"),
 		getProgramParameter:            getFunction(g, "getProgramParameter"),
 		getShaderInfoLog:               getFunction(g, "getShaderInfoLog"),
 		getUniformLocation:             getFunction(g, "getUniformLocation"),
 		getShaderParameter:             getFunction(g, "getShaderParameter"),
 		linkProgram:                    getFunction(g, "linkProgram"),
+		readPixels:                     getFunction(g, "readPixels"),
 		renderbufferStorageMultisample: getFunction(g, "renderbufferStorageMultisample"),
 		shaderSource:                   getFunction(g, "shaderSource"),
 		texImage2D:                     getFunction(g, "texImage2D"),
+		texParameteri:                  getFunction(g, "texParameteri"),
 		useProgram:                     getFunction(g, "useProgram"),
 		vertexAttribDivisor:            getFunction(g, "vertexAttribDivisor"),
 		vertexAttribIPointer:           getFunction(g, "vertexAttribIPointer"),
@@ -279,6 +291,10 @@ func (c *context) BindVertexArray(array any) {
 	c.bindVertexArray.Invoke(array)
 }
 
+func (c *context) ActiveTexture(texture uint32) {
+	c.activeTexture.Invoke(texture)
+}
+
 func (c *context) AttachShader(program any, shader any) {
 	c.attachShader.Invoke(program, shader)
 }
@@ -295,6 +311,14 @@ func (c *context) Clear(mask uint32) {
 	c.clear.Invoke(mask)
 }
 
+func (c *context) ClearBufferuiv(buffer uint32, drawbuffer int32, value []uint32) {
+	values := make([]any, len(value))
+	for i, v := range value {
+		values[i] = v
+	}
+	c.clearBufferuiv.Invoke(buffer, drawbuffer, values)
+}
+
 func (c *context) ClearColor(r float32, g float32, b float32, a float32) {
 	c.clearColor.Invoke(r, g, b, a)
 }
@@ -303,6 +327,10 @@ func (c *context) CompileShader(shader any) {
 	c.compileShader.Invoke(shader)
 }
 
+func (c *context) DisableVertexAttribArray(index uint32) {
+	c.disableVertexAttribArray.Invoke(index)
+}
+
 func (c *context) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indexOffset uintptr, instancecount int32) {
 	c.drawElementsInstanced.Invoke(mode, count, xtype, indexOffset, instancecount)
 }
@@ -319,6 +347,10 @@ func (c *context) FramebufferTexture2D(target uint32, attachment uint32, textarg
 	c.framebufferTexture2D.Invoke(target, attachment, textarget, texture, level)
 }
 
+func (c *context) GenerateMipmap(target uint32) {
+	c.generateMipmap.Invoke(target)
+}
+
 func (c *context) GetProgramInfoLog(program any) string {
 	return c.getProgramInfoLog.Invoke(program).String()
 }
@@ -343,6 +375,25 @@ func (c *context) LinkProgram(program any) {
 	c.linkProgram.Invoke(program)
 }
 
+func (c *context) ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels any) {
+	// WebGL needs a typed array matching xtype, the bytes are copied back afterwards
+	var bytes []byte
+	var arrayType string
+	switch v := pixels.(type) {
+	case []uint8:
+		bytes, arrayType = v, "Uint8Array"
+	case []uint16:
+		bytes, arrayType = unsafe.Slice((*byte)(unsafe.Pointer(&v[0])), len(v)*2), "Uint16Array"
+	case []uint32:
+		bytes, arrayType = unsafe.Slice((*byte)(unsafe.Pointer(&v[0])), len(v)*4), "Uint32Array"
+	default:
+		panic("WebGL2 implementation only supports uint[8,16,32] slices for ReadPixels")
+	}
+	buffer := js.Global().Get("ArrayBuffer").New(len(bytes))
+	c.readPixels.Invoke(x, y, width, height, format, xtype, js.Global().Get(arrayType).New(buffer))
+	js.CopyBytesToGo(bytes, js.Global().Get("Uint8Array").New(buffer))
+}
+
 func (c *context) RenderbufferStorageMultisample(target uint32, samples int32, internalformat uint32, width int32, height int32) {
 	c.renderbufferStorageMultisample.Invoke(target, samples, internalformat, width, height)
 }
@@ -352,7 +403,25 @@ func (c *context) ShaderSource(shader any, source string) {
 }
 
 func (c *context) TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels any) {
-	c.texImage2D.Invoke(target, level, internalformat, width, height, border, format, xtype, c.jsData(pixels))
+	c.texImage2D.Invoke(target, level, internalformat, width, height, border, format, xtype, jsPixels(pixels))
+}
+
+// textures need a typed array matching xtype, unlike buffers which accept the DataView from jsData
+func jsPixels(pixels any) js.Value {
+	switch v := pixels.(type) {
+	case nil:
+		return js.Null()
+	case []uint8:
+		arr := js.Global().Get("Uint8Array").New(len(v))
+		js.CopyBytesToJS(arr, v)
+		return arr
+	default:
+		panic("WebGL2 implementation only supports uint8 slices for textures")
+	}
+}
+
+func (c *context) TexParameteri(target uint32, pname uint32, param int32) {
+	c.texParameteri.Invoke(target, pname, param)
 }
 
 func (c *context) UseProgram(program any) {
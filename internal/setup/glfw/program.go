@@ -35,6 +35,10 @@ func (p *glfwProgram) Time() float64 {
 	return glfw.GetTime()
 }
 
+func (p *glfwProgram) SetProgramCacheDir(dir string) error {
+	return p.renderer.(*g.Renderer).SetProgramCacheDir(dir)
+}
+
 func NewProgram(width, height uint16, name string) program.Program {
 	// requird for OpenGL bindings (and i think also GLFW)
 	runtime.LockOSThread()
@@ -59,7 +63,7 @@ func NewProgram(width, height uint16, name string) program.Program {
 	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 	gl.ClearDepth(0)
 
-	return &glfwProgram{win, g.NewRenderer(
+	renderer := g.NewRenderer(
 		func() uint16 {
 			width, _ := win.WindowSize()
 			return uint16(width)
@@ -68,8 +72,11 @@ func NewProgram(width, height uint16, name string) program.Program {
 			_, height := win.WindowSize()
 			return uint16(height)
 		},
+		glfw.GetTime,
 		opengl.MakeContext(),
 		"#version 330 core",
 		false,
-	)}
+	)
+	win.frameEnd = renderer.EndFrame
+	return &glfwProgram{win, renderer}
 }
@@ -15,6 +15,8 @@ type window struct {
 	keyboard    *keyboard
 	mouse       *mouse
 	controller  controller
+	// called before swapping buffers, used to end the frame of the renderer
+	frameEnd func()
 }
 
 func makeWindow(width, height uint16, name string) *window {
@@ -74,6 +76,9 @@ func (w *window) WindowSize() (uint16, uint16) {
 }
 
 func (w *window) UpdateView() {
+	if w.frameEnd != nil {
+		w.frameEnd()
+	}
 	w.glfwWin.SwapBuffers()
 	glfw.PollEvents()
 }
@@ -55,6 +55,8 @@ func initApp(init func(app.App), update func()) {
 	if g.IsNull() {
 		panic("Failed to init webgl2, please try changing or updating your browser")
 	}
+	// app is made after the renderer, but the renderer needs the time
+	var a *webApp
 	// init renderer
 	r := gl.NewRenderer(
 		func() uint16 {
@@ -63,12 +65,15 @@ func initApp(init func(app.App), update func()) {
 		func() uint16 {
 			return uint16(win.Get("innerHeight").Int())
 		},
+		func() float64 {
+			return a.time
+		},
 		webgl.MakeContext(g),
 		"#version 300 es\nprecision highp float;\nprecision highp int;",
 		true,
 	)
 	// init app
-	a := &webApp{
+	a = &webApp{
 		renderer:   r,
 		updateFunc: update,
 		keyboard:   makeKeyboard(),
@@ -116,9 +121,15 @@ func (w *webApp) update(_ js.Value, args []js.Value) any {
 	w.canvas.Set("width", width)
 	w.canvas.Set("height", height)
 	w.time = args[0].Float() * 0.001
-	w.renderer.PrimaryRenderTarget().Resize(uint16(width), uint16(height))
+	// the override target is a normal RenderTarget, so this only fails if the setup is wrong
+	if err := w.renderer.PrimaryRenderTarget().Resize(uint16(width), uint16(height)); err != nil {
+		panic(err)
+	}
 	w.updateFunc()
-	w.renderer.PrimaryRenderTarget().BlitTo(w.renderer.RealPrimaryRenderTarget(), 0, 0)
+	if err := w.renderer.PrimaryRenderTarget().BlitTo(w.renderer.RealPrimaryRenderTarget(), 0, 0); err != nil {
+		panic(err)
+	}
+	w.renderer.EndFrame()
 	return nil
 }
 
@@ -0,0 +1,272 @@
+package zstd
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+const (
+	magic = 0xfd2fb528
+	// largest amount of data in a block
+	maxBlockSize = 128 << 10
+	// decoders may limit the window to 8 MB, so matches are never further back than that
+	maxOffset = 8 << 20
+	minMatch  = 4
+	hashBits  = 16
+)
+
+// a match of MatchLength bytes Offset bytes back, after LiteralLength literals
+type sequence struct {
+	literalLength, matchLength, offset uint32
+}
+
+// Compresses data into a single zstd frame with a checksum
+// Matches are found greedily and coded with the predefined tables, so it compresses less than the zstd tool, but every zstd decoder can read it
+func Compress(data []byte) []byte {
+	out := binary.LittleEndian.AppendUint32(make([]byte, 0, len(data)/2+32), magic)
+	// single segment, so the window is the content size and no window descriptor is needed
+	descriptor := byte(1<<5 | 1<<2)
+	size := uint64(len(data))
+	switch {
+	case size < 256:
+		out = append(out, descriptor, byte(size))
+	case size < 256+math.MaxUint16+1:
+		out = append(out, 1<<6|descriptor)
+		out = binary.LittleEndian.AppendUint16(out, uint16(size-256))
+	case size <= math.MaxUint32:
+		out = append(out, 2<<6|descriptor)
+		out = binary.LittleEndian.AppendUint32(out, uint32(size))
+	default:
+		out = append(out, 3<<6|descriptor)
+		out = binary.LittleEndian.AppendUint64(out, size)
+	}
+	e := &encoder{data: data, table: make([]int32, 1<<hashBits)}
+	for start := 0; ; {
+		end := min(start+maxBlockSize, len(data))
+		out = e.block(out, start, end, end == len(data))
+		if end == len(data) {
+			break
+		}
+		start = end
+	}
+	var checksum xxhash64
+	checksum.reset()
+	checksum.update(data)
+	return binary.LittleEndian.AppendUint32(out, uint32(checksum.digest()))
+}
+
+type encoder struct {
+	data []byte
+	// position+1 of the last 4 bytes with the hash, 0 if there is none
+	table []int32
+}
+
+func (e *encoder) hash(i int) uint32 {
+	return (binary.LittleEndian.Uint32(e.data[i:]) * 2654435761) >> (32 - hashBits)
+}
+
+// adds the block with data[start:end], raw if compressing does not make it smaller
+func (e *encoder) block(out []byte, start, end int, last bool) []byte {
+	header := uint32(0)
+	if last {
+		header = 1
+	}
+	literals, sequences := e.findMatches(start, end)
+	if compressed := compressBlock(literals, sequences); len(compressed) < end-start {
+		header |= 2<<1 | uint32(len(compressed))<<3
+		out = append(out, byte(header), byte(header>>8), byte(header>>16))
+		return append(out, compressed...)
+	}
+	header |= uint32(end-start) << 3
+	out = append(out, byte(header), byte(header>>8), byte(header>>16))
+	return append(out, e.data[start:end]...)
+}
+
+func (e *encoder) findMatches(start, end int) ([]byte, []sequence) {
+	literals := make([]byte, 0)
+	sequences := make([]sequence, 0)
+	literalStart := start
+	for i := start; i+minMatch <= end; {
+		h := e.hash(i)
+		candidate := int(e.table[h]) - 1
+		e.table[h] = int32(i + 1)
+		if candidate < 0 || i-candidate > maxOffset || binary.LittleEndian.Uint32(e.data[candidate:]) != binary.LittleEndian.Uint32(e.data[i:]) {
+			i++
+			continue
+		}
+		length := minMatch
+		for i+length < end && e.data[candidate+length] == e.data[i+length] {
+			length++
+		}
+		literals = append(literals, e.data[literalStart:i]...)
+		sequences = append(sequences, sequence{uint32(i - literalStart), uint32(length), uint32(i - candidate)})
+		// the rest of the match is added, so later matches can start inside it
+		for j := i + 1; j < i+length && j+minMatch <= len(e.data); j++ {
+			e.table[e.hash(j)] = int32(j + 1)
+		}
+		i += length
+		literalStart = i
+	}
+	return append(literals, e.data[literalStart:end]...), sequences
+}
+
+// literals section and sequences section of a compressed block
+// literals are stored raw, and the sequences use the predefined FSE tables
+func compressBlock(literals []byte, sequences []sequence) []byte {
+	out := make([]byte, 0, len(literals)+len(sequences)*4+8)
+	// Raw_Literals_Block, with the smallest size format that fits
+	switch n := len(literals); {
+	case n < 1<<5:
+		out = append(out, byte(n<<3))
+	case n < 1<<12:
+		out = append(out, byte(1<<2|n<<4), byte(n>>4))
+	default:
+		out = append(out, byte(3<<2|n<<4), byte(n>>4), byte(n>>12))
+	}
+	out = append(out, literals...)
+	switch n := len(sequences); {
+	case n == 0:
+		return append(out, 0)
+	case n < 128:
+		out = append(out, byte(n))
+	case n < 0x7f00:
+		out = append(out, byte(n>>8+128), byte(n))
+	default:
+		out = append(out, 255, byte(n-0x7f00), byte((n-0x7f00)>>8))
+	}
+	// predefined mode for every table
+	out = append(out, 0)
+
+	codes := [3]*fseEncoder{literalEncoder, offsetEncoder, matchEncoder}
+	values := func(s sequence) [3]uint32 {
+		// offset values 1 to 3 are repeated offsets, so 3 is added
+		return [3]uint32{s.literalLength, s.offset + 3, s.matchLength}
+	}
+	// the states are found backwards, since the state of a sequence depends on the state of the next one
+	states := make([][3]uint16, len(sequences))
+	for i := len(sequences) - 1; i >= 0; i-- {
+		v := values(sequences[i])
+		for k, code := range codes {
+			next := uint16(0)
+			if i+1 < len(sequences) {
+				next = states[i+1][k]
+			}
+			states[i][k] = code.states[code.symbol(v[k])][next]
+		}
+	}
+	// the decoder reads backwards, so everything is written in the opposite order of how it is read
+	w := &bitWriter{out: out}
+	for i := len(sequences) - 1; i >= 0; i-- {
+		v := values(sequences[i])
+		for _, k := range []seqCode{seqLiteral, seqMatch, seqOffset} {
+			entry := codes[k].table[states[i][k]]
+			w.add(v[k]-codes[k].baseline(entry), entry.basebits)
+		}
+		if i == 0 {
+			break
+		}
+		// going from the state of the previous sequence to this one
+		for _, k := range []seqCode{seqOffset, seqMatch, seqLiteral} {
+			entry := codes[k].table[states[i-1][k]]
+			w.add(uint32(states[i][k]-entry.base), entry.bits)
+		}
+	}
+	for _, k := range []seqCode{seqMatch, seqOffset, seqLiteral} {
+		w.add(uint32(states[0][k]), codes[k].tableBits)
+	}
+	return w.close()
+}
+
+// encodes with one of the predefined tables
+type fseEncoder struct {
+	table     []fseBaselineEntry
+	tableBits uint8
+	// the values of a symbol are baseline to baseline + 1<<basebits
+	symbols []fseBaselineEntry
+	// by symbol and next state, the state that goes to the next state
+	states [][]uint16
+	// offsets are stored with 3 added, but the decoding table has them without it
+	offset bool
+}
+
+var (
+	literalEncoder = newFSEEncoder(predefinedLiteralTable[:], 6, false)
+	offsetEncoder  = newFSEEncoder(predefinedOffsetTable[:], 5, true)
+	matchEncoder   = newFSEEncoder(predefinedMatchTable[:], 6, false)
+)
+
+func newFSEEncoder(table []fseBaselineEntry, tableBits uint8, offset bool) *fseEncoder {
+	e := &fseEncoder{table: table, tableBits: tableBits, offset: offset}
+	for state, entry := range table {
+		symbol := -1
+		for i, s := range e.symbols {
+			if s.baseline == entry.baseline && s.basebits == entry.basebits {
+				symbol = i
+			}
+		}
+		if symbol == -1 {
+			symbol = len(e.symbols)
+			e.symbols = append(e.symbols, fseBaselineEntry{baseline: entry.baseline, basebits: entry.basebits})
+			e.states = append(e.states, make([]uint16, len(table)))
+		}
+		// the states of a symbol split the table between them, so every next state has exactly one
+		for next := int(entry.base); next < int(entry.base)+1<<entry.bits; next++ {
+			e.states[symbol][next] = uint16(state)
+		}
+	}
+	return e
+}
+
+func (e *fseEncoder) baseline(entry fseBaselineEntry) uint32 {
+	if e.offset {
+		return entry.baseline + 3
+	}
+	return entry.baseline
+}
+
+// index of the symbol the value is coded with
+func (e *fseEncoder) symbol(value uint32) int {
+	if e.offset {
+		// offset values are split by their highest bit
+		code := uint8(bits.Len32(value) - 1)
+		for i, s := range e.symbols {
+			if s.basebits == code {
+				return i
+			}
+		}
+		panic("zstd: offset is too large")
+	}
+	for i, s := range e.symbols {
+		if value >= s.baseline && value-s.baseline < 1<<s.basebits {
+			return i
+		}
+	}
+	panic("zstd: length is too large")
+}
+
+// writes bits from the lowest bit of the first byte
+type bitWriter struct {
+	out   []byte
+	bits  uint64
+	count uint8
+}
+
+func (w *bitWriter) add(value uint32, count uint8) {
+	w.bits |= uint64(value) << w.count
+	w.count += count
+	for w.count >= 8 {
+		w.out = append(w.out, byte(w.bits))
+		w.bits >>= 8
+		w.count -= 8
+	}
+}
+
+// ends the stream with a 1 bit, so the decoder knows where the last byte starts
+func (w *bitWriter) close() []byte {
+	w.add(1, 1)
+	if w.count > 0 {
+		w.out = append(w.out, byte(w.bits))
+	}
+	return w.out
+}
@@ -0,0 +1,62 @@
+package zstd
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, 300000)
+	rng.Read(random)
+	// short random runs and copies of earlier data, so there are many matches of different lengths and offsets
+	mixed := make([]byte, 0, 1<<20)
+	for len(mixed) < 1<<20 {
+		n := rng.Intn(50)
+		if rng.Intn(2) == 0 && len(mixed) > 1000 {
+			start := rng.Intn(len(mixed) - 100)
+			mixed = append(mixed, mixed[start:start+n]...)
+		} else {
+			for i := 0; i < n; i++ {
+				mixed = append(mixed, byte('a'+rng.Intn(6)))
+			}
+		}
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"one byte", []byte("a")},
+		{"short", []byte("hello hello hello hello hello")},
+		{"two byte size", bytes.Repeat([]byte("ab"), 1000)},
+		{"repeated", bytes.Repeat([]byte("abcdefgh"), 100000)},
+		{"random", random},
+		{"mixed", mixed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			compressed := Compress(test.data)
+			data, err := io.ReadAll(NewReader(bytes.NewReader(compressed)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(data, test.data) {
+				t.Fatalf("Decompressed %v bytes, compressed %v", len(data), len(test.data))
+			}
+			// random data is stored in raw blocks, so it only grows by the headers
+			if len(compressed) > len(test.data)+len(test.data)/maxBlockSize*3+32 {
+				t.Fatalf("Compressed %v bytes to %v", len(test.data), len(compressed))
+			}
+		})
+	}
+}
+
+func TestCompressRepeated(t *testing.T) {
+	data := bytes.Repeat([]byte("abcdefgh"), 100000)
+	if n := len(Compress(data)); n > 1000 {
+		t.Fatalf("Compressed %v repeated bytes to %v", len(data), n)
+	}
+}
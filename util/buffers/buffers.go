@@ -10,3 +10,21 @@ func AddTo[Buffer any, Elem any](buffer *[]Buffer, elem Elem) {
 func SetAt[Buffer any, Elem any](buffer *[]Buffer, idx int, elem Elem) {
 	(*buffer)[idx] = *(*Buffer)(unsafe.Pointer(&elem))
 }
+
+// Same as AddTo, but adds all of elem, even if it is bigger than a single Buffer
+// The size of Elem must be a multiple of the size of Buffer
+func AddAll[Buffer any, Elem any](buffer *[]Buffer, elem Elem) {
+	var b Buffer
+	amt := unsafe.Sizeof(elem) / unsafe.Sizeof(b)
+	if amt*unsafe.Sizeof(b) != unsafe.Sizeof(elem) {
+		panic("size of element must be a multiple of the size of the buffer type")
+	}
+	if amt == 0 {
+		return
+	}
+	start := len(*buffer)
+	*buffer = append(*buffer, make([]Buffer, amt)...)
+	// copying bytes, since elem might not be aligned like Buffer
+	dst := unsafe.Slice((*byte)(unsafe.Pointer(&(*buffer)[start])), unsafe.Sizeof(elem))
+	copy(dst, unsafe.Slice((*byte)(unsafe.Pointer(&elem)), unsafe.Sizeof(elem)))
+}